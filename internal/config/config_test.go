@@ -134,6 +134,7 @@ func TestNewWechatConfig_Defaults(t *testing.T) {
 	assert.Empty(t, cfg.NotifyURL)
 	assert.Empty(t, cfg.AdminPhone)
 	assert.Empty(t, cfg.AdminOpenID)
+	assert.Empty(t, cfg.PublishTemplateID)
 }
 
 func TestNewWechatConfig_CustomEnv(t *testing.T) {
@@ -144,6 +145,7 @@ func TestNewWechatConfig_CustomEnv(t *testing.T) {
 	os.Setenv("WECHAT_NOTIFY_URL", "https://example.com/notify")
 	os.Setenv("WECHAT_ADMIN_PHONE", "13928998540")
 	os.Setenv("WECHAT_ADMIN_OPEN_ID", "openid_admin")
+	os.Setenv("WECHAT_PUBLISH_TEMPLATE_ID", "tpl_publish")
 	defer func() {
 		os.Unsetenv("WECHAT_APPID")
 		os.Unsetenv("WECHAT_SECRET")
@@ -152,6 +154,7 @@ func TestNewWechatConfig_CustomEnv(t *testing.T) {
 		os.Unsetenv("WECHAT_NOTIFY_URL")
 		os.Unsetenv("WECHAT_ADMIN_PHONE")
 		os.Unsetenv("WECHAT_ADMIN_OPEN_ID")
+		os.Unsetenv("WECHAT_PUBLISH_TEMPLATE_ID")
 	}()
 
 	cfg := NewWechatConfig()
@@ -162,6 +165,7 @@ func TestNewWechatConfig_CustomEnv(t *testing.T) {
 	assert.Equal(t, "https://example.com/notify", cfg.NotifyURL)
 	assert.Equal(t, "13928998540", cfg.AdminPhone)
 	assert.Equal(t, "openid_admin", cfg.AdminOpenID)
+	assert.Equal(t, "tpl_publish", cfg.PublishTemplateID)
 }
 
 // ===== Crypto Config Tests =====
@@ -179,3 +183,19 @@ func TestNewCryptoConfig_CustomEnv(t *testing.T) {
 	cfg := NewCryptoConfig()
 	assert.Equal(t, "custom-crypto-key-for-testing!!!", cfg.Key)
 }
+
+// ===== Scheduler Config Tests =====
+
+func TestNewSchedulerConfig_Defaults(t *testing.T) {
+	cfg := NewSchedulerConfig()
+	assert.Equal(t, "warn", cfg.CapacityPolicy)
+	assert.Equal(t, "America/Chicago", cfg.Timezone)
+}
+
+func TestNewSchedulerConfig_CustomEnv(t *testing.T) {
+	os.Setenv("SCHEDULER_CAPACITY_POLICY", "reject")
+	defer os.Unsetenv("SCHEDULER_CAPACITY_POLICY")
+
+	cfg := NewSchedulerConfig()
+	assert.Equal(t, "reject", cfg.CapacityPolicy)
+}
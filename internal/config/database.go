@@ -90,6 +90,7 @@ func Provide() fx.Option {
 		fx.Provide(NewJWTConfig),
 		fx.Provide(NewWechatConfig),
 		fx.Provide(NewCryptoConfig),
+		fx.Provide(NewSchedulerConfig),
 		fx.Provide(NewDatabase),
 	)
 }
@@ -0,0 +1,17 @@
+package config
+
+// SchedulerConfig 调度业务配置
+type SchedulerConfig struct {
+	// CapacityPolicy 班次超载时的全局处理策略：warn / reject / allow_with_reason，班次可单独覆盖。
+	CapacityPolicy string `yaml:"capacityPolicy"`
+	// Timezone 调度业务所用的 IANA 时区：解释录入的日期时间并输出日历订阅。
+	Timezone string `yaml:"timezone"`
+}
+
+// NewSchedulerConfig 创建调度配置
+func NewSchedulerConfig() *SchedulerConfig {
+	return &SchedulerConfig{
+		CapacityPolicy: getEnvOrConfig("SCHEDULER_CAPACITY_POLICY", "scheduler.capacityPolicy", "warn"),
+		Timezone:       getEnvOrConfig("SCHEDULER_TIMEZONE", "scheduler.timezone", "America/Chicago"),
+	}
+}
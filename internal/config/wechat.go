@@ -9,17 +9,20 @@ type WechatConfig struct {
 	NotifyURL   string `yaml:"notifyUrl"`
 	AdminPhone  string `yaml:"adminPhone"`
 	AdminOpenID string `yaml:"adminOpenId"`
+	// PublishTemplateID 班次发布订阅消息模板，留空则不发送通知。
+	PublishTemplateID string `yaml:"publishTemplateId"`
 }
 
 // NewWechatConfig 创建微信配置
 func NewWechatConfig() *WechatConfig {
 	return &WechatConfig{
-		AppID:       getEnvOrConfig("WECHAT_APPID", "wechat.appId", ""),
-		AppSecret:   getEnvOrConfig("WECHAT_SECRET", "wechat.appSecret", ""),
-		MchID:       getEnvOrConfig("WECHAT_MCH_ID", "wechat.mchId", ""),
-		MchKey:      getEnvOrConfig("WECHAT_MCH_KEY", "wechat.mchKey", ""),
-		NotifyURL:   getEnvOrConfig("WECHAT_NOTIFY_URL", "wechat.notifyUrl", ""),
-		AdminPhone:  getEnvOrConfig("WECHAT_ADMIN_PHONE", "wechat.adminPhone", ""),
-		AdminOpenID: getEnvOrConfig("WECHAT_ADMIN_OPEN_ID", "wechat.adminOpenId", ""),
+		AppID:             getEnvOrConfig("WECHAT_APPID", "wechat.appId", ""),
+		AppSecret:         getEnvOrConfig("WECHAT_SECRET", "wechat.appSecret", ""),
+		MchID:             getEnvOrConfig("WECHAT_MCH_ID", "wechat.mchId", ""),
+		MchKey:            getEnvOrConfig("WECHAT_MCH_KEY", "wechat.mchKey", ""),
+		NotifyURL:         getEnvOrConfig("WECHAT_NOTIFY_URL", "wechat.notifyUrl", ""),
+		AdminPhone:        getEnvOrConfig("WECHAT_ADMIN_PHONE", "wechat.adminPhone", ""),
+		AdminOpenID:       getEnvOrConfig("WECHAT_ADMIN_OPEN_ID", "wechat.adminOpenId", ""),
+		PublishTemplateID: getEnvOrConfig("WECHAT_PUBLISH_TEMPLATE_ID", "wechat.publishTemplateId", ""),
 	}
 }
@@ -12,6 +12,7 @@ import (
 	"pickup/internal/config"
 	"pickup/internal/model"
 	schedulercontrollers "pickup/internal/scheduler/controllers"
+	schedulerservice "pickup/internal/scheduler/service"
 	"pickup/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -1572,7 +1573,12 @@ func TestAdminHandler_ExportDatabaseFields_Error(t *testing.T) {
 func TestNewRouterConfig(t *testing.T) {
 	authCtl := schedulercontrollers.NewAuthController(nil)
 	studentCtl := schedulercontrollers.NewStudentController(nil)
-	adminCtl := schedulercontrollers.NewAdminController(nil)
+	adminCtl := schedulercontrollers.NewAdminController(nil, time.UTC)
+	staffCtl := schedulercontrollers.NewStaffController(nil, time.UTC)
+	driverCtl := schedulercontrollers.NewDriverController(nil, time.UTC)
+	calendarCtl := schedulercontrollers.NewCalendarController(nil)
+	streamCtl := schedulercontrollers.NewStreamController(nil)
+	idempotency := schedulerservice.NewIdempotencyService(nil)
 
 	jwtCfg := &config.JWTConfig{
 		Secret:     "test-secret",
@@ -1580,18 +1586,28 @@ func TestNewRouterConfig(t *testing.T) {
 		Issuer:     "test",
 	}
 
-	rc := NewRouterConfig(authCtl, studentCtl, adminCtl, jwtCfg)
+	rc := NewRouterConfig(authCtl, studentCtl, adminCtl, staffCtl, driverCtl, calendarCtl, streamCtl, idempotency, jwtCfg)
 	require.NotNil(t, rc)
 	assert.Equal(t, authCtl, rc.AuthController)
 	assert.Equal(t, studentCtl, rc.StudentController)
 	assert.Equal(t, adminCtl, rc.AdminController)
+	assert.Equal(t, staffCtl, rc.StaffController)
+	assert.Equal(t, driverCtl, rc.DriverController)
+	assert.Equal(t, calendarCtl, rc.CalendarController)
+	assert.Equal(t, streamCtl, rc.StreamController)
+	assert.Equal(t, idempotency, rc.Idempotency)
 	assert.Equal(t, jwtCfg, rc.JWTConfig)
 }
 
 func TestSetupRoutes_HealthCheck(t *testing.T) {
 	authCtl := schedulercontrollers.NewAuthController(nil)
 	studentCtl := schedulercontrollers.NewStudentController(nil)
-	adminCtl := schedulercontrollers.NewAdminController(nil)
+	adminCtl := schedulercontrollers.NewAdminController(nil, time.UTC)
+	staffCtl := schedulercontrollers.NewStaffController(nil, time.UTC)
+	driverCtl := schedulercontrollers.NewDriverController(nil, time.UTC)
+	calendarCtl := schedulercontrollers.NewCalendarController(nil)
+	streamCtl := schedulercontrollers.NewStreamController(nil)
+	idempotency := schedulerservice.NewIdempotencyService(nil)
 
 	jwtCfg := &config.JWTConfig{
 		Secret:     "test-secret",
@@ -1599,7 +1615,7 @@ func TestSetupRoutes_HealthCheck(t *testing.T) {
 		Issuer:     "test",
 	}
 
-	rc := NewRouterConfig(authCtl, studentCtl, adminCtl, jwtCfg)
+	rc := NewRouterConfig(authCtl, studentCtl, adminCtl, staffCtl, driverCtl, calendarCtl, streamCtl, idempotency, jwtCfg)
 
 	router := gin.New()
 	rc.SetupRoutes(router)
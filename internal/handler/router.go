@@ -4,6 +4,7 @@ import (
 	"pickup/internal/config"
 	"pickup/internal/scheduler/controllers"
 	"pickup/internal/scheduler/routes"
+	"pickup/internal/scheduler/service"
 	"pickup/internal/utils"
 	"pickup/pkg/server"
 
@@ -13,10 +14,15 @@ import (
 
 // RouterConfig 路由配置
 type RouterConfig struct {
-	AuthController    *controllers.AuthController
-	StudentController *controllers.StudentController
-	AdminController   *controllers.AdminController
-	JWTConfig         *config.JWTConfig
+	AuthController     *controllers.AuthController
+	StudentController  *controllers.StudentController
+	AdminController    *controllers.AdminController
+	StaffController    *controllers.StaffController
+	DriverController   *controllers.DriverController
+	CalendarController *controllers.CalendarController
+	StreamController   *controllers.StreamController
+	Idempotency        *service.IdempotencyService
+	JWTConfig          *config.JWTConfig
 }
 
 // NewRouterConfig 创建路由配置
@@ -24,13 +30,23 @@ func NewRouterConfig(
 	authController *controllers.AuthController,
 	studentController *controllers.StudentController,
 	adminController *controllers.AdminController,
+	staffController *controllers.StaffController,
+	driverController *controllers.DriverController,
+	calendarController *controllers.CalendarController,
+	streamController *controllers.StreamController,
+	idempotency *service.IdempotencyService,
 	jwtConfig *config.JWTConfig,
 ) *RouterConfig {
 	return &RouterConfig{
-		AuthController:    authController,
-		StudentController: studentController,
-		AdminController:   adminController,
-		JWTConfig:         jwtConfig,
+		AuthController:     authController,
+		StudentController:  studentController,
+		AdminController:    adminController,
+		StaffController:    staffController,
+		DriverController:   driverController,
+		CalendarController: calendarController,
+		StreamController:   streamController,
+		Idempotency:        idempotency,
+		JWTConfig:          jwtConfig,
 	}
 }
 
@@ -38,7 +54,7 @@ func NewRouterConfig(
 func (rc *RouterConfig) SetupRoutes(r *gin.Engine) {
 	// 创建JWT工具
 	jwtUtil := utils.NewJWTUtil(rc.JWTConfig.Secret, rc.JWTConfig.ExpireTime, rc.JWTConfig.Issuer)
-	routes.RegisterRoutes(r, rc.AuthController, rc.StudentController, rc.AdminController, jwtUtil)
+	routes.RegisterRoutes(r, rc.AuthController, rc.StudentController, rc.AdminController, rc.StaffController, rc.DriverController, rc.CalendarController, rc.StreamController, rc.Idempotency, jwtUtil)
 }
 
 // Provide 提供依赖注入
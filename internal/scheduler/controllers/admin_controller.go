@@ -1,10 +1,14 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"pickup/internal/scheduler/middlewares"
+	"pickup/internal/scheduler/models"
 	"pickup/internal/scheduler/service"
 
 	"github.com/gin-gonic/gin"
@@ -12,15 +16,18 @@ import (
 
 type AdminController struct {
 	svc *service.AdminService
+	// loc 解释请求中不带时区的日期时间，见 service.NewSchedulerLocation。
+	loc *time.Location
 }
 
-func NewAdminController(svc *service.AdminService) *AdminController {
-	return &AdminController{svc: svc}
+func NewAdminController(svc *service.AdminService, loc *time.Location) *AdminController {
+	return &AdminController{svc: svc, loc: loc}
 }
 
 type createShiftRequest struct {
-	DriverID      uint   `json:"driver_id" binding:"required"`
-	DepartureTime string `json:"departure_time" binding:"required"`
+	DriverID         uint   `json:"driver_id" binding:"required"`
+	DepartureTime    string `json:"departure_time" binding:"required"`
+	EstimatedMinutes int    `json:"estimated_minutes"`
 }
 
 type createDriverRequest struct {
@@ -32,11 +39,13 @@ type createDriverRequest struct {
 }
 
 type assignStudentRequest struct {
-	RequestID uint `json:"request_id" binding:"required"`
+	RequestID uint   `json:"request_id" binding:"required"`
+	Reason    string `json:"reason"`
 }
 
 type assignStaffRequest struct {
-	StaffID uint `json:"staff_id" binding:"required"`
+	StaffID uint   `json:"staff_id" binding:"required"`
+	Reason  string `json:"reason"`
 }
 
 type updateDriverRequest struct {
@@ -47,13 +56,69 @@ type updateDriverRequest struct {
 	MaxCarryOn int    `json:"max_carry_on" binding:"required"`
 }
 
+type terminalRequest struct {
+	AirportCode   string `json:"airport_code" binding:"required"`
+	Terminal      string `json:"terminal" binding:"required"`
+	International bool   `json:"international"`
+	BufferMinutes int    `json:"buffer_minutes" binding:"min=0"`
+	MeetingPoint  string `json:"meeting_point"`
+}
+
+func (r terminalRequest) dto() service.TerminalDTO {
+	return service.TerminalDTO{
+		AirportCode:   r.AirportCode,
+		Terminal:      r.Terminal,
+		International: r.International,
+		BufferMinutes: r.BufferMinutes,
+		MeetingPoint:  r.MeetingPoint,
+	}
+}
+
 type updateShiftRequest struct {
-	DriverID      *uint   `json:"driver_id"`
-	DepartureTime *string `json:"departure_time"`
+	DriverID         *uint                  `json:"driver_id"`
+	DepartureTime    *string                `json:"departure_time"`
+	EstimatedMinutes *int                   `json:"estimated_minutes"`
+	CapacityPolicy   *models.CapacityPolicy `json:"capacity_policy"`
+}
+
+type updateRequestRequest struct {
+	Passengers  *int `json:"passengers"`
+	CheckedBags *int `json:"checked_bags"`
+	CarryOnBags *int `json:"carry_on_bags"`
+}
+
+type planPreviewRequest struct {
+	From              string `json:"from" binding:"required"`
+	To                string `json:"to" binding:"required"`
+	WindowMinutes     int    `json:"window_minutes"`
+	TurnaroundMinutes int    `json:"turnaround_minutes"`
+	ReserveSeats      int    `json:"reserve_seats"`
+}
+
+type planCommitRequest struct {
+	Shifts []service.PlannedShift `json:"shifts" binding:"required"`
+}
+
+// scoped 支持列表接口通过 ?campaign_id= 查看非当前活动的数据。
+func (ctl *AdminController) scoped(c *gin.Context) (*service.AdminService, bool) {
+	raw := c.Query("campaign_id")
+	if raw == "" {
+		return ctl.svc, true
+	}
+	campaignID, err := parseID(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid campaign id"})
+		return nil, false
+	}
+	return ctl.svc.WithCampaign(campaignID), true
 }
 
 func (ctl *AdminController) ListDrivers(c *gin.Context) {
-	res, err := ctl.svc.ListDrivers()
+	svc, ok := ctl.scoped(c)
+	if !ok {
+		return
+	}
+	res, err := svc.ListDrivers(c.Query("include_archived") == "true")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -67,7 +132,7 @@ func (ctl *AdminController) CreateDriver(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	driver, err := ctl.svc.CreateDriver(service.DriverDTO{
+	driver, err := ctl.svc.WithActor(auditActor(c)).CreateDriver(service.DriverDTO{
 		Name:       input.Name,
 		CarModel:   input.CarModel,
 		MaxSeats:   input.MaxSeats,
@@ -78,6 +143,7 @@ func (ctl *AdminController) CreateDriver(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	setETag(c, driver.Version)
 	c.JSON(http.StatusCreated, driver)
 }
 
@@ -87,6 +153,10 @@ func (ctl *AdminController) UpdateDriver(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid driver id"})
 		return
 	}
+	ifMatch, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
 
 	var input updateDriverRequest
 	if err = c.ShouldBindJSON(&input); err != nil {
@@ -94,39 +164,126 @@ func (ctl *AdminController) UpdateDriver(c *gin.Context) {
 		return
 	}
 
-	driver, err := ctl.svc.UpdateDriver(driverID, service.DriverDTO{
+	driver, err := ctl.svc.WithActor(auditActor(c)).UpdateDriver(driverID, service.DriverDTO{
 		Name:       input.Name,
 		CarModel:   input.CarModel,
 		MaxSeats:   input.MaxSeats,
 		MaxChecked: input.MaxChecked,
 		MaxCarryOn: input.MaxCarryOn,
+		IfMatch:    ifMatch,
 	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, service.ErrDriverNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrVersionMismatch):
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
+	setETag(c, driver.Version)
 	c.JSON(http.StatusOK, driver)
 }
 
 func (ctl *AdminController) Dashboard(c *gin.Context) {
-	res, err := ctl.svc.DashboardShifts()
+	svc, ok := ctl.scoped(c)
+	if !ok {
+		return
+	}
+	filter := service.ShiftFilter{
+		Terminal: c.Query("terminal"),
+		FlightNo: c.Query("flight_no"),
+	}
+	if !ctl.bindListQuery(c, &filter.From, &filter.To, &filter.PageQuery) {
+		return
+	}
+	if raw := c.Query("driver_id"); raw != "" {
+		driverID, err := parseID(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid driver_id"})
+			return
+		}
+		filter.DriverID = driverID
+	}
+	if raw := c.Query("status"); raw != "" {
+		for _, status := range strings.Split(raw, ",") {
+			filter.Statuses = append(filter.Statuses, models.ShiftStatus(strings.TrimSpace(status)))
+		}
+	}
+	res, err := svc.DashboardShifts(filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, res)
 }
 
 func (ctl *AdminController) PendingRequests(c *gin.Context) {
-	res, err := ctl.svc.PendingRequests()
+	svc, ok := ctl.scoped(c)
+	if !ok {
+		return
+	}
+	filter := service.RequestFilter{
+		Airport:  c.Query("airport"),
+		Terminal: c.Query("terminal"),
+		FlightNo: c.Query("flight_no"),
+	}
+	if !ctl.bindListQuery(c, &filter.From, &filter.To, &filter.PageQuery) {
+		return
+	}
+	res, err := svc.PendingRequests(filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, res)
 }
 
+// bindListQuery 解析分页列表共用的 from/to/sort/cursor/limit 参数，失败时已写入 400。
+func (ctl *AdminController) bindListQuery(c *gin.Context, from, to **time.Time, page *service.PageQuery) bool {
+	if raw := c.Query("from"); raw != "" {
+		t, err := parseQueryTime(raw, false, ctl.loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+			return false
+		}
+		*from = &t
+	}
+	if raw := c.Query("to"); raw != "" {
+		t, err := parseQueryTime(raw, true, ctl.loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+			return false
+		}
+		*to = &t
+	}
+	page.Sort = c.Query("sort")
+	page.Cursor = c.Query("cursor")
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return false
+		}
+		page.Limit = limit
+	}
+	return true
+}
+
+// listErrorStatus 查询参数错误返回 400，其余为 500。
+func listErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidSort), errors.Is(err, service.ErrInvalidCursor),
+		errors.Is(err, service.ErrInvalidLimit), errors.Is(err, service.ErrInvalidShiftStatus):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func (ctl *AdminController) ListUsers(c *gin.Context) {
 	res, err := ctl.svc.ListUsers()
 	if err != nil {
@@ -142,7 +299,7 @@ func (ctl *AdminController) SetStaff(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
 		return
 	}
-	user, err := ctl.svc.SetUserStaff(userID)
+	user, err := ctl.svc.WithActor(auditActor(c)).SetUserStaff(userID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -156,7 +313,7 @@ func (ctl *AdminController) UnsetStaff(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
 		return
 	}
-	user, err := ctl.svc.UnsetUserStaff(userID)
+	user, err := ctl.svc.WithActor(auditActor(c)).UnsetUserStaff(userID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -164,22 +321,36 @@ func (ctl *AdminController) UnsetStaff(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// shiftErrorStatus 司机或志愿者时段重叠返回 409，版本过期返回 412，其余按请求错误处理。
+func shiftErrorStatus(err error) int {
+	var overlap *service.DriverOverlapError
+	var staffOverlap *service.StaffOverlapError
+	switch {
+	case errors.As(err, &overlap), errors.As(err, &staffOverlap), errors.Is(err, service.ErrDriverArchived):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	}
+	return http.StatusBadRequest
+}
+
 func (ctl *AdminController) CreateShift(c *gin.Context) {
 	var req createShiftRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	t, err := time.Parse("2006-01-02 15:04:05", req.DepartureTime)
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", req.DepartureTime, ctl.loc)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid departure_time"})
 		return
 	}
-	shift, err := ctl.svc.CreateShift(req.DriverID, t)
+	shift, err := ctl.svc.WithActor(auditActor(c)).CreateShift(req.DriverID, t, req.EstimatedMinutes)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(shiftErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
+	setETag(c, shift.Version)
 	c.JSON(http.StatusCreated, shift)
 }
 
@@ -189,6 +360,10 @@ func (ctl *AdminController) UpdateShift(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shift id"})
 		return
 	}
+	ifMatch, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
 
 	var req updateShiftRequest
 	if err = c.ShouldBindJSON(&req); err != nil {
@@ -198,7 +373,7 @@ func (ctl *AdminController) UpdateShift(c *gin.Context) {
 
 	var departureTime *time.Time
 	if req.DepartureTime != nil {
-		parsed, parseErr := time.Parse("2006-01-02 15:04:05", *req.DepartureTime)
+		parsed, parseErr := time.ParseInLocation("2006-01-02 15:04:05", *req.DepartureTime, ctl.loc)
 		if parseErr != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid departure_time"})
 			return
@@ -206,15 +381,19 @@ func (ctl *AdminController) UpdateShift(c *gin.Context) {
 		departureTime = &parsed
 	}
 
-	shift, err := ctl.svc.UpdateShift(shiftID, service.ShiftUpdateDTO{
-		DriverID:      req.DriverID,
-		DepartureTime: departureTime,
+	shift, err := ctl.svc.WithActor(auditActor(c)).UpdateShift(shiftID, service.ShiftUpdateDTO{
+		DriverID:         req.DriverID,
+		DepartureTime:    departureTime,
+		EstimatedMinutes: req.EstimatedMinutes,
+		CapacityPolicy:   req.CapacityPolicy,
+		IfMatch:          ifMatch,
 	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(shiftErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
+	setETag(c, shift.Version)
 	c.JSON(http.StatusOK, shift)
 }
 
@@ -229,11 +408,55 @@ func (ctl *AdminController) AssignStudent(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	result, err := ctl.svc.AssignStudent(shiftID, req.RequestID)
+	result, err := ctl.svc.WithActor(auditActor(c)).AssignStudent(shiftID, req.RequestID, req.Reason)
+	if err != nil {
+		var capErr *service.CapacityExceededError
+		if errors.As(err, &capErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (ctl *AdminController) UpdateRequest(c *gin.Context) {
+	requestID, err := parseID(c.Param("id"))
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request id"})
+		return
+	}
+	ifMatch, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	var req updateRequestRequest
+	if err = c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	result, err := ctl.svc.WithActor(auditActor(c)).UpdateRequest(requestID, service.RequestUpdateDTO{
+		Passengers:  req.Passengers,
+		CheckedBags: req.CheckedBags,
+		CarryOnBags: req.CarryOnBags,
+		IfMatch:     ifMatch,
+	})
+	if err != nil {
+		var capErr *service.CapacityExceededError
+		switch {
+		case errors.As(err, &capErr):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrRequestNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrVersionMismatch):
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	setETag(c, result.Request.Version)
 	c.JSON(http.StatusOK, result)
 }
 
@@ -248,8 +471,15 @@ func (ctl *AdminController) RemoveStudent(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err = ctl.svc.RemoveStudent(shiftID, req.RequestID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err = ctl.svc.WithActor(auditActor(c)).RemoveStudent(shiftID, req.RequestID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrRequestNotFound), errors.Is(err, service.ErrRequestNotOnShift):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrRequestAlreadyCanceled):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "ok"})
@@ -266,11 +496,28 @@ func (ctl *AdminController) AssignStaff(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err = ctl.svc.AssignStaff(shiftID, req.StaffID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	result, err := ctl.svc.WithActor(auditActor(c)).AssignStaff(shiftID, req.StaffID, req.Reason)
+	if err != nil {
+		var overlap *service.StaffOverlapError
+		var capErr *service.CapacityExceededError
+		switch {
+		case errors.As(err, &overlap), errors.As(err, &capErr):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrShiftNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+	res := gin.H{"message": "ok"}
+	if result.Warning != "" {
+		res["warning"] = result.Warning
+	}
+	if result.Reason != "" {
+		res["reason"] = result.Reason
+	}
+	c.JSON(http.StatusOK, res)
 }
 
 func (ctl *AdminController) RemoveStaff(c *gin.Context) {
@@ -284,7 +531,7 @@ func (ctl *AdminController) RemoveStaff(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err = ctl.svc.RemoveStaff(shiftID, req.StaffID); err != nil {
+	if err = ctl.svc.WithActor(auditActor(c)).RemoveStaff(shiftID, req.StaffID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -297,13 +544,132 @@ func (ctl *AdminController) PublishShift(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shift id"})
 		return
 	}
-	if err = ctl.svc.PublishShift(shiftID); err != nil {
+	if err = ctl.svc.WithActor(auditActor(c)).PublishShift(shiftID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "ok"})
 }
 
+func (ctl *AdminController) StartShift(c *gin.Context) {
+	ctl.transitionShift(c, ctl.svc.WithActor(auditActor(c)).StartShift)
+}
+
+func (ctl *AdminController) CompleteShift(c *gin.Context) {
+	ctl.transitionShift(c, ctl.svc.WithActor(auditActor(c)).CompleteShift)
+}
+
+func (ctl *AdminController) CancelShift(c *gin.Context) {
+	ctl.transitionShift(c, ctl.svc.WithActor(auditActor(c)).CancelShift)
+}
+
+// DeleteShift 已发布班次需带 ?force=true。
+func (ctl *AdminController) DeleteShift(c *gin.Context) {
+	shiftID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shift id"})
+		return
+	}
+	if err = ctl.svc.WithActor(auditActor(c)).DeleteShift(shiftID, c.Query("force") == "true"); err != nil {
+		switch {
+		case errors.Is(err, service.ErrShiftNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrShiftPublished), errors.Is(err, service.ErrShiftNotDeletable):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+func (ctl *AdminController) transitionShift(c *gin.Context, fn func(uint) (*models.Shift, error)) {
+	shiftID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shift id"})
+		return
+	}
+	shift, err := fn(shiftID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, shift)
+}
+
+func (ctl *AdminController) ShiftConflicts(c *gin.Context) {
+	svc, ok := ctl.scoped(c)
+	if !ok {
+		return
+	}
+	includeResolved := c.Query("include_resolved") == "true"
+	res, err := svc.ListShiftConflicts(includeResolved)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (ctl *AdminController) PreviewPlan(c *gin.Context) {
+	var req planPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	from, err := time.ParseInLocation("2006-01-02", req.From, ctl.loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+		return
+	}
+	to, err := time.ParseInLocation("2006-01-02", req.To, ctl.loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+		return
+	}
+	svc, ok := ctl.scoped(c)
+	if !ok {
+		return
+	}
+	plan, err := svc.PreviewPlan(service.PlanPreviewInput{
+		From:              from,
+		To:                to,
+		WindowMinutes:     req.WindowMinutes,
+		TurnaroundMinutes: req.TurnaroundMinutes,
+		ReserveSeats:      req.ReserveSeats,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+func (ctl *AdminController) CommitPlan(c *gin.Context) {
+	var req planCommitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	svc, ok := ctl.scoped(c)
+	if !ok {
+		return
+	}
+	shifts, err := svc.WithActor(auditActor(c)).CommitPlan(req.Shifts)
+	if err != nil {
+		c.JSON(shiftErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, shifts)
+}
+
+// auditActor 从请求上下文构造审计操作者。
+func auditActor(c *gin.Context) service.Actor {
+	userID, _ := middlewares.UserID(c)
+	return service.Actor{UserID: userID, Role: middlewares.UserRole(c), RequestID: middlewares.RequestIDFrom(c)}
+}
+
 func parseID(raw string) (uint, error) {
 	id64, err := strconv.ParseUint(raw, 10, 64)
 	if err != nil {
@@ -311,3 +677,186 @@ func parseID(raw string) (uint, error) {
 	}
 	return uint(id64), nil
 }
+
+// NoShowReport 支持 ?from=YYYY-MM-DD&to=YYYY-MM-DD，缺省为当天。
+func (ctl *AdminController) NoShowReport(c *gin.Context) {
+	today := time.Now().In(ctl.loc).Format("2006-01-02")
+	from, err := time.ParseInLocation("2006-01-02", c.DefaultQuery("from", today), ctl.loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+		return
+	}
+	to, err := time.ParseInLocation("2006-01-02", c.DefaultQuery("to", c.DefaultQuery("from", today)), ctl.loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+		return
+	}
+	svc, ok := ctl.scoped(c)
+	if !ok {
+		return
+	}
+	res, err := svc.NoShowReport(from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// AuditLogs 支持 ?actor_id=&entity_type=&entity_id=&from=&to=&limit=，时间为 RFC3339 或 YYYY-MM-DD（to 含当天）。
+func (ctl *AdminController) AuditLogs(c *gin.Context) {
+	var filter service.AuditFilter
+	var err error
+	if raw := c.Query("actor_id"); raw != "" {
+		if filter.ActorID, err = parseID(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid actor_id"})
+			return
+		}
+	}
+	filter.EntityType = c.Query("entity_type")
+	if raw := c.Query("entity_id"); raw != "" {
+		if filter.EntityID, err = parseID(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid entity_id"})
+			return
+		}
+	}
+	if raw := c.Query("from"); raw != "" {
+		from, err := parseQueryTime(raw, false, ctl.loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+			return
+		}
+		filter.From = &from
+	}
+	if raw := c.Query("to"); raw != "" {
+		to, err := parseQueryTime(raw, true, ctl.loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+			return
+		}
+		filter.To = &to
+	}
+	if raw := c.Query("limit"); raw != "" {
+		if filter.Limit, err = strconv.Atoi(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+	res, err := ctl.svc.ListAuditLogs(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// parseQueryTime 接受 RFC3339 或 YYYY-MM-DD（按 loc 解释）；endOfDay 时日期取次日零点，作为开区间上界。
+func parseQueryTime(raw string, endOfDay bool, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", raw, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+func (ctl *AdminController) ShiftCapacity(c *gin.Context) {
+	shiftID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shift id"})
+		return
+	}
+	res, err := ctl.svc.ShiftCapacity(shiftID)
+	if err != nil {
+		if errors.Is(err, service.ErrShiftNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// ShiftNotifications 查看班次发布通知的发送记录。
+func (ctl *AdminController) ShiftNotifications(c *gin.Context) {
+	shiftID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shift id"})
+		return
+	}
+	items, err := ctl.svc.ListShiftNotifications(shiftID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func (ctl *AdminController) ListTerminals(c *gin.Context) {
+	res, err := ctl.svc.ListTerminals()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (ctl *AdminController) CreateTerminal(c *gin.Context) {
+	var input terminalRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	terminal, err := ctl.svc.WithActor(auditActor(c)).CreateTerminal(input.dto())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, terminal)
+}
+
+func (ctl *AdminController) UpdateTerminal(c *gin.Context) {
+	terminalID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid terminal id"})
+		return
+	}
+	var input terminalRequest
+	if err = c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	terminal, err := ctl.svc.WithActor(auditActor(c)).UpdateTerminal(terminalID, input.dto())
+	if err != nil {
+		if errors.Is(err, service.ErrTerminalNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, terminal)
+}
+
+func (ctl *AdminController) DeleteTerminal(c *gin.Context) {
+	terminalID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid terminal id"})
+		return
+	}
+	if err = ctl.svc.WithActor(auditActor(c)).DeleteTerminal(terminalID); err != nil {
+		if errors.Is(err, service.ErrTerminalNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
@@ -0,0 +1,339 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"pickup/internal/scheduler/middlewares"
+	"pickup/internal/scheduler/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const availabilityTimeLayout = "2006-01-02 15:04:05"
+
+type availabilityRequest struct {
+	StartTime string `json:"start_time" binding:"required"`
+	EndTime   string `json:"end_time" binding:"required"`
+	Note      string `json:"note"`
+}
+
+func (r availabilityRequest) dto(loc *time.Location) (service.AvailabilityDTO, error) {
+	start, err := time.ParseInLocation(availabilityTimeLayout, r.StartTime, loc)
+	if err != nil {
+		return service.AvailabilityDTO{}, errors.New("invalid start_time")
+	}
+	end, err := time.ParseInLocation(availabilityTimeLayout, r.EndTime, loc)
+	if err != nil {
+		return service.AvailabilityDTO{}, errors.New("invalid end_time")
+	}
+	return service.AvailabilityDTO{StartTime: start, EndTime: end, Note: r.Note}, nil
+}
+
+// AvailableDrivers 查询 ?from=&to= 时段内空闲的司机。
+func (ctl *AdminController) AvailableDrivers(c *gin.Context) {
+	from, err := time.ParseInLocation(availabilityTimeLayout, c.Query("from"), ctl.loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+		return
+	}
+	to, err := time.ParseInLocation(availabilityTimeLayout, c.Query("to"), ctl.loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+		return
+	}
+	svc, ok := ctl.scoped(c)
+	if !ok {
+		return
+	}
+	res, err := svc.AvailableDrivers(from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (ctl *AdminController) ListDriverAvailability(c *gin.Context) {
+	driverID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid driver id"})
+		return
+	}
+	res, err := ctl.svc.ListDriverAvailability(driverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (ctl *AdminController) CreateDriverAvailability(c *gin.Context) {
+	driverID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid driver id"})
+		return
+	}
+	var input availabilityRequest
+	if err = c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dto, err := input.dto(ctl.loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	window, err := ctl.svc.WithActor(auditActor(c)).CreateDriverAvailability(driverID, dto)
+	if err != nil {
+		if errors.Is(err, service.ErrDriverNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, window)
+}
+
+func (ctl *AdminController) DeleteDriverAvailability(c *gin.Context) {
+	driverID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid driver id"})
+		return
+	}
+	windowID, err := parseID(c.Param("availabilityId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid availability id"})
+		return
+	}
+	if err = ctl.svc.WithActor(auditActor(c)).DeleteDriverAvailability(driverID, windowID); err != nil {
+		if errors.Is(err, service.ErrAvailabilityNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// StaffSchedule 查看志愿者某天（?date=YYYY-MM-DD，缺省为今天）的值班安排。
+func (ctl *AdminController) StaffSchedule(c *gin.Context) {
+	staffID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid staff id"})
+		return
+	}
+	date := time.Now()
+	if raw := c.Query("date"); raw != "" {
+		if date, err = time.ParseInLocation("2006-01-02", raw, ctl.loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date"})
+			return
+		}
+	}
+	res, err := ctl.svc.StaffSchedule(staffID, date)
+	if err != nil {
+		if errors.Is(err, service.ErrStaffNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (ctl *AdminController) ListStaffAvailability(c *gin.Context) {
+	staffID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid staff id"})
+		return
+	}
+	res, err := ctl.svc.ListStaffAvailability(staffID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (ctl *AdminController) CreateStaffAvailability(c *gin.Context) {
+	staffID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid staff id"})
+		return
+	}
+	var input availabilityRequest
+	if err = c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dto, err := input.dto(ctl.loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	window, err := ctl.svc.WithActor(auditActor(c)).CreateStaffAvailability(staffID, dto)
+	if err != nil {
+		if errors.Is(err, service.ErrStaffNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, window)
+}
+
+func (ctl *AdminController) DeleteStaffAvailability(c *gin.Context) {
+	staffID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid staff id"})
+		return
+	}
+	windowID, err := parseID(c.Param("availabilityId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid availability id"})
+		return
+	}
+	if err = ctl.svc.WithActor(auditActor(c)).DeleteStaffAvailability(staffID, windowID); err != nil {
+		if errors.Is(err, service.ErrAvailabilityNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// availabilityErrorStatus 自报时间窗接口的错误码：未关联司机或删除管理员录入的时间窗返回 403。
+func availabilityErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrDriverNotLinked), errors.Is(err, service.ErrAvailabilityNotSelf):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrAvailabilityNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// bindAvailability 解析当前用户与请求体，失败时已写入响应。
+func bindAvailability(c *gin.Context, loc *time.Location) (uint, service.AvailabilityDTO, bool) {
+	userID, ok := middlewares.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return 0, service.AvailabilityDTO{}, false
+	}
+	var input availabilityRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return 0, service.AvailabilityDTO{}, false
+	}
+	dto, err := input.dto(loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return 0, service.AvailabilityDTO{}, false
+	}
+	return userID, dto, true
+}
+
+// bindAvailabilityID 解析当前用户与路径中的时间窗 ID，失败时已写入响应。
+func bindAvailabilityID(c *gin.Context) (uint, uint, bool) {
+	userID, ok := middlewares.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return 0, 0, false
+	}
+	windowID, err := parseID(c.Param("availabilityId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid availability id"})
+		return 0, 0, false
+	}
+	return userID, windowID, true
+}
+
+func (ctl *DriverController) MyAvailability(c *gin.Context) {
+	userID, ok := middlewares.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	res, err := ctl.svc.MyAvailability(userID)
+	if err != nil {
+		status := availabilityErrorStatus(err)
+		if status == http.StatusBadRequest {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (ctl *DriverController) CreateMyAvailability(c *gin.Context) {
+	userID, dto, ok := bindAvailability(c, ctl.loc)
+	if !ok {
+		return
+	}
+	window, err := ctl.svc.WithActor(auditActor(c)).CreateMyAvailability(userID, dto)
+	if err != nil {
+		c.JSON(availabilityErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, window)
+}
+
+func (ctl *DriverController) DeleteMyAvailability(c *gin.Context) {
+	userID, windowID, ok := bindAvailabilityID(c)
+	if !ok {
+		return
+	}
+	if err := ctl.svc.WithActor(auditActor(c)).DeleteMyAvailability(userID, windowID); err != nil {
+		c.JSON(availabilityErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+func (ctl *StaffController) MyAvailability(c *gin.Context) {
+	staffID, ok := middlewares.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	res, err := ctl.svc.MyAvailability(staffID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (ctl *StaffController) CreateMyAvailability(c *gin.Context) {
+	staffID, dto, ok := bindAvailability(c, ctl.loc)
+	if !ok {
+		return
+	}
+	window, err := ctl.svc.WithActor(auditActor(c)).CreateMyAvailability(staffID, dto)
+	if err != nil {
+		c.JSON(availabilityErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, window)
+}
+
+func (ctl *StaffController) DeleteMyAvailability(c *gin.Context) {
+	staffID, windowID, ok := bindAvailabilityID(c)
+	if !ok {
+		return
+	}
+	if err := ctl.svc.WithActor(auditActor(c)).DeleteMyAvailability(staffID, windowID); err != nil {
+		c.JSON(availabilityErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
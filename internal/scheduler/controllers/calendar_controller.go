@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"pickup/internal/scheduler/middlewares"
+	"pickup/internal/scheduler/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarController struct {
+	svc *service.CalendarService
+}
+
+func NewCalendarController(svc *service.CalendarService) *CalendarController {
+	return &CalendarController{svc: svc}
+}
+
+// feedURL 由当前请求推出订阅地址：.../calendar/feed → .../calendar/<token>.ics。
+func feedURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	base := strings.TrimSuffix(c.Request.URL.Path, "/feed")
+	return scheme + "://" + c.Request.Host + base + "/" + token + ".ics"
+}
+
+// IssueFeed 生成（或重新生成）当前用户的订阅链接，旧链接失效。
+func (ctl *CalendarController) IssueFeed(c *gin.Context) {
+	userID, ok := middlewares.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	token, err := ctl.svc.IssueFeed(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"token": token, "url": feedURL(c, token)})
+}
+
+func (ctl *CalendarController) RevokeFeed(c *gin.Context) {
+	userID, ok := middlewares.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	if err := ctl.svc.RevokeFeed(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Feed 日历客户端无法携带 JWT，凭路径中的签名令牌访问；路由参数含 .ics 后缀。
+func (ctl *CalendarController) Feed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	body, err := ctl.svc.Feed(token, time.Now())
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCalendarToken) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", body)
+}
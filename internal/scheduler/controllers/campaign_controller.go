@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"pickup/internal/scheduler/models"
+	"pickup/internal/scheduler/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type campaignRequest struct {
+	Name      string                `json:"name" binding:"required"`
+	StartDate string                `json:"start_date" binding:"required"`
+	EndDate   string                `json:"end_date" binding:"required"`
+	Status    models.CampaignStatus `json:"status"`
+}
+
+func (r campaignRequest) dto() (service.CampaignDTO, error) {
+	start, err := time.Parse("2006-01-02", r.StartDate)
+	if err != nil {
+		return service.CampaignDTO{}, errors.New("invalid start_date")
+	}
+	end, err := time.Parse("2006-01-02", r.EndDate)
+	if err != nil {
+		return service.CampaignDTO{}, errors.New("invalid end_date")
+	}
+	return service.CampaignDTO{Name: r.Name, StartDate: start, EndDate: end, Status: r.Status}, nil
+}
+
+func (ctl *AdminController) ListCampaigns(c *gin.Context) {
+	res, err := ctl.svc.ListCampaigns()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (ctl *AdminController) ActiveCampaign(c *gin.Context) {
+	res, err := ctl.svc.ActiveCampaign()
+	if err != nil {
+		if errors.Is(err, service.ErrCampaignNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (ctl *AdminController) CreateCampaign(c *gin.Context) {
+	var input campaignRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dto, err := input.dto()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	campaign, err := ctl.svc.WithActor(auditActor(c)).CreateCampaign(dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, campaign)
+}
+
+func (ctl *AdminController) UpdateCampaign(c *gin.Context) {
+	campaignID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid campaign id"})
+		return
+	}
+	var input campaignRequest
+	if err = c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dto, err := input.dto()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	campaign, err := ctl.svc.WithActor(auditActor(c)).UpdateCampaign(campaignID, dto)
+	if err != nil {
+		if errors.Is(err, service.ErrCampaignNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, campaign)
+}
+
+func (ctl *AdminController) ActivateCampaign(c *gin.Context) {
+	campaignID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid campaign id"})
+		return
+	}
+	campaign, err := ctl.svc.WithActor(auditActor(c)).ActivateCampaign(campaignID)
+	if err != nil {
+		if errors.Is(err, service.ErrCampaignNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, campaign)
+}
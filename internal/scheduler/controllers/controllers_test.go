@@ -1,7 +1,10 @@
 package controllers
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -9,6 +12,8 @@ import (
 	"time"
 
 	"pickup/internal/config"
+	"pickup/internal/scheduler/events"
+	"pickup/internal/scheduler/middlewares"
 	"pickup/internal/scheduler/service"
 
 	"github.com/gin-gonic/gin"
@@ -26,11 +31,19 @@ func newControllerTestDB(t *testing.T) *gorm.DB {
 	require.NoError(t, err)
 	ddls := []string{
 		`CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, open_id TEXT NOT NULL UNIQUE, name TEXT NOT NULL, phone TEXT, role TEXT NOT NULL DEFAULT 'student', created_at DATETIME, updated_at DATETIME);`,
-		`CREATE TABLE drivers (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, car_model TEXT NOT NULL, max_seats INTEGER NOT NULL, max_checked INTEGER NOT NULL, max_carry_on INTEGER NOT NULL);`,
-		`CREATE TABLE requests (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER NOT NULL, flight_no TEXT NOT NULL, arrival_date DATETIME NOT NULL, terminal TEXT NOT NULL, checked_bags INTEGER NOT NULL DEFAULT 0, carry_on_bags INTEGER NOT NULL DEFAULT 0, status TEXT NOT NULL DEFAULT 'pending', arrival_time_api DATETIME, pickup_buffer INTEGER NOT NULL DEFAULT 45, calc_pickup_time DATETIME, created_at DATETIME, updated_at DATETIME);`,
-		`CREATE TABLE shifts (id INTEGER PRIMARY KEY AUTOINCREMENT, driver_id INTEGER NOT NULL, departure_time DATETIME NOT NULL, status TEXT NOT NULL DEFAULT 'draft', created_at DATETIME);`,
-		`CREATE TABLE shift_requests (shift_id INTEGER NOT NULL, request_id INTEGER NOT NULL UNIQUE, PRIMARY KEY (shift_id, request_id));`,
+		`CREATE TABLE drivers (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, car_model TEXT NOT NULL, max_seats INTEGER NOT NULL, max_checked INTEGER NOT NULL, max_carry_on INTEGER NOT NULL, campaign_id INTEGER, user_id INTEGER UNIQUE, archived_at DATETIME, version INTEGER NOT NULL DEFAULT 0);`,
+		`CREATE TABLE requests (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER NOT NULL, flight_no TEXT NOT NULL, arrival_date DATETIME NOT NULL, airport TEXT NOT NULL DEFAULT '', terminal TEXT NOT NULL, passengers INTEGER NOT NULL DEFAULT 1, checked_bags INTEGER NOT NULL DEFAULT 0, carry_on_bags INTEGER NOT NULL DEFAULT 0, status TEXT NOT NULL DEFAULT 'pending', arrival_time_api DATETIME, pickup_buffer INTEGER NOT NULL DEFAULT 45, calc_pickup_time DATETIME, canceled_at DATETIME, campaign_id INTEGER, version INTEGER NOT NULL DEFAULT 0, created_at DATETIME, updated_at DATETIME);`,
+		`CREATE TABLE shifts (id INTEGER PRIMARY KEY AUTOINCREMENT, driver_id INTEGER NOT NULL, departure_time DATETIME NOT NULL, estimated_minutes INTEGER NOT NULL DEFAULT 120, status TEXT NOT NULL DEFAULT 'draft', started_at DATETIME, completed_at DATETIME, capacity_policy TEXT, driver_status TEXT NOT NULL DEFAULT 'pending', driver_responded_at DATETIME, driver_note TEXT NOT NULL DEFAULT '', campaign_id INTEGER, schedule_seq INTEGER NOT NULL DEFAULT 0, version INTEGER NOT NULL DEFAULT 0, created_at DATETIME);`,
+		`CREATE TABLE shift_requests (shift_id INTEGER NOT NULL, request_id INTEGER NOT NULL UNIQUE, boarding_status TEXT NOT NULL DEFAULT 'waiting', checked_in_at DATETIME, checked_in_by INTEGER, overload_reason TEXT, PRIMARY KEY (shift_id, request_id));`,
 		`CREATE TABLE shift_staffs (shift_id INTEGER NOT NULL, staff_id INTEGER NOT NULL, PRIMARY KEY (shift_id, staff_id));`,
+		`CREATE TABLE shift_conflicts (id INTEGER PRIMARY KEY AUTOINCREMENT, shift_id INTEGER NOT NULL, request_id INTEGER NOT NULL, kind TEXT NOT NULL, calc_pickup_time DATETIME NOT NULL, departure_time DATETIME NOT NULL, delta_minutes INTEGER NOT NULL, detected_at DATETIME NOT NULL, resolved_at DATETIME);`,
+		`CREATE TABLE audit_logs (id INTEGER PRIMARY KEY AUTOINCREMENT, actor_id INTEGER NOT NULL, actor_role TEXT NOT NULL DEFAULT '', action TEXT NOT NULL, entity_type TEXT NOT NULL, entity_id INTEGER NOT NULL, before_data TEXT, after_data TEXT, request_id TEXT NOT NULL DEFAULT '', created_at DATETIME);`,
+		`CREATE TABLE notifications (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER NOT NULL, request_id INTEGER NOT NULL, shift_id INTEGER NOT NULL, event TEXT NOT NULL, template_id TEXT NOT NULL DEFAULT '', status TEXT NOT NULL, err_code INTEGER NOT NULL DEFAULT 0, err_msg TEXT NOT NULL DEFAULT '', created_at DATETIME);`,
+		`CREATE TABLE driver_availabilities (id INTEGER PRIMARY KEY AUTOINCREMENT, driver_id INTEGER NOT NULL, start_time DATETIME NOT NULL, end_time DATETIME NOT NULL, source TEXT NOT NULL DEFAULT 'admin', note TEXT NOT NULL DEFAULT '', created_at DATETIME);`,
+		`CREATE TABLE staff_availabilities (id INTEGER PRIMARY KEY AUTOINCREMENT, staff_id INTEGER NOT NULL, start_time DATETIME NOT NULL, end_time DATETIME NOT NULL, source TEXT NOT NULL DEFAULT 'admin', note TEXT NOT NULL DEFAULT '', created_at DATETIME);`,
+		`CREATE TABLE campaigns (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, start_date DATETIME NOT NULL, end_date DATETIME NOT NULL, status TEXT NOT NULL DEFAULT 'open', active BOOLEAN NOT NULL DEFAULT 0, created_at DATETIME, updated_at DATETIME);`,
+		`CREATE TABLE calendar_feeds (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER NOT NULL UNIQUE, nonce TEXT NOT NULL, created_at DATETIME);`,
+		`CREATE TABLE airport_terminals (id INTEGER PRIMARY KEY AUTOINCREMENT, airport_code TEXT NOT NULL, terminal TEXT NOT NULL, international BOOLEAN NOT NULL DEFAULT 0, buffer_minutes INTEGER NOT NULL DEFAULT 45, meeting_point TEXT NOT NULL DEFAULT '', created_at DATETIME, updated_at DATETIME, UNIQUE (airport_code, terminal));`,
 	}
 	for _, ddl := range ddls {
 		require.NoError(t, db.Exec(ddl).Error)
@@ -77,13 +90,14 @@ func TestAuthController_BadRequests(t *testing.T) {
 func TestStudentController_Flows(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	db := newControllerTestDB(t)
-	svc := service.NewStudentService(db)
+	svc := service.NewStudentService(db, nil, time.UTC)
 	ctl := NewStudentController(svc)
 
 	r := gin.New()
 	r.POST("/requests", func(c *gin.Context) { c.Set("user_id", uint(1)); ctl.CreateRequest(c) })
 	r.GET("/my", func(c *gin.Context) { c.Set("user_id", uint(1)); ctl.MyRequests(c) })
 	r.PUT("/requests/:id", func(c *gin.Context) { c.Set("user_id", uint(1)); ctl.UpdateRequest(c) })
+	r.POST("/requests/:id/cancel", func(c *gin.Context) { c.Set("user_id", uint(1)); ctl.CancelRequest(c) })
 
 	w1 := httptest.NewRecorder()
 	req1 := httptest.NewRequest(http.MethodPost, "/requests", strings.NewReader(`{"flight_no":"AA1","arrival_date":"2026-03-01","terminal":"T1","expected_arrival_time":"2026-03-01 10:00:00"}`))
@@ -105,8 +119,19 @@ func TestStudentController_Flows(t *testing.T) {
 	w3 := httptest.NewRecorder()
 	req3 := httptest.NewRequest(http.MethodPut, "/requests/1", strings.NewReader(`{"terminal":"T5"}`))
 	req3.Header.Set("Content-Type", "application/json")
+	req3.Header.Set("If-Match", `"0"`)
 	r.ServeHTTP(w3, req3)
 	assert.Equal(t, http.StatusOK, w3.Code)
+	assert.Equal(t, `"1"`, w3.Header().Get("ETag"))
+
+	for ifMatch, code := range map[string]int{"": http.StatusPreconditionRequired, `"0"`: http.StatusPreconditionFailed, "x": http.StatusBadRequest} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "/requests/1", strings.NewReader(`{"terminal":"T1"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", ifMatch)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, code, w.Code, ifMatch)
+	}
 
 	w4 := httptest.NewRecorder()
 	req4 := httptest.NewRequest(http.MethodPut, "/requests/bad", strings.NewReader(`{}`))
@@ -128,6 +153,23 @@ func TestStudentController_Flows(t *testing.T) {
 	r2.ServeHTTP(w6, req6)
 	assert.Equal(t, http.StatusUnauthorized, w6.Code)
 
+	w5b := httptest.NewRecorder()
+	r.ServeHTTP(w5b, httptest.NewRequest(http.MethodPost, "/requests/bad/cancel", nil))
+	assert.Equal(t, http.StatusBadRequest, w5b.Code)
+
+	w5c := httptest.NewRecorder()
+	r.ServeHTTP(w5c, httptest.NewRequest(http.MethodPost, "/requests/1/cancel", nil))
+	assert.Equal(t, http.StatusOK, w5c.Code)
+
+	w5d := httptest.NewRecorder()
+	r.ServeHTTP(w5d, httptest.NewRequest(http.MethodPost, "/requests/1/cancel", nil))
+	assert.Equal(t, http.StatusBadRequest, w5d.Code)
+
+	r2.POST("/requests/:id/cancel", ctl.CancelRequest)
+	w5e := httptest.NewRecorder()
+	r2.ServeHTTP(w5e, httptest.NewRequest(http.MethodPost, "/requests/1/cancel", nil))
+	assert.Equal(t, http.StatusUnauthorized, w5e.Code)
+
 	r3 := gin.New()
 	r3.GET("/my", ctl.MyRequests)
 	w7 := httptest.NewRecorder()
@@ -145,9 +187,9 @@ func TestStudentController_Flows(t *testing.T) {
 func TestAdminController_FlowsAndErrors(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	db := newControllerTestDB(t)
-	assigner := service.NewShiftAssignmentService(db)
-	svc := service.NewAdminService(db, assigner)
-	ctl := NewAdminController(svc)
+	assigner := service.NewShiftAssignmentService(db, nil)
+	svc := service.NewAdminService(db, assigner, nil, nil, time.UTC)
+	ctl := NewAdminController(svc, time.UTC)
 
 	r := gin.New()
 	r.GET("/drivers", ctl.ListDrivers)
@@ -247,12 +289,14 @@ func TestAdminController_FlowsAndErrors(t *testing.T) {
 	w12a := httptest.NewRecorder()
 	req12a := httptest.NewRequest(http.MethodPut, "/drivers/1", strings.NewReader(`{"name":"d2","car_model":"SUV","max_seats":5,"max_checked":5,"max_carry_on":5}`))
 	req12a.Header.Set("Content-Type", "application/json")
+	req12a.Header.Set("If-Match", `"0"`)
 	r.ServeHTTP(w12a, req12a)
 	assert.Equal(t, http.StatusOK, w12a.Code)
 
 	w12b := httptest.NewRecorder()
 	req12b := httptest.NewRequest(http.MethodPut, "/shifts/1", strings.NewReader(`{"departure_time":"2026-03-01 13:00:00"}`))
 	req12b.Header.Set("Content-Type", "application/json")
+	req12b.Header.Set("If-Match", `"1"`)
 	r.ServeHTTP(w12b, req12b)
 	assert.Equal(t, http.StatusOK, w12b.Code)
 
@@ -361,9 +405,9 @@ func TestAuthController_LoginUnauthorizedPathWithService(t *testing.T) {
 func TestAdminController_ErrorBranchesDeep(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	db := newControllerTestDB(t)
-	assigner := service.NewShiftAssignmentService(db)
-	svc := service.NewAdminService(db, assigner)
-	ctl := NewAdminController(svc)
+	assigner := service.NewShiftAssignmentService(db, nil)
+	svc := service.NewAdminService(db, assigner, nil, nil, time.UTC)
+	ctl := NewAdminController(svc, time.UTC)
 
 	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','SUV',4,4,4)`).Error)
 	require.NoError(t, db.Exec(`INSERT INTO shifts(driver_id,departure_time,status) VALUES (1,'2026-03-01 12:00:00','draft')`).Error)
@@ -409,7 +453,7 @@ func TestAdminController_ErrorBranchesDeep(t *testing.T) {
 func TestStudentController_UpdateErrorBranches(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	db := newControllerTestDB(t)
-	svc := service.NewStudentService(db)
+	svc := service.NewStudentService(db, nil, time.UTC)
 	ctl := NewStudentController(svc)
 
 	require.NoError(t, db.Exec(`INSERT INTO requests(user_id,flight_no,arrival_date,terminal,status,checked_bags,carry_on_bags,pickup_buffer) VALUES (1,'AA1','2026-03-01','T1','pending',0,0,45)`).Error)
@@ -437,6 +481,7 @@ func TestStudentController_UpdateErrorBranches(t *testing.T) {
 	w3 := httptest.NewRecorder()
 	req3 := httptest.NewRequest(http.MethodPut, "/requests/1", strings.NewReader(`{"terminal":"T2"}`))
 	req3.Header.Set("Content-Type", "application/json")
+	req3.Header.Set("If-Match", `"0"`)
 	r3.ServeHTTP(w3, req3)
 	assert.Equal(t, http.StatusBadRequest, w3.Code)
 }
@@ -444,9 +489,9 @@ func TestStudentController_UpdateErrorBranches(t *testing.T) {
 func TestAdminController_PendingAndCreateShiftErrorBranches(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	db := newControllerTestDB(t)
-	assigner := service.NewShiftAssignmentService(db)
-	svc := service.NewAdminService(db, assigner)
-	ctl := NewAdminController(svc)
+	assigner := service.NewShiftAssignmentService(db, nil)
+	svc := service.NewAdminService(db, assigner, nil, nil, time.UTC)
+	ctl := NewAdminController(svc, time.UTC)
 
 	r := gin.New()
 	r.GET("/pending", ctl.PendingRequests)
@@ -464,3 +509,1061 @@ func TestAdminController_PendingAndCreateShiftErrorBranches(t *testing.T) {
 	r.ServeHTTP(w2, req2)
 	assert.Equal(t, http.StatusInternalServerError, w2.Code)
 }
+
+func TestAdminController_PlanPreviewAndCommit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	svc := service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+	ctl := NewAdminController(svc, time.UTC)
+
+	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','SUV',4,4,4)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(user_id,flight_no,arrival_date,terminal,status,checked_bags,carry_on_bags,pickup_buffer,calc_pickup_time) VALUES (1,'AA1','2026-03-01','T1','pending',1,1,45,'2026-03-01 10:45:00')`).Error)
+
+	r := gin.New()
+	r.POST("/plans/preview", ctl.PreviewPlan)
+	r.POST("/plans/commit", ctl.CommitPlan)
+
+	w1 := httptest.NewRecorder()
+	req1 := httptest.NewRequest(http.MethodPost, "/plans/preview", strings.NewReader(`{"from":"2026-03-01","to":"2026-03-01"}`))
+	req1.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w1, req1)
+	require.Equal(t, http.StatusOK, w1.Code)
+	assert.Contains(t, w1.Body.String(), `"request_ids":[1]`)
+
+	w2 := httptest.NewRecorder()
+	req2 := httptest.NewRequest(http.MethodPost, "/plans/preview", strings.NewReader(`{"from":"bad","to":"2026-03-01"}`))
+	req2.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w2, req2)
+	assert.Equal(t, http.StatusBadRequest, w2.Code)
+
+	// ?campaign_id= 与看板一致：只规划该活动的需求，司机不属于该活动时不能提交。
+	for _, tc := range []struct {
+		path, body string
+		code       int
+	}{
+		{"/plans/preview?campaign_id=9", `{"from":"2026-03-01","to":"2026-03-01"}`, http.StatusOK},
+		{"/plans/preview?campaign_id=x", `{"from":"2026-03-01","to":"2026-03-01"}`, http.StatusBadRequest},
+		{"/plans/commit?campaign_id=9", `{"shifts":[{"driver_id":1,"departure_time":"2026-03-01T10:45:00Z","request_ids":[1]}]}`, http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.path)
+		assert.NotContains(t, w.Body.String(), `"request_ids":[1]`, tc.path)
+	}
+
+	w3 := httptest.NewRecorder()
+	req3 := httptest.NewRequest(http.MethodPost, "/plans/commit", strings.NewReader(`{"shifts":[{"driver_id":1,"departure_time":"2026-03-01T10:45:00Z","request_ids":[1]}]}`))
+	req3.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w3, req3)
+	assert.Equal(t, http.StatusCreated, w3.Code)
+
+	w4 := httptest.NewRecorder()
+	req4 := httptest.NewRequest(http.MethodPost, "/plans/commit", strings.NewReader(`{"shifts":[{"driver_id":1,"departure_time":"2026-03-01T10:45:00Z","request_ids":[1]}]}`))
+	req4.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w4, req4)
+	// 同一司机同一时段再次提交，先命中排班重叠
+	assert.Equal(t, http.StatusConflict, w4.Code)
+}
+
+func TestAdminController_ShiftConflicts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+
+	r := gin.New()
+	r.GET("/shifts/conflicts", ctl.ShiftConflicts)
+
+	w1 := httptest.NewRecorder()
+	r.ServeHTTP(w1, httptest.NewRequest(http.MethodGet, "/shifts/conflicts?include_resolved=true", nil))
+	assert.Equal(t, http.StatusOK, w1.Code)
+
+	require.NoError(t, db.Exec(`DROP TABLE shift_conflicts`).Error)
+	w2 := httptest.NewRecorder()
+	r.ServeHTTP(w2, httptest.NewRequest(http.MethodGet, "/shifts/conflicts", nil))
+	assert.Equal(t, http.StatusInternalServerError, w2.Code)
+}
+
+func TestAdminController_ShiftLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+
+	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','SUV',4,4,4)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shifts(driver_id,departure_time,status) VALUES (1,'2026-03-01 12:00:00','published')`).Error)
+
+	r := gin.New()
+	r.POST("/shifts/:id/start", ctl.StartShift)
+	r.POST("/shifts/:id/complete", ctl.CompleteShift)
+	r.POST("/shifts/:id/cancel", ctl.CancelShift)
+
+	cases := []struct {
+		path string
+		code int
+	}{
+		{"/shifts/bad/start", http.StatusBadRequest},
+		{"/shifts/1/complete", http.StatusBadRequest},
+		{"/shifts/1/start", http.StatusOK},
+		{"/shifts/1/cancel", http.StatusBadRequest},
+		{"/shifts/1/complete", http.StatusOK},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tc.path, nil))
+		assert.Equal(t, tc.code, w.Code, tc.path)
+	}
+}
+
+func TestAdminController_DeleteShiftAndArchiveDriver(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+
+	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','SUV',4,4,4)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shifts(driver_id,departure_time,status) VALUES (1,'2026-03-01 12:00:00','published'),(1,'2026-03-01 15:00:00','in_progress')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(user_id,flight_no,arrival_date,terminal,status) VALUES (1,'AA1','2026-03-01','T1','published')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shift_requests(shift_id,request_id) VALUES (1,1)`).Error)
+
+	r := gin.New()
+	r.GET("/drivers", ctl.ListDrivers)
+	r.DELETE("/shifts/:id", ctl.DeleteShift)
+	r.POST("/drivers/:id/archive", ctl.ArchiveDriver)
+	r.POST("/drivers/:id/unarchive", ctl.UnarchiveDriver)
+
+	type step struct {
+		method string
+		path   string
+		code   int
+		want   string
+	}
+	run := func(steps []step) {
+		for _, tc := range steps {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+			assert.Equal(t, tc.code, w.Code, tc.method+" "+tc.path)
+			assert.Contains(t, w.Body.String(), tc.want, tc.method+" "+tc.path)
+		}
+	}
+
+	run([]step{
+		{http.MethodDelete, "/shifts/x", http.StatusBadRequest, ""},
+		{http.MethodDelete, "/shifts/9", http.StatusNotFound, ""},
+		{http.MethodDelete, "/shifts/1", http.StatusConflict, "force=true"},
+		{http.MethodDelete, "/shifts/2?force=true", http.StatusConflict, ""},
+		{http.MethodDelete, "/shifts/1?force=true", http.StatusOK, ""},
+		{http.MethodPost, "/drivers/9/archive", http.StatusNotFound, ""},
+		{http.MethodPost, "/drivers/1/archive", http.StatusConflict, ""},
+	})
+	var status string
+	require.NoError(t, db.Raw(`SELECT status FROM requests WHERE id = 1`).Scan(&status).Error)
+	assert.Equal(t, "pending", status)
+
+	require.NoError(t, db.Exec(`UPDATE shifts SET status = 'completed' WHERE id = 2`).Error)
+	run([]step{
+		{http.MethodPost, "/drivers/1/archive", http.StatusOK, `"archived_at":"`},
+		{http.MethodGet, "/drivers", http.StatusOK, "[]"},
+		{http.MethodGet, "/drivers?include_archived=true", http.StatusOK, `"name":"d1"`},
+		{http.MethodPost, "/drivers/1/unarchive", http.StatusOK, ""},
+		{http.MethodGet, "/drivers", http.StatusOK, `"name":"d1"`},
+	})
+}
+
+func TestStaffController_CheckIn(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewStaffController(service.NewStaffService(db, nil, time.UTC), time.UTC)
+
+	require.NoError(t, db.Exec(`INSERT INTO users(open_id,name,role) VALUES ('s1','staff','staff'),('s2','other','staff')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','SUV',4,4,4)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shifts(driver_id,departure_time,status) VALUES (1,'2026-03-01 12:00:00','in_progress')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(user_id,flight_no,arrival_date,terminal,status) VALUES (1,'AA1','2026-03-01','T1','published')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shift_requests(shift_id,request_id) VALUES (1,1)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shift_staffs(shift_id,staff_id) VALUES (1,1)`).Error)
+
+	r := gin.New()
+	r.POST("/shifts/:id/check-in", func(c *gin.Context) {
+		if id := c.GetHeader("X-User"); id == "2" {
+			c.Set("user_id", uint(2))
+		} else if id != "" {
+			c.Set("user_id", uint(1))
+		}
+		ctl.CheckIn(c)
+	})
+
+	cases := []struct {
+		path string
+		user string
+		body string
+		code int
+	}{
+		{"/shifts/1/check-in", "", `{"request_id":1,"status":"boarded"}`, http.StatusUnauthorized},
+		{"/shifts/bad/check-in", "1", `{"request_id":1,"status":"boarded"}`, http.StatusBadRequest},
+		{"/shifts/1/check-in", "1", `{`, http.StatusBadRequest},
+		{"/shifts/1/check-in", "2", `{"request_id":1,"status":"boarded"}`, http.StatusForbidden},
+		{"/shifts/1/check-in", "1", `{"request_id":2,"status":"boarded"}`, http.StatusBadRequest},
+		{"/shifts/1/check-in", "1", `{"request_id":1,"status":"boarded"}`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", tc.user)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.path+" "+tc.body)
+	}
+
+	var status string
+	require.NoError(t, db.Raw(`SELECT boarding_status FROM shift_requests WHERE request_id = 1`).Scan(&status).Error)
+	assert.Equal(t, "boarded", status)
+}
+
+func TestAdminController_NoShowReport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+
+	r := gin.New()
+	r.GET("/reports/no-shows", ctl.NoShowReport)
+
+	cases := []struct {
+		path string
+		code int
+	}{
+		{"/reports/no-shows", http.StatusOK},
+		{"/reports/no-shows?from=2026-03-01&to=2026-03-02", http.StatusOK},
+		{"/reports/no-shows?from=bad", http.StatusBadRequest},
+		{"/reports/no-shows?from=2026-03-01&to=bad", http.StatusBadRequest},
+		{"/reports/no-shows?from=2026-03-02&to=2026-03-01", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		assert.Equal(t, tc.code, w.Code, tc.path)
+	}
+}
+
+func TestAdminController_AuditLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+
+	r := gin.New()
+	r.Use(middlewares.RequestID())
+	r.POST("/drivers", func(c *gin.Context) {
+		c.Set("user_id", uint(5))
+		c.Set("user_role", "admin")
+		ctl.CreateDriver(c)
+	})
+	r.GET("/audit", ctl.AuditLogs)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/drivers", strings.NewReader(`{"name":"d1","car_model":"SUV","max_seats":4,"max_checked":4,"max_carry_on":4}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middlewares.RequestIDHeader, "rid-1")
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	w1 := httptest.NewRecorder()
+	r.ServeHTTP(w1, httptest.NewRequest(http.MethodGet, "/audit?actor_id=5&entity_type=driver&entity_id=1&from=2020-01-01&to=2099-01-01&limit=10", nil))
+	require.Equal(t, http.StatusOK, w1.Code)
+	var logs []map[string]any
+	require.NoError(t, json.Unmarshal(w1.Body.Bytes(), &logs))
+	require.Len(t, logs, 1)
+	assert.Equal(t, "driver.create", logs[0]["action"])
+	assert.Equal(t, "rid-1", logs[0]["request_id"])
+	assert.Equal(t, "admin", logs[0]["actor_role"])
+
+	for _, path := range []string{"/audit?actor_id=x", "/audit?entity_id=x", "/audit?from=x", "/audit?to=x", "/audit?limit=x"} {
+		w2 := httptest.NewRecorder()
+		r.ServeHTTP(w2, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusBadRequest, w2.Code, path)
+	}
+
+	require.NoError(t, db.Exec(`DROP TABLE audit_logs`).Error)
+	w3 := httptest.NewRecorder()
+	r.ServeHTTP(w3, httptest.NewRequest(http.MethodGet, "/audit", nil))
+	assert.Equal(t, http.StatusInternalServerError, w3.Code)
+}
+
+func TestAdminController_AssignStudentCapacityRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+
+	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','Sedan',1,4,4)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shifts(driver_id,departure_time,status) VALUES (1,'2026-03-01 12:00:00','draft')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(user_id,flight_no,arrival_date,terminal,status) VALUES (1,'AA1','2026-03-01','T1','pending'),(2,'AA2','2026-03-01','T1','pending')`).Error)
+
+	r := gin.New()
+	r.PUT("/shifts/:id", ctl.UpdateShift)
+	r.POST("/shifts/:id/assign-student", ctl.AssignStudent)
+
+	cases := []struct {
+		method  string
+		path    string
+		body    string
+		ifMatch string
+		code    int
+	}{
+		{http.MethodPut, "/shifts/1", `{"capacity_policy":"reject"}`, `"0"`, http.StatusOK},
+		{http.MethodPost, "/shifts/1/assign-student", `{"request_id":1}`, "", http.StatusOK},
+		{http.MethodPost, "/shifts/1/assign-student", `{"request_id":2}`, "", http.StatusConflict},
+		{http.MethodPut, "/shifts/1", `{"capacity_policy":"allow_with_reason"}`, `"1"`, http.StatusOK},
+		{http.MethodPost, "/shifts/1/assign-student", `{"request_id":2}`, "", http.StatusBadRequest},
+		{http.MethodPost, "/shifts/1/assign-student", `{"request_id":2,"reason":"family"}`, "", http.StatusOK},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", tc.ifMatch)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.path+" "+tc.body)
+	}
+}
+
+func TestAdminController_UpdateRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+
+	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','Sedan',3,4,4)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shifts(driver_id,departure_time,status,capacity_policy) VALUES (1,'2026-03-01 12:00:00','draft','reject')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(user_id,flight_no,arrival_date,terminal,status) VALUES (1,'AA1','2026-03-01','T1','assigned')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shift_requests(shift_id,request_id) VALUES (1,1)`).Error)
+
+	r := gin.New()
+	r.PUT("/requests/:id", ctl.UpdateRequest)
+
+	cases := []struct {
+		path    string
+		body    string
+		ifMatch string
+		code    int
+	}{
+		{"/requests/1", `{"passengers":3,"checked_bags":2}`, `"0"`, http.StatusOK},
+		{"/requests/1", `{"passengers":4}`, `"1"`, http.StatusConflict},
+		{"/requests/1", `{"passengers":0}`, `"1"`, http.StatusBadRequest},
+		{"/requests/1", `{"passengers":"x"}`, `"1"`, http.StatusBadRequest},
+		{"/requests/1", `{"passengers":2}`, `"0"`, http.StatusPreconditionFailed},
+		{"/requests/1", `{"passengers":2}`, "", http.StatusPreconditionRequired},
+		{"/requests/bad", `{"passengers":1}`, `"1"`, http.StatusBadRequest},
+		{"/requests/9", `{"passengers":1}`, `"0"`, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", tc.ifMatch)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.path+" "+tc.body)
+	}
+
+	var passengers int
+	require.NoError(t, db.Raw(`SELECT passengers FROM requests WHERE id = 1`).Scan(&passengers).Error)
+	assert.Equal(t, 3, passengers)
+}
+
+func TestAdminController_DriverAvailability(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+
+	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','SUV',4,4,4),('d2','Van',6,6,6)`).Error)
+
+	r := gin.New()
+	r.GET("/drivers/available", ctl.AvailableDrivers)
+	r.GET("/drivers/:id/availability", ctl.ListDriverAvailability)
+	r.POST("/drivers/:id/availability", ctl.CreateDriverAvailability)
+	r.DELETE("/drivers/:id/availability/:availabilityId", ctl.DeleteDriverAvailability)
+	r.POST("/shifts", ctl.CreateShift)
+	r.PUT("/shifts/:id", ctl.UpdateShift)
+
+	cases := []struct {
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{http.MethodPost, "/drivers/1/availability", `{"start_time":"2026-08-20 08:00:00","end_time":"2026-08-20 14:00:00"}`, http.StatusCreated},
+		{http.MethodPost, "/drivers/1/availability", `{"start_time":"2026-08-20 08:00:00","end_time":"bad"}`, http.StatusBadRequest},
+		{http.MethodPost, "/drivers/1/availability", `{"start_time":"2026-08-20 08:00:00","end_time":"2026-08-20 07:00:00"}`, http.StatusBadRequest},
+		{http.MethodPost, "/drivers/9/availability", `{"start_time":"2026-08-20 08:00:00","end_time":"2026-08-20 09:00:00"}`, http.StatusNotFound},
+		{http.MethodPost, "/drivers/x/availability", `{}`, http.StatusBadRequest},
+		{http.MethodGet, "/drivers/1/availability", "", http.StatusOK},
+		{http.MethodPost, "/shifts", `{"driver_id":1,"departure_time":"2026-08-20 09:00:00","estimated_minutes":90}`, http.StatusCreated},
+		{http.MethodPost, "/shifts", `{"driver_id":1,"departure_time":"2026-08-20 10:00:00"}`, http.StatusConflict},
+		{http.MethodPost, "/shifts", `{"driver_id":2,"departure_time":"2026-08-20 10:00:00","estimated_minutes":-5}`, http.StatusBadRequest},
+		{http.MethodPost, "/shifts", `{"driver_id":2,"departure_time":"2026-08-20 10:00:00"}`, http.StatusCreated},
+		{http.MethodPut, "/shifts/2", `{"driver_id":1}`, http.StatusConflict},
+		{http.MethodGet, "/drivers/available?from=2026-08-20%2009:30:00&to=2026-08-20%2010:00:00", "", http.StatusOK},
+		{http.MethodGet, "/drivers/available?from=bad&to=2026-08-20%2010:00:00", "", http.StatusBadRequest},
+		{http.MethodGet, "/drivers/available?from=2026-08-20%2009:30:00&to=bad", "", http.StatusBadRequest},
+		{http.MethodGet, "/drivers/available?from=2026-08-20%2010:00:00&to=2026-08-20%2009:00:00", "", http.StatusBadRequest},
+		{http.MethodDelete, "/drivers/2/availability/1", "", http.StatusNotFound},
+		{http.MethodDelete, "/drivers/1/availability/x", "", http.StatusBadRequest},
+		{http.MethodDelete, "/drivers/1/availability/1", "", http.StatusOK},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"0"`)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.method+" "+tc.path+" "+tc.body)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/drivers/available?from=2026-08-20%2016:00:00&to=2026-08-20%2017:00:00", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var free []map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &free))
+	assert.Len(t, free, 2)
+}
+
+func TestAdminController_StaffSchedule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+
+	require.NoError(t, db.Exec(`INSERT INTO users(open_id,name,role) VALUES ('staff','s','staff'),('stu','u','student')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','SUV',4,4,4)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shifts(driver_id,departure_time,status) VALUES (1,'2026-08-20 10:00:00','draft'),(1,'2026-08-20 10:10:00','draft'),(1,'2026-08-20 18:00:00','draft')`).Error)
+
+	r := gin.New()
+	r.POST("/shifts/:id/assign-staff", ctl.AssignStaff)
+	r.GET("/staff/:id/schedule", ctl.StaffSchedule)
+	r.GET("/staff/:id/availability", ctl.ListStaffAvailability)
+	r.POST("/staff/:id/availability", ctl.CreateStaffAvailability)
+	r.DELETE("/staff/:id/availability/:availabilityId", ctl.DeleteStaffAvailability)
+
+	cases := []struct {
+		method string
+		path   string
+		body   string
+		code   int
+		want   string
+	}{
+		{http.MethodPost, "/staff/1/availability", `{"start_time":"2026-08-20 08:00:00","end_time":"2026-08-20 14:00:00"}`, http.StatusCreated, ""},
+		{http.MethodPost, "/staff/2/availability", `{"start_time":"2026-08-20 08:00:00","end_time":"2026-08-20 14:00:00"}`, http.StatusBadRequest, ""},
+		{http.MethodPost, "/staff/9/availability", `{"start_time":"2026-08-20 08:00:00","end_time":"2026-08-20 14:00:00"}`, http.StatusNotFound, ""},
+		{http.MethodPost, "/staff/1/availability", `{"start_time":"bad","end_time":"2026-08-20 14:00:00"}`, http.StatusBadRequest, ""},
+		{http.MethodGet, "/staff/1/availability", "", http.StatusOK, ""},
+		{http.MethodGet, "/staff/x/availability", "", http.StatusBadRequest, ""},
+		{http.MethodPost, "/shifts/1/assign-staff", `{"staff_id":1}`, http.StatusOK, ""},
+		{http.MethodPost, "/shifts/2/assign-staff", `{"staff_id":1}`, http.StatusConflict, ""},
+		{http.MethodPost, "/shifts/3/assign-staff", `{"staff_id":1}`, http.StatusOK, `"warning":"outside_availability"`},
+		{http.MethodPost, "/shifts/9/assign-staff", `{"staff_id":1}`, http.StatusNotFound, ""},
+		{http.MethodGet, "/staff/1/schedule?date=2026-08-20", "", http.StatusOK, `"overlaps":[]`},
+		{http.MethodGet, "/staff/1/schedule?date=bad", "", http.StatusBadRequest, ""},
+		{http.MethodGet, "/staff/9/schedule", "", http.StatusNotFound, ""},
+		{http.MethodGet, "/staff/2/schedule", "", http.StatusBadRequest, ""},
+		{http.MethodDelete, "/staff/2/availability/1", "", http.StatusNotFound, ""},
+		{http.MethodDelete, "/staff/1/availability/1", "", http.StatusOK, ""},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.method+" "+tc.path+" "+tc.body)
+		if tc.want != "" {
+			assert.Contains(t, w.Body.String(), tc.want)
+		}
+	}
+}
+
+func TestAdminController_ShiftCapacity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+
+	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','SUV',4,4,4)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shifts(driver_id,departure_time,status) VALUES (1,'2026-03-01 12:00:00','draft')`).Error)
+
+	r := gin.New()
+	r.GET("/shifts/:id/capacity", ctl.ShiftCapacity)
+
+	cases := []struct {
+		path string
+		code int
+	}{
+		{"/shifts/1/capacity", http.StatusOK},
+		{"/shifts/bad/capacity", http.StatusBadRequest},
+		{"/shifts/9/capacity", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		assert.Equal(t, tc.code, w.Code, tc.path)
+	}
+
+	require.NoError(t, db.Exec(`DROP TABLE shift_staffs`).Error)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/shifts/1/capacity", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestAdminController_ShiftNotifications(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+
+	require.NoError(t, db.Exec(`INSERT INTO notifications(user_id,request_id,shift_id,event,template_id,status,err_code,err_msg) VALUES (1,1,1,'shift_published','tpl','sent',0,'')`).Error)
+
+	r := gin.New()
+	r.GET("/shifts/:id/notifications", ctl.ShiftNotifications)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/shifts/1/notifications", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var items []map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+	require.Len(t, items, 1)
+	assert.Equal(t, "sent", items[0]["status"])
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/shifts/bad/notifications", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	require.NoError(t, db.Exec(`DROP TABLE notifications`).Error)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/shifts/1/notifications", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestAdminController_Terminals(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+
+	r := gin.New()
+	r.GET("/terminals", ctl.ListTerminals)
+	r.POST("/terminals", ctl.CreateTerminal)
+	r.PUT("/terminals/:id", ctl.UpdateTerminal)
+	r.DELETE("/terminals/:id", ctl.DeleteTerminal)
+
+	cases := []struct {
+		method, path, body string
+		code               int
+	}{
+		{http.MethodPost, "/terminals", `{"airport_code":"ORD","terminal":"T5","international":true,"buffer_minutes":90,"meeting_point":"Door 5E"}`, http.StatusCreated},
+		{http.MethodPost, "/terminals", `{"airport_code":"ORD"}`, http.StatusBadRequest},
+		{http.MethodPost, "/terminals", `{"airport_code":"ORD","terminal":"T5","buffer_minutes":90}`, http.StatusBadRequest},
+		{http.MethodPut, "/terminals/1", `{"airport_code":"ORD","terminal":"T5","international":true,"buffer_minutes":100}`, http.StatusOK},
+		{http.MethodPut, "/terminals/bad", `{}`, http.StatusBadRequest},
+		{http.MethodPut, "/terminals/1", `{"airport_code":"ORD"}`, http.StatusBadRequest},
+		{http.MethodPut, "/terminals/9", `{"airport_code":"ORD","terminal":"T1","buffer_minutes":45}`, http.StatusNotFound},
+		{http.MethodGet, "/terminals", "", http.StatusOK},
+		{http.MethodDelete, "/terminals/bad", "", http.StatusBadRequest},
+		{http.MethodDelete, "/terminals/1", "", http.StatusOK},
+		{http.MethodDelete, "/terminals/1", "", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.method+" "+tc.path+" "+w.Body.String())
+	}
+
+	require.NoError(t, db.Exec(`DROP TABLE airport_terminals`).Error)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/terminals", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestAdminController_Campaigns(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+
+	r := gin.New()
+	r.GET("/campaigns", ctl.ListCampaigns)
+	r.GET("/campaigns/active", ctl.ActiveCampaign)
+	r.POST("/campaigns", ctl.CreateCampaign)
+	r.PUT("/campaigns/:id", ctl.UpdateCampaign)
+	r.POST("/campaigns/:id/activate", ctl.ActivateCampaign)
+	r.GET("/pending", ctl.PendingRequests)
+	r.GET("/drivers", ctl.ListDrivers)
+
+	cases := []struct {
+		method, path, body string
+		code               int
+	}{
+		{http.MethodGet, "/campaigns/active", "", http.StatusNotFound},
+		{http.MethodPost, "/campaigns", `{"name":"Fall 2026","start_date":"2026-08-01","end_date":"2026-08-31"}`, http.StatusCreated},
+		{http.MethodPost, "/campaigns", `{"name":"Fall 2026"}`, http.StatusBadRequest},
+		{http.MethodPost, "/campaigns", `{"name":"x","start_date":"bad","end_date":"2026-08-31"}`, http.StatusBadRequest},
+		{http.MethodPost, "/campaigns", `{"name":"x","start_date":"2026-08-01","end_date":"bad"}`, http.StatusBadRequest},
+		{http.MethodPost, "/campaigns", `{"name":"x","start_date":"2026-08-31","end_date":"2026-08-01"}`, http.StatusBadRequest},
+		{http.MethodPut, "/campaigns/1", `{"name":"Fall 2026","start_date":"2026-08-01","end_date":"2026-09-05","status":"closed"}`, http.StatusOK},
+		{http.MethodPut, "/campaigns/bad", `{}`, http.StatusBadRequest},
+		{http.MethodPut, "/campaigns/1", `{}`, http.StatusBadRequest},
+		{http.MethodPut, "/campaigns/1", `{"name":"x","start_date":"bad","end_date":"2026-08-31"}`, http.StatusBadRequest},
+		{http.MethodPut, "/campaigns/9", `{"name":"x","start_date":"2026-08-01","end_date":"2026-08-31"}`, http.StatusNotFound},
+		{http.MethodPut, "/campaigns/1", `{"name":"x","start_date":"2026-08-01","end_date":"2026-08-31","status":"bogus"}`, http.StatusBadRequest},
+		{http.MethodPost, "/campaigns/bad/activate", "", http.StatusBadRequest},
+		{http.MethodPost, "/campaigns/9/activate", "", http.StatusNotFound},
+		{http.MethodPost, "/campaigns/1/activate", "", http.StatusOK},
+		{http.MethodGet, "/campaigns/active", "", http.StatusOK},
+		{http.MethodGet, "/campaigns", "", http.StatusOK},
+		{http.MethodGet, "/pending?campaign_id=bad", "", http.StatusBadRequest},
+		{http.MethodGet, "/pending?campaign_id=1", "", http.StatusOK},
+		{http.MethodGet, "/drivers?campaign_id=x", "", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.method+" "+tc.path+" "+w.Body.String())
+	}
+
+	require.NoError(t, db.Exec(`DROP TABLE campaigns`).Error)
+	for _, path := range []string{"/campaigns", "/campaigns/active", "/pending", "/drivers"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code, path)
+	}
+}
+
+func TestDriverController_Shifts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	adminCtl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+	ctl := NewDriverController(service.NewDriverService(db), time.UTC)
+
+	require.NoError(t, db.Exec(`INSERT INTO users(open_id,name,phone,role) VALUES ('drv','Wang','','student'),('stu','Li','13800000000','student'),('staff','Lee','','staff')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','SUV',4,4,4)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shifts(driver_id,departure_time,status) VALUES (1,'2026-08-20 10:00:00','published'),(1,'2026-08-20 16:00:00','draft')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(user_id,flight_no,arrival_date,terminal,checked_bags,status) VALUES (2,'CA981','2026-08-20','T1',2,'published')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shift_requests(shift_id,request_id) VALUES (1,1)`).Error)
+
+	r := gin.New()
+	r.POST("/admin/drivers/:id/link-user", adminCtl.LinkDriverUser)
+	r.POST("/admin/drivers/:id/unlink-user", adminCtl.UnlinkDriverUser)
+	withUser := func(h gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			if id := c.GetHeader("X-User"); id != "" {
+				var uid uint
+				fmt.Sscan(id, &uid)
+				c.Set("user_id", uid)
+			}
+			h(c)
+		}
+	}
+	r.GET("/driver/shifts", withUser(ctl.MyShifts))
+	r.GET("/driver/shifts/:id", withUser(ctl.MyShift))
+	r.POST("/driver/shifts/:id/accept", withUser(ctl.AcceptShift))
+	r.POST("/driver/shifts/:id/decline", withUser(ctl.DeclineShift))
+	r.GET("/driver/availability", withUser(ctl.MyAvailability))
+	r.POST("/driver/availability", withUser(ctl.CreateMyAvailability))
+	r.DELETE("/driver/availability/:availabilityId", withUser(ctl.DeleteMyAvailability))
+	require.NoError(t, db.Exec(`INSERT INTO driver_availabilities(driver_id,start_time,end_time,source) VALUES (1,'2026-08-20 06:00:00','2026-08-20 09:00:00','admin')`).Error)
+
+	cases := []struct {
+		method string
+		path   string
+		user   string
+		body   string
+		code   int
+		want   string
+	}{
+		{http.MethodGet, "/driver/shifts", "1", "", http.StatusForbidden, ""},
+		{http.MethodPost, "/driver/availability", "1", `{"start_time":"2026-08-20 08:00:00","end_time":"2026-08-20 12:00:00"}`, http.StatusForbidden, ""},
+		{http.MethodPost, "/admin/drivers/1/link-user", "", `{}`, http.StatusBadRequest, ""},
+		{http.MethodPost, "/admin/drivers/9/link-user", "", `{"user_id":1}`, http.StatusNotFound, ""},
+		{http.MethodPost, "/admin/drivers/1/link-user", "", `{"user_id":3}`, http.StatusBadRequest, ""},
+		{http.MethodPost, "/admin/drivers/1/link-user", "", `{"user_id":1}`, http.StatusOK, `"user_id":1`},
+		{http.MethodPost, "/admin/drivers/1/link-user", "", `{"user_id":2}`, http.StatusConflict, ""},
+		{http.MethodGet, "/driver/shifts", "", "", http.StatusUnauthorized, ""},
+		{http.MethodGet, "/driver/shifts", "1", "", http.StatusOK, `"phone":"13800000000"`},
+		{http.MethodGet, "/driver/shifts/1", "1", "", http.StatusOK, `"checked_bags":2`},
+		{http.MethodGet, "/driver/shifts/2", "1", "", http.StatusNotFound, ""},
+		{http.MethodGet, "/driver/shifts/x", "1", "", http.StatusBadRequest, ""},
+		{http.MethodPost, "/driver/shifts/1/decline", "1", `{"reason":"sick"}`, http.StatusOK, `"driver_status":"declined"`},
+		{http.MethodPost, "/driver/shifts/1/accept", "1", "", http.StatusOK, `"driver_status":"accepted"`},
+		{http.MethodPost, "/driver/shifts/2/accept", "1", "", http.StatusConflict, ""},
+		{http.MethodGet, "/driver/availability", "", "", http.StatusUnauthorized, ""},
+		{http.MethodPost, "/driver/availability", "1", `{"start_time":"bad","end_time":"2026-08-20 12:00:00"}`, http.StatusBadRequest, ""},
+		{http.MethodPost, "/driver/availability", "1", `{"start_time":"2026-08-20 08:00:00","end_time":"2026-08-20 12:00:00"}`, http.StatusCreated, `"source":"self"`},
+		{http.MethodGet, "/driver/availability", "1", "", http.StatusOK, `"source":"admin"`},
+		{http.MethodDelete, "/driver/availability/1", "1", "", http.StatusForbidden, ""},
+		{http.MethodDelete, "/driver/availability/x", "1", "", http.StatusBadRequest, ""},
+		{http.MethodDelete, "/driver/availability/2", "1", "", http.StatusOK, ""},
+		{http.MethodDelete, "/driver/availability/2", "1", "", http.StatusNotFound, ""},
+		{http.MethodPost, "/admin/drivers/1/unlink-user", "", "", http.StatusOK, ""},
+		{http.MethodGet, "/driver/shifts", "1", "", http.StatusForbidden, ""},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", tc.user)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.method+" "+tc.path+" "+tc.body)
+		if tc.want != "" {
+			assert.Contains(t, w.Body.String(), tc.want)
+		}
+	}
+}
+
+func TestStaffController_MyShifts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewStaffController(service.NewStaffService(db, nil, time.UTC), time.UTC)
+
+	require.NoError(t, db.Exec(`INSERT INTO users(open_id,name,phone,role) VALUES ('s1','staff','','staff'),('s2','other','','staff'),('stu','Li','13800000000','student')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','SUV',4,4,4)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shifts(driver_id,departure_time,status) VALUES (1,'2026-03-01 12:00:00','published'),(1,'2026-03-01 18:00:00','published')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(user_id,flight_no,arrival_date,terminal,status) VALUES (3,'AA1','2026-03-01','T1','published')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shift_requests(shift_id,request_id) VALUES (1,1)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shift_staffs(shift_id,staff_id) VALUES (1,1),(2,2)`).Error)
+
+	r := gin.New()
+	withUser := func(h gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			if id := c.GetHeader("X-User"); id != "" {
+				var uid uint
+				fmt.Sscan(id, &uid)
+				c.Set("user_id", uid)
+			}
+			h(c)
+		}
+	}
+	r.GET("/shifts/my", withUser(ctl.MyShifts))
+	r.GET("/shifts/:id", withUser(ctl.MyShift))
+	r.POST("/shifts/:id/start", withUser(ctl.StartShift))
+	r.POST("/shifts/:id/complete", withUser(ctl.CompleteShift))
+
+	cases := []struct {
+		method string
+		path   string
+		user   string
+		code   int
+		want   string
+	}{
+		{http.MethodGet, "/shifts/my", "", http.StatusUnauthorized, ""},
+		{http.MethodGet, "/shifts/my", "1", http.StatusOK, `"phone":"138****0000"`},
+		{http.MethodGet, "/shifts/1", "1", http.StatusOK, `"manifest":[`},
+		{http.MethodGet, "/shifts/2", "1", http.StatusForbidden, ""},
+		{http.MethodGet, "/shifts/x", "1", http.StatusBadRequest, ""},
+		{http.MethodPost, "/shifts/2/start", "1", http.StatusForbidden, ""},
+		{http.MethodPost, "/shifts/1/complete", "1", http.StatusBadRequest, ""},
+		{http.MethodPost, "/shifts/1/start", "1", http.StatusOK, `"status":"in_progress"`},
+		{http.MethodPost, "/shifts/1/complete", "1", http.StatusOK, `"status":"completed"`},
+		{http.MethodGet, "/shifts/my", "1", http.StatusOK, `[]`},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("X-User", tc.user)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.method+" "+tc.path)
+		if tc.want != "" {
+			assert.Contains(t, w.Body.String(), tc.want)
+		}
+	}
+}
+
+func TestAdminController_DashboardAndPendingQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+
+	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','SUV',4,4,4)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shifts(driver_id,departure_time,status) VALUES (1,'2026-08-20 10:00:00','draft'),(1,'2026-08-20 14:00:00','published'),(1,'2026-08-21 10:00:00','completed')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(user_id,flight_no,arrival_date,terminal,status) VALUES (1,'UA881','2026-08-20','T1','pending'),(2,'AA100','2026-08-21','T5','pending')`).Error)
+
+	r := gin.New()
+	r.GET("/dashboard", ctl.Dashboard)
+	r.GET("/pending", ctl.PendingRequests)
+
+	cases := []struct {
+		path string
+		code int
+		want string
+	}{
+		{"/dashboard", http.StatusOK, `"total":2`},
+		{"/dashboard?limit=1", http.StatusOK, `"next_cursor":"`},
+		{"/dashboard?status=completed,published", http.StatusOK, `"total":2`},
+		{"/dashboard?from=2026-08-20&to=2026-08-20&driver_id=1", http.StatusOK, `"total":2`},
+		{"/dashboard?status=parked", http.StatusBadRequest, ""},
+		{"/dashboard?sort=name", http.StatusBadRequest, ""},
+		{"/dashboard?limit=x", http.StatusBadRequest, ""},
+		{"/dashboard?limit=999", http.StatusBadRequest, ""},
+		{"/dashboard?from=bad", http.StatusBadRequest, ""},
+		{"/dashboard?driver_id=x", http.StatusBadRequest, ""},
+		{"/dashboard?cursor=abc", http.StatusBadRequest, ""},
+		{"/pending", http.StatusOK, `"total":2`},
+		{"/pending?terminal=T5", http.StatusOK, `"flight_no":"AA100"`},
+		{"/pending?flight_no=ua8&sort=-arrival_date", http.StatusOK, `"total":1`},
+		{"/pending?to=bad", http.StatusBadRequest, ""},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		assert.Equal(t, tc.code, w.Code, tc.path)
+		if tc.want != "" {
+			assert.Contains(t, w.Body.String(), tc.want, tc.path)
+		}
+	}
+}
+
+func TestController_ExportManifests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	adminCtl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+	staffCtl := NewStaffController(service.NewStaffService(db, nil, time.UTC), time.UTC)
+
+	require.NoError(t, db.Exec(`INSERT INTO users(open_id,name,phone,role) VALUES ('s1','staff','','staff'),('stu','Li','13800000000','student')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','SUV',4,4,4)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shifts(driver_id,departure_time,status) VALUES (1,?,'published')`, time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(user_id,flight_no,arrival_date,terminal,checked_bags,status) VALUES (2,'AA1','2026-03-01','T1',2,'published')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shift_requests(shift_id,request_id) VALUES (1,1)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shift_staffs(shift_id,staff_id) VALUES (1,1)`).Error)
+
+	r := gin.New()
+	withRole := func(role string, h gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("user_id", uint(1))
+			c.Set("user_role", role)
+			h(c)
+		}
+	}
+	r.GET("/admin/exports/manifests", withRole("admin", adminCtl.ExportManifests))
+	r.GET("/staff/exports/manifests", withRole("staff", staffCtl.ExportManifests))
+
+	cases := []struct {
+		path        string
+		code        int
+		contentType string
+		want        string
+	}{
+		{"/admin/exports/manifests?date=2026-03-01", http.StatusOK, "text/csv; charset=utf-8", "Li,13800000000,AA1,,T1,1,2,0"},
+		{"/staff/exports/manifests?date=2026-03-01", http.StatusOK, "text/csv; charset=utf-8", "Li,138****0000"},
+		{"/admin/exports/manifests?date=2026-03-01&format=xlsx", http.StatusOK, xlsxContentType, "PK"},
+		{"/admin/exports/manifests?date=03-01", http.StatusBadRequest, "", ""},
+		{"/staff/exports/manifests?format=pdf", http.StatusBadRequest, "", ""},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		assert.Equal(t, tc.code, w.Code, tc.path)
+		if tc.code != http.StatusOK {
+			continue
+		}
+		assert.Equal(t, tc.contentType, w.Header().Get("Content-Type"), tc.path)
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "manifests-2026-03-01.", tc.path)
+		assert.Contains(t, w.Body.String(), tc.want, tc.path)
+	}
+}
+
+func TestAdminController_ImportRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, time.UTC), time.UTC)
+	r := gin.New()
+	r.POST("/admin/imports/requests", ctl.ImportRequests)
+
+	csvBody := "姓名,phone,flight_no,arrival_date,terminal\nLi,13800000000,UA881,2026-08-20,T5\n"
+	multipartBody := func(dryRun string) (*bytes.Buffer, string) {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		fw, err := mw.CreateFormFile("file", "requests.csv")
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(csvBody))
+		require.NoError(t, err)
+		require.NoError(t, mw.WriteField("mapping", `{"name":"姓名"}`))
+		require.NoError(t, mw.WriteField("dry_run", dryRun))
+		require.NoError(t, mw.Close())
+		return &buf, mw.FormDataContentType()
+	}
+
+	body, contentType := multipartBody("true")
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/admin/imports/requests", body)
+	req.Header.Set("Content-Type", contentType)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"dry_run":true`)
+	assert.Contains(t, w.Body.String(), `"created":1`)
+
+	for _, want := range []string{`"created":1`, `"existing":1`} {
+		body, contentType = multipartBody("false")
+		w = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, "/admin/imports/requests", body)
+		req.Header.Set("Content-Type", contentType)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), want)
+	}
+
+	cases := []struct {
+		path string
+		body string
+		code int
+		want string
+	}{
+		{"/admin/imports/requests", "name,phone,flight_no,arrival_date,terminal\nWang,139,AA1,2026-08-20,T3\n", http.StatusUnprocessableEntity, `"status":"invalid"`},
+		{"/admin/imports/requests", "name,phone\n", http.StatusBadRequest, "not found"},
+		{"/admin/imports/requests?mapping=x", csvBody, http.StatusBadRequest, "mapping"},
+		{"/admin/imports/requests?dry_run=maybe", csvBody, http.StatusBadRequest, "dry_run"},
+		{"/admin/imports/requests?campaign_id=9", "name,phone,flight_no,arrival_date,terminal\n", http.StatusNotFound, ""},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "text/csv")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.path)
+		assert.Contains(t, w.Body.String(), tc.want, tc.path)
+	}
+}
+
+func TestCalendarController_Feed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	ctl := NewCalendarController(service.NewCalendarService(db, &config.JWTConfig{Secret: "secret"}, time.UTC))
+
+	require.NoError(t, db.Exec(`INSERT INTO users(open_id,name,phone,role) VALUES ('stu','Li','13800000000','student')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','SUV',4,4,4)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shifts(driver_id,departure_time,status) VALUES (1,?,'published')`, time.Now().Add(24*time.Hour)).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(user_id,flight_no,arrival_date,terminal,status) VALUES (1,'AA1','2026-03-01','T1','published')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO shift_requests(shift_id,request_id) VALUES (1,1)`).Error)
+
+	r := gin.New()
+	withUser := func(h gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			if c.GetHeader("X-User") != "" {
+				c.Set("user_id", uint(1))
+			}
+			h(c)
+		}
+	}
+	r.GET("/api/v1/calendar/:token", ctl.Feed)
+	r.POST("/api/v1/calendar/feed", withUser(ctl.IssueFeed))
+	r.DELETE("/api/v1/calendar/feed", withUser(ctl.RevokeFeed))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/calendar/feed", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/calendar/feed", nil)
+	req.Header.Set("X-User", "1")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var issued struct {
+		Token string `json:"token"`
+		URL   string `json:"url"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &issued))
+	assert.Equal(t, "https://example.com/api/v1/calendar/"+issued.Token+".ics", issued.URL)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/calendar/"+issued.Token+".ics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "SUMMARY:接机 AA1")
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/calendar/feed", nil)
+	req.Header.Set("X-User", "1")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/calendar/"+issued.Token+".ics", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCalendarController_FeedUsesSchedulerTimezone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newControllerTestDB(t)
+	loc := service.NewSchedulerLocation(&config.SchedulerConfig{Timezone: "America/Chicago"})
+	adminCtl := NewAdminController(service.NewAdminService(db, service.NewShiftAssignmentService(db, nil), nil, nil, loc), loc)
+	ctl := NewCalendarController(service.NewCalendarService(db, &config.JWTConfig{Secret: "secret"}, loc))
+
+	require.NoError(t, db.Exec(`INSERT INTO users(open_id,name,phone,role) VALUES ('stu','Li','13800000000','student')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO drivers(name,car_model,max_seats,max_checked,max_carry_on) VALUES ('d1','SUV',4,4,4)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(user_id,flight_no,arrival_date,terminal,status) VALUES (1,'AA1',?,'T1','pending')`, time.Now()).Error)
+
+	r := gin.New()
+	r.POST("/shifts", adminCtl.CreateShift)
+	r.POST("/shifts/:id/assign-student", adminCtl.AssignStudent)
+	r.POST("/shifts/:id/publish", adminCtl.PublishShift)
+	r.POST("/calendar/feed", func(c *gin.Context) { c.Set("user_id", uint(1)); ctl.IssueFeed(c) })
+	r.GET("/calendar/:token", ctl.Feed)
+
+	// 管理员录入的是芝加哥当地时间，订阅中应原样出现，而不是按 UTC 偏移 5 小时。
+	departure := time.Now().In(loc).AddDate(0, 0, 1).Format("2006-01-02") + " 14:30:00"
+	for _, step := range []struct{ path, body string }{
+		{"/shifts", `{"driver_id":1,"departure_time":"` + departure + `"}`},
+		{"/shifts/1/assign-student", `{"request_id":1}`},
+		{"/shifts/1/publish", ``},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, step.path, strings.NewReader(step.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		require.Less(t, w.Code, 300, step.path+": "+w.Body.String())
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/calendar/feed", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var issued struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &issued))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/calendar/"+issued.Token+".ics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	dtstart := "DTSTART;TZID=America/Chicago:" + strings.NewReplacer("-", "", " ", "T", ":", "").Replace(departure) + "\r\n"
+	assert.Contains(t, w.Body.String(), dtstart)
+}
+
+func TestStreamController_Stream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	broker := events.NewBroker()
+	first := broker.Subscribe(0, false).LastID + 1
+	broker.Publish(events.RequestCreated, map[string]any{"request_id": 1})
+	broker.Publish(events.StudentAssigned, map[string]any{"shift_id": 2, "request_id": 1})
+	broker.Publish(events.ShiftPublished, map[string]any{"shift_id": 2})
+	// 关闭后订阅只会收到补发的历史事件，处理函数随即返回。
+	broker.Close()
+
+	r := gin.New()
+	r.GET("/api/v1/admin/stream", NewStreamController(broker).Stream)
+	stream := func(header, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/stream"+query, nil)
+		if header != "" {
+			req.Header.Set("Last-Event-ID", header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := stream(fmt.Sprint(first), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "retry: 3000\n\n"+
+		fmt.Sprintf("id: %d\nevent: shift.student_assigned\ndata: {\"request_id\":1,\"shift_id\":2}\n\n", first+1)+
+		fmt.Sprintf("id: %d\nevent: shift.published\ndata: {\"shift_id\":2}\n\n", first+2),
+		w.Body.String())
+
+	w = stream("", fmt.Sprintf("?last_event_id=%d", first+1))
+	assert.NotContains(t, w.Body.String(), "shift.student_assigned")
+	assert.Contains(t, w.Body.String(), "event: shift.published")
+
+	// 未带 ID 时只订阅新事件；ID 不在历史中时提示整体刷新。
+	assert.Equal(t, "retry: 3000\n\n", stream("", "").Body.String())
+	assert.Contains(t, stream("1", "").Body.String(), fmt.Sprintf("id: %d\nevent: reset\ndata: {}\n\n", first+2))
+	assert.Equal(t, http.StatusBadRequest, stream("abc", "").Code)
+}
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"pickup/internal/scheduler/middlewares"
+	"pickup/internal/scheduler/models"
+	"pickup/internal/scheduler/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DriverController struct {
+	svc *service.DriverService
+	// loc 解释请求中不带时区的日期时间，见 service.NewSchedulerLocation。
+	loc *time.Location
+}
+
+func NewDriverController(svc *service.DriverService, loc *time.Location) *DriverController {
+	return &DriverController{svc: svc, loc: loc}
+}
+
+type declineShiftRequest struct {
+	Reason string `json:"reason"`
+}
+
+type linkDriverUserRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// driverErrorStatus 未关联司机或班次不属于该司机时返回 403/404。
+func driverErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrDriverNotLinked):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrShiftNotForDriver), errors.Is(err, service.ErrShiftNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrShiftNotRespondable):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func (ctl *DriverController) MyShifts(c *gin.Context) {
+	userID, ok := middlewares.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	items, err := ctl.svc.MyShifts(userID)
+	if err != nil {
+		status := driverErrorStatus(err)
+		if status == http.StatusBadRequest {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func (ctl *DriverController) MyShift(c *gin.Context) {
+	userID, ok := middlewares.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	shiftID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shift id"})
+		return
+	}
+	item, err := ctl.svc.MyShift(userID, shiftID)
+	if err != nil {
+		c.JSON(driverErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (ctl *DriverController) AcceptShift(c *gin.Context) {
+	ctl.respond(c, true)
+}
+
+func (ctl *DriverController) DeclineShift(c *gin.Context) {
+	ctl.respond(c, false)
+}
+
+func (ctl *DriverController) respond(c *gin.Context, accept bool) {
+	userID, ok := middlewares.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	shiftID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shift id"})
+		return
+	}
+	var input declineShiftRequest
+	if !accept && c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	shift, err := ctl.svc.WithActor(auditActor(c)).Respond(userID, shiftID, accept, input.Reason)
+	if err != nil {
+		c.JSON(driverErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, shift)
+}
+
+func (ctl *AdminController) LinkDriverUser(c *gin.Context) {
+	driverID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid driver id"})
+		return
+	}
+	var input linkDriverUserRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	driver, err := ctl.svc.WithActor(auditActor(c)).LinkDriverUser(driverID, input.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDriverNotFound), errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrDriverAlreadyLinked), errors.Is(err, service.ErrDriverHasUser), errors.Is(err, service.ErrDriverArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, driver)
+}
+
+func (ctl *AdminController) UnlinkDriverUser(c *gin.Context) {
+	driverID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid driver id"})
+		return
+	}
+	driver, err := ctl.svc.WithActor(auditActor(c)).UnlinkDriverUser(driverID)
+	if err != nil {
+		if errors.Is(err, service.ErrDriverNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, driver)
+}
+
+func (ctl *AdminController) ArchiveDriver(c *gin.Context) {
+	ctl.archiveDriver(c, ctl.svc.WithActor(auditActor(c)).ArchiveDriver)
+}
+
+func (ctl *AdminController) UnarchiveDriver(c *gin.Context) {
+	ctl.archiveDriver(c, ctl.svc.WithActor(auditActor(c)).UnarchiveDriver)
+}
+
+func (ctl *AdminController) archiveDriver(c *gin.Context, fn func(uint) (*models.Driver, error)) {
+	driverID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid driver id"})
+		return
+	}
+	driver, err := fn(driverID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDriverNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrDriverHasShifts):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, driver)
+}
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag 版本号即实体标签，如 "3"。
+func etag(version uint) string {
+	return strconv.Quote(strconv.FormatUint(uint64(version), 10))
+}
+
+func setETag(c *gin.Context, version uint) {
+	c.Header("ETag", etag(version))
+}
+
+// requireIfMatch 读取 If-Match 中的版本号：缺失返回 428，无法解析返回 400。
+// 兼容弱标签 W/"3" 与未加引号的 3。
+func requireIfMatch(c *gin.Context) (*uint, bool) {
+	raw := strings.TrimSpace(c.GetHeader("If-Match"))
+	if raw == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required; use the ETag from the last read"})
+		return nil, false
+	}
+	raw = strings.Trim(strings.TrimPrefix(raw, "W/"), `"`)
+	version, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid If-Match"})
+		return nil, false
+	}
+	v := uint(version)
+	return &v, true
+}
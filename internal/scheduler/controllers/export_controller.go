@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"pickup/internal/scheduler/export"
+	"pickup/internal/scheduler/middlewares"
+	"pickup/internal/scheduler/models"
+	"pickup/internal/scheduler/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+var manifestHeader = []string{
+	"shift_id", "departure_time", "driver", "car_model", "name", "phone",
+	"flight_no", "airport", "terminal", "passengers", "checked_bags", "carry_on_bags", "pickup_time",
+}
+
+// manifestExportQuery 解析 ?date=YYYY-MM-DD（按 loc 解释，缺省当天）与 ?format=csv|xlsx（缺省 csv）。
+func manifestExportQuery(c *gin.Context, loc *time.Location) (time.Time, string, bool) {
+	date := time.Now().In(loc)
+	if raw := c.Query("date"); raw != "" {
+		var err error
+		if date, err = time.ParseInLocation("2006-01-02", raw, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date"})
+			return time.Time{}, "", false
+		}
+	}
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return time.Time{}, "", false
+	}
+	return date, format, true
+}
+
+// maskPhonesFor 只有管理员导出完整手机号。
+func maskPhonesFor(c *gin.Context) bool {
+	return middlewares.UserRole(c) != string(models.UserRoleAdmin)
+}
+
+func writeManifestExport(c *gin.Context, rows []service.ManifestRow, date time.Time, format string) {
+	table := export.Table{Header: manifestHeader, Rows: make([][]any, 0, len(rows))}
+	for _, row := range rows {
+		pickup := ""
+		if row.CalcPickupTime != nil {
+			pickup = row.CalcPickupTime.Format("2006-01-02 15:04")
+		}
+		table.Rows = append(table.Rows, []any{
+			row.ShiftID, row.DepartureTime.Format("2006-01-02 15:04"), row.DriverName, row.CarModel,
+			row.Name, row.Phone, row.FlightNo, row.Airport, row.Terminal,
+			row.Passengers, row.CheckedBags, row.CarryOnBags, pickup,
+		})
+	}
+
+	var buf bytes.Buffer
+	contentType := "text/csv; charset=utf-8"
+	var err error
+	if format == "xlsx" {
+		contentType = xlsxContentType
+		err = export.WriteXLSX(&buf, date.Format("2006-01-02"), table)
+	} else {
+		err = export.WriteCSV(&buf, table)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="manifests-%s.%s"`, date.Format("2006-01-02"), format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+func (ctl *AdminController) ExportManifests(c *gin.Context) {
+	svc, ok := ctl.scoped(c)
+	if !ok {
+		return
+	}
+	date, format, ok := manifestExportQuery(c, ctl.loc)
+	if !ok {
+		return
+	}
+	rows, err := svc.ExportManifests(date, maskPhonesFor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	writeManifestExport(c, rows, date, format)
+}
+
+func (ctl *StaffController) ExportManifests(c *gin.Context) {
+	staffID, ok := middlewares.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	date, format, ok := manifestExportQuery(c, ctl.loc)
+	if !ok {
+		return
+	}
+	rows, err := ctl.svc.ExportManifests(staffID, date, maskPhonesFor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	writeManifestExport(c, rows, date, format)
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"pickup/internal/scheduler/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportBytes 导入文件大小上限。
+const maxImportBytes = 4 << 20
+
+// formOrQuery 先取表单字段，再取查询参数，便于 multipart 与原始 CSV 两种上传方式共用参数。
+func formOrQuery(c *gin.Context, key string) string {
+	if v := c.PostForm(key); v != "" {
+		return v
+	}
+	return c.Query(key)
+}
+
+// importSource multipart 上传时取 file 字段，否则把请求体当作 CSV。
+func importSource(c *gin.Context) (io.ReadCloser, error) {
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		return header.Open()
+	}
+	return c.Request.Body, nil
+}
+
+// ImportRequests 接受 multipart 的 file 字段或 text/csv 请求体；
+// mapping 为 JSON 对象（字段 → 表头），dry_run=true 时只校验不写入。
+func (ctl *AdminController) ImportRequests(c *gin.Context) {
+	svc, ok := ctl.scoped(c)
+	if !ok {
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	var opts service.ImportOptions
+	if raw := formOrQuery(c, "mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts.Mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mapping must be a JSON object of field to column"})
+			return
+		}
+	}
+	if raw := formOrQuery(c, "dry_run"); raw != "" {
+		dryRun, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dry_run"})
+			return
+		}
+		opts.DryRun = dryRun
+	}
+	src, err := importSource(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer src.Close()
+
+	report, err := svc.WithActor(auditActor(c)).ImportRequests(src, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImportHasErrors):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "report": report})
+		case errors.Is(err, service.ErrCampaignNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
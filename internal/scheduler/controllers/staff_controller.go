@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"pickup/internal/scheduler/middlewares"
+	"pickup/internal/scheduler/models"
+	"pickup/internal/scheduler/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StaffController struct {
+	svc *service.StaffService
+	// loc 解释请求中不带时区的日期时间，见 service.NewSchedulerLocation。
+	loc *time.Location
+}
+
+func NewStaffController(svc *service.StaffService, loc *time.Location) *StaffController {
+	return &StaffController{svc: svc, loc: loc}
+}
+
+func (ctl *StaffController) CheckIn(c *gin.Context) {
+	staffID, ok := middlewares.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	shiftID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shift id"})
+		return
+	}
+	var input service.CheckInInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := ctl.svc.WithActor(auditActor(c)).CheckIn(staffID, shiftID, input)
+	if err != nil {
+		if errors.Is(err, service.ErrStaffNotOnShift) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// staffErrorStatus 不在该班次值班时返回 403，班次不存在时返回 404。
+func staffErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrStaffNotOnShift):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrShiftNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func (ctl *StaffController) MyShifts(c *gin.Context) {
+	staffID, ok := middlewares.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	items, err := ctl.svc.MyShifts(staffID, maskPhonesFor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func (ctl *StaffController) MyShift(c *gin.Context) {
+	staffID, ok := middlewares.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	shiftID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shift id"})
+		return
+	}
+	item, err := ctl.svc.MyShift(staffID, shiftID, maskPhonesFor(c))
+	if err != nil {
+		c.JSON(staffErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (ctl *StaffController) StartShift(c *gin.Context) {
+	ctl.transitionShift(c, ctl.svc.WithActor(auditActor(c)).StartShift)
+}
+
+func (ctl *StaffController) CompleteShift(c *gin.Context) {
+	ctl.transitionShift(c, ctl.svc.WithActor(auditActor(c)).CompleteShift)
+}
+
+func (ctl *StaffController) transitionShift(c *gin.Context, fn func(staffID, shiftID uint) (*models.Shift, error)) {
+	staffID, ok := middlewares.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	shiftID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shift id"})
+		return
+	}
+	shift, err := fn(staffID, shiftID)
+	if err != nil {
+		c.JSON(staffErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, shift)
+}
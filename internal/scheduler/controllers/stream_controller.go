@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pickup/internal/scheduler/events"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// streamHeartbeat 空闲时发送注释行，避免代理因长时间无数据断开连接。
+	streamHeartbeat = 25 * time.Second
+	// streamRetry 建议客户端断线后的重连间隔（毫秒）。
+	streamRetry = 3000
+)
+
+type StreamController struct {
+	broker *events.Broker
+}
+
+func NewStreamController(broker *events.Broker) *StreamController {
+	return &StreamController{broker: broker}
+}
+
+// Stream 以 SSE 推送管理端变更事件。断线重连时浏览器会自动带上 Last-Event-ID；
+// 不能设置请求头的客户端可改用 ?last_event_id=。ID 已不在保留历史中时先发送 reset 事件，客户端应整体刷新。
+func (ctl *StreamController) Stream(c *gin.Context) {
+	raw := strings.TrimSpace(c.GetHeader("Last-Event-ID"))
+	if raw == "" {
+		raw = strings.TrimSpace(c.Query("last_event_id"))
+	}
+	var lastID uint64
+	if raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid Last-Event-ID"})
+			return
+		}
+		lastID = id
+	}
+
+	sub := ctl.broker.Subscribe(lastID, raw != "")
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+	if sub.Reset {
+		fmt.Fprintf(w, "id: %d\nevent: reset\ndata: {}\n\n", sub.LastID)
+	}
+	for _, ev := range sub.Backlog {
+		writeEvent(w, ev)
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case ev, ok := <-sub.Events:
+			// 通道关闭：连接积压过多被断开或服务停止，客户端带 Last-Event-ID 重连即可补齐。
+			if !ok {
+				return
+			}
+			writeEvent(w, ev)
+			w.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			w.Flush()
+		}
+	}
+}
+
+func writeEvent(w gin.ResponseWriter, ev events.Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, ev.Data)
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -34,6 +35,7 @@ func (ctl *StudentController) CreateRequest(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	setETag(c, res.Version)
 	c.JSON(http.StatusCreated, res)
 }
 
@@ -62,12 +64,41 @@ func (ctl *StudentController) UpdateRequest(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
+	ifMatch, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
 	var input service.UpdateRequestInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	input.IfMatch = ifMatch
 	res, err := ctl.svc.UpdatePendingRequest(userID, uint(id64), input)
+	if err != nil {
+		if errors.Is(err, service.ErrVersionMismatch) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	setETag(c, res.Version)
+	c.JSON(http.StatusOK, res)
+}
+
+func (ctl *StudentController) CancelRequest(c *gin.Context) {
+	userID, ok := middlewares.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id64, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	res, err := ctl.svc.CancelRequest(userID, uint(id64))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -2,10 +2,15 @@ package cron
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
+	"pickup/internal/scheduler/events"
+	"pickup/internal/scheduler/service"
+
 	"github.com/glebarez/sqlite"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -27,29 +32,31 @@ func newCronDB(t *testing.T) *gorm.DB {
 	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
 	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	require.NoError(t, err)
-	require.NoError(t, db.Exec(`CREATE TABLE requests (id INTEGER PRIMARY KEY AUTOINCREMENT, flight_no TEXT, arrival_date DATETIME, status TEXT, terminal TEXT, arrival_time_api DATETIME, pickup_buffer INTEGER, calc_pickup_time DATETIME)`).Error)
+	require.NoError(t, db.Exec(`CREATE TABLE requests (id INTEGER PRIMARY KEY AUTOINCREMENT, flight_no TEXT, arrival_date DATETIME, status TEXT, airport TEXT NOT NULL DEFAULT '', terminal TEXT, arrival_time_api DATETIME, pickup_buffer INTEGER, calc_pickup_time DATETIME, version INTEGER NOT NULL DEFAULT 0)`).Error)
+	require.NoError(t, db.Exec(`CREATE TABLE airport_terminals (id INTEGER PRIMARY KEY AUTOINCREMENT, airport_code TEXT, terminal TEXT, international BOOLEAN, buffer_minutes INTEGER, meeting_point TEXT, created_at DATETIME, updated_at DATETIME)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO airport_terminals(airport_code,terminal,international,buffer_minutes,meeting_point) VALUES ('ORD','T1',0,45,''), ('ORD','T5',1,90,''), ('CMI','MAIN',0,30,'')`).Error)
 	return db
 }
 
 func TestSyncFlightService_BasicBranches(t *testing.T) {
 	db := newCronDB(t)
-	svc := NewSyncFlightService(db, zap.NewNop())
-	svc.baseURL = ""
+	svc := NewSyncFlightService(db, zap.NewNop(), nil, nil)
+	svc.SetProvider(nil)
 
 	err := svc.SyncFlightData(context.Background())
 	require.NoError(t, err)
 
-	_, err = svc.fetchFlight(context.Background(), "AA1")
+	_, err = svc.fetchFlight(context.Background(), "AA1", time.Now())
 	assert.Error(t, err)
 
-	err = svc.batchUpdateByFlightNo(context.Background(), nil)
+	err = svc.batchUpdateByFlightNo(context.Background(), time.Now(), nil)
 	require.NoError(t, err)
 }
 
 func TestRegisterCron_HookLifecycle(t *testing.T) {
 	lc := &fakeLifecycle{}
-	svc := &SyncFlightService{baseURL: "", logger: zap.NewNop()}
-	RegisterCron(lc, svc, zap.NewNop())
+	svc := &SyncFlightService{logger: zap.NewNop()}
+	RegisterCron(lc, svc, service.NewIdempotencyService(nil), zap.NewNop())
 	require.Len(t, lc.hooks, 1)
 	require.NoError(t, lc.hooks[0].OnStart(context.Background()))
 	time.Sleep(10 * time.Millisecond)
@@ -61,16 +68,93 @@ func TestSyncFlightService_WithConfiguredBaseURLAndBatchUpdate(t *testing.T) {
 	today := time.Now().Format("2006-01-02")
 	require.NoError(t, db.Exec(`INSERT INTO requests(flight_no,arrival_date,status,terminal,pickup_buffer) VALUES ('AA100', ?, 'pending', 'T1', 45)`, today).Error)
 
-	svc := NewSyncFlightService(db, zap.NewNop())
-	svc.baseURL = "http://example.test"
+	svc := NewSyncFlightService(db, zap.NewNop(), nil, nil)
+	svc.SetProvider(NewHTTPFlightProvider("http://example.test", &http.Client{Timeout: time.Second}))
 
 	err := svc.SyncFlightData(context.Background())
 	require.NoError(t, err)
 
-	err = svc.batchUpdateByFlightNo(context.Background(), []FlightResult{{
+	err = svc.batchUpdateByFlightNo(context.Background(), time.Now(), []FlightResult{{
 		FlightNo:    "AA100",
 		Terminal:    "T5",
 		ArrivalTime: time.Now(),
 	}})
 	require.NoError(t, err)
 }
+
+type stubFlightProvider map[string]FlightResult
+
+func (p stubFlightProvider) FetchFlight(_ context.Context, flightNo string, _ time.Time) (*FlightResult, error) {
+	res, ok := p[flightNo]
+	if !ok {
+		return nil, ErrFlightNotFound
+	}
+	return &res, nil
+}
+
+func TestSyncFlightService_BufferFromTerminalTable(t *testing.T) {
+	db := newCronDB(t)
+	today := time.Now().Format("2006-01-02")
+	require.NoError(t, db.Exec(`INSERT INTO requests(flight_no,arrival_date,status,airport,terminal,pickup_buffer) VALUES ('AA3321', ?, 'pending', 'CMI', 'MAIN', 45)`, today).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(flight_no,arrival_date,status,airport,terminal,pickup_buffer) VALUES ('UA1', ?, 'pending', '', 'T9', 30)`, today).Error)
+
+	arrival := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	svc := NewSyncFlightService(db, zap.NewNop(), nil, nil)
+	svc.SetProvider(stubFlightProvider{
+		"AA3321": {FlightNo: "AA3321", ArrivalTime: arrival},
+		"UA1":    {FlightNo: "UA1", Terminal: "T9", ArrivalTime: arrival},
+	})
+	require.NoError(t, svc.SyncFlightData(context.Background()))
+
+	var buffers []int
+	require.NoError(t, db.Raw(`SELECT pickup_buffer FROM requests ORDER BY id`).Scan(&buffers).Error)
+	// CMI MAIN 取表内 30 分钟；未配置的航站楼回落到默认缓冲
+	assert.Equal(t, []int{30, 45}, buffers)
+
+	require.NoError(t, db.Exec(`DROP TABLE airport_terminals`).Error)
+	assert.Error(t, svc.batchUpdateByFlightNo(context.Background(), time.Now(), []FlightResult{{FlightNo: "UA1", ArrivalTime: arrival}}))
+}
+
+func TestSyncFlightService_PublishesTimeChanges(t *testing.T) {
+	db := newCronDB(t)
+	today := time.Now().Format("2006-01-02")
+	arrival := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, db.Exec(`INSERT INTO requests(flight_no,arrival_date,status,terminal,arrival_time_api,pickup_buffer) VALUES ('AA1', ?, 'pending', 'T1', ?, 45)`, today, arrival).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(flight_no,arrival_date,status,terminal,pickup_buffer) VALUES ('AA1', ?, 'assigned', 'T1', 45)`, today).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(flight_no,arrival_date,status,terminal,arrival_time_api,pickup_buffer) VALUES ('UA1', ?, 'pending', 'T1', ?, 45)`, today, arrival).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(flight_no,arrival_date,status,terminal,pickup_buffer) VALUES ('AA1', ?, 'published', 'T1', 45)`, today).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(flight_no,arrival_date,status,terminal,pickup_buffer) VALUES ('AA1', ?, 'canceled', 'T1', 45)`, today).Error)
+
+	broker := events.NewBroker()
+	sub := broker.Subscribe(0, false)
+	defer sub.Close()
+	svc := NewSyncFlightService(db, zap.NewNop(), nil, broker)
+	svc.SetProvider(stubFlightProvider{
+		"AA1": {FlightNo: "AA1", ArrivalTime: arrival},
+		"UA1": {FlightNo: "UA1", ArrivalTime: arrival},
+	})
+	require.NoError(t, svc.SyncFlightData(context.Background()))
+
+	// 只有到达时间实际变化的航班广播，且只列出变化的需求；已发布的需求同样回写，已取消的不动。
+	require.Len(t, sub.Events, 1)
+	ev := <-sub.Events
+	assert.Equal(t, events.FlightTimeChanged, ev.Type)
+	var data struct {
+		FlightNo    string    `json:"flight_no"`
+		ArrivalDate string    `json:"arrival_date"`
+		ArrivalTime time.Time `json:"arrival_time"`
+		RequestIDs  []uint    `json:"request_ids"`
+	}
+	require.NoError(t, json.Unmarshal(ev.Data, &data))
+	assert.Equal(t, "AA1", data.FlightNo)
+	assert.Equal(t, today, data.ArrivalDate)
+	assert.True(t, arrival.Equal(data.ArrivalTime))
+	assert.Equal(t, []uint{2, 4}, data.RequestIDs)
+
+	var rows []struct{ CalcPickupTime *time.Time }
+	require.NoError(t, db.Raw(`SELECT calc_pickup_time FROM requests WHERE id IN (4, 5) ORDER BY id`).Scan(&rows).Error)
+	require.Len(t, rows, 2)
+	require.NotNil(t, rows[0].CalcPickupTime)
+	assert.True(t, rows[0].CalcPickupTime.Equal(arrival.Add(45*time.Minute)))
+	assert.Nil(t, rows[1].CalcPickupTime)
+}
@@ -0,0 +1,89 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrFlightNotFound = errors.New("flight not found")
+
+// FlightProvider 航班实时状态数据源。
+type FlightProvider interface {
+	FetchFlight(ctx context.Context, flightNo string, date time.Time) (*FlightResult, error)
+}
+
+// HTTPFlightProvider 基于 FLIGHT_API_URL 的 HTTP 实现。
+// 约定接口：GET {baseURL}/flights/{flight_no}?date=YYYY-MM-DD，返回 FlightAPIResponse。
+type HTTPFlightProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewHTTPFlightProvider(baseURL string, client *http.Client) *HTTPFlightProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 8 * time.Second}
+	}
+	return &HTTPFlightProvider{
+		baseURL: strings.TrimRight(strings.TrimSpace(baseURL), "/"),
+		client:  client,
+	}
+}
+
+func (p *HTTPFlightProvider) FetchFlight(ctx context.Context, flightNo string, date time.Time) (*FlightResult, error) {
+	params := url.Values{}
+	params.Set("date", date.Format("2006-01-02"))
+	endpoint := fmt.Sprintf("%s/flights/%s?%s", p.baseURL, url.PathEscape(flightNo), params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrFlightNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("flight api status: %d", resp.StatusCode)
+	}
+
+	var payload FlightAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+	return payload.toResult(flightNo)
+}
+
+// toResult 将接口响应映射为 FlightResult；到达时间兼容 RFC3339 与 "2006-01-02 15:04:05"。
+// 接口返回的航班号写法可能不同（"AA 100"、ICAO 代码 "AAL100"），回写按航班号匹配需求，始终沿用查询时的航班号。
+func (r FlightAPIResponse) toResult(requestedFlightNo string) (*FlightResult, error) {
+	raw := strings.TrimSpace(r.ArrivalTime)
+	if raw == "" {
+		return nil, fmt.Errorf("flight %s: empty arrival_time", requestedFlightNo)
+	}
+	arrival, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		arrival, err = time.ParseInLocation("2006-01-02 15:04:05", raw, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("flight %s: invalid arrival_time %q", requestedFlightNo, raw)
+		}
+	}
+
+	return &FlightResult{
+		FlightNo:    requestedFlightNo,
+		Terminal:    strings.TrimSpace(r.Terminal),
+		ArrivalTime: arrival,
+	}, nil
+}
@@ -0,0 +1,117 @@
+package cron
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newFixtureFlightServer 用 testdata/flights 下录制的 JSON 模拟航班接口。
+func newFixtureFlightServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flightNo := strings.TrimPrefix(r.URL.Path, "/flights/")
+		if r.URL.Query().Get("date") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if flightNo == "ERR500" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, err := os.ReadFile(filepath.Join("testdata", "flights", flightNo+".json"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPFlightProvider_FetchFlight(t *testing.T) {
+	server := newFixtureFlightServer(t)
+	provider := NewHTTPFlightProvider(server.URL+"/", nil)
+	date := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	res, err := provider.FetchFlight(context.Background(), "AA100", date)
+	require.NoError(t, err)
+	assert.Equal(t, "AA100", res.FlightNo)
+	assert.Equal(t, "T5", res.Terminal)
+	assert.True(t, res.ArrivalTime.Equal(time.Date(2026, 3, 1, 14, 35, 0, 0, time.UTC)))
+
+	res, err = provider.FetchFlight(context.Background(), "UA881", date)
+	require.NoError(t, err)
+	assert.Empty(t, res.Terminal)
+	assert.Equal(t, 9, res.ArrivalTime.Hour())
+
+	// 接口返回 "DAL 42"，结果仍以查询的航班号为准。
+	res, err = provider.FetchFlight(context.Background(), "DL42", date)
+	require.NoError(t, err)
+	assert.Equal(t, "DL42", res.FlightNo)
+
+	_, err = provider.FetchFlight(context.Background(), "BAD1", date)
+	assert.ErrorContains(t, err, "invalid arrival_time")
+
+	_, err = provider.FetchFlight(context.Background(), "NOPE", date)
+	assert.ErrorIs(t, err, ErrFlightNotFound)
+
+	_, err = provider.FetchFlight(context.Background(), "ERR500", date)
+	assert.ErrorContains(t, err, "500")
+}
+
+func TestSyncFlightService_SyncWithFixtures(t *testing.T) {
+	db := newCronDB(t)
+	today := time.Now().Format("2006-01-02")
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	require.NoError(t, db.Exec(`INSERT INTO requests(flight_no,arrival_date,status,terminal,pickup_buffer) VALUES ('AA100', ?, 'pending', 'T1', 45)`, today).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(flight_no,arrival_date,status,terminal,pickup_buffer) VALUES ('UA881', ?, 'assigned', 'T3', 45)`, today).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(flight_no,arrival_date,status,terminal,pickup_buffer) VALUES ('NOPE', ?, 'pending', 'T1', 45)`, today).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(flight_no,arrival_date,status,terminal,pickup_buffer) VALUES ('AA100', ?, 'pending', 'T1', 45)`, yesterday).Error)
+	require.NoError(t, db.Exec(`INSERT INTO requests(flight_no,arrival_date,status,terminal,pickup_buffer) VALUES ('DL42', ?, 'pending', 'T1', 45)`, today).Error)
+
+	server := newFixtureFlightServer(t)
+	svc := NewSyncFlightService(db, zap.NewNop(), nil, nil)
+	svc.SetProvider(NewHTTPFlightProvider(server.URL, nil))
+	require.NoError(t, svc.SyncFlightData(context.Background()))
+
+	type row struct {
+		Terminal       string
+		PickupBuffer   int
+		CalcPickupTime *time.Time
+	}
+	var aa row
+	require.NoError(t, db.Raw(`SELECT terminal, pickup_buffer, calc_pickup_time FROM requests WHERE id = 1`).Scan(&aa).Error)
+	assert.Equal(t, "T5", aa.Terminal)
+	assert.Equal(t, 90, aa.PickupBuffer)
+	require.NotNil(t, aa.CalcPickupTime)
+	assert.True(t, aa.CalcPickupTime.Equal(time.Date(2026, 3, 1, 16, 5, 0, 0, time.UTC)))
+
+	var ua row
+	require.NoError(t, db.Raw(`SELECT terminal, pickup_buffer, calc_pickup_time FROM requests WHERE id = 2`).Scan(&ua).Error)
+	assert.Equal(t, "T3", ua.Terminal)
+	assert.Equal(t, 45, ua.PickupBuffer)
+	require.NotNil(t, ua.CalcPickupTime)
+
+	var untouched row
+	require.NoError(t, db.Raw(`SELECT terminal, pickup_buffer, calc_pickup_time FROM requests WHERE id = 4`).Scan(&untouched).Error)
+	assert.Equal(t, "T1", untouched.Terminal)
+	assert.Nil(t, untouched.CalcPickupTime)
+
+	var dl row
+	require.NoError(t, db.Raw(`SELECT terminal, pickup_buffer, calc_pickup_time FROM requests WHERE id = 5`).Scan(&dl).Error)
+	assert.Equal(t, "T5", dl.Terminal)
+	require.NotNil(t, dl.CalcPickupTime)
+	assert.True(t, dl.CalcPickupTime.Equal(time.Date(2026, 3, 1, 19, 40, 0, 0, time.UTC)))
+}
@@ -2,13 +2,16 @@ package cron
 
 import (
 	"context"
+	"time"
+
+	"pickup/internal/scheduler/service"
 
 	rcron "github.com/robfig/cron/v3"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
 
-func RegisterCron(lc fx.Lifecycle, syncSvc *SyncFlightService, logger *zap.Logger) {
+func RegisterCron(lc fx.Lifecycle, syncSvc *SyncFlightService, idempotency *service.IdempotencyService, logger *zap.Logger) {
 	c := rcron.New()
 
 	lc.Append(fx.Hook{
@@ -21,6 +24,14 @@ func RegisterCron(lc fx.Lifecycle, syncSvc *SyncFlightService, logger *zap.Logge
 			if err != nil {
 				return err
 			}
+			_, err = c.AddFunc("@every 1h", func() {
+				if _, purgeErr := idempotency.PurgeExpired(time.Now()); purgeErr != nil {
+					logger.Error("purge idempotency keys failed", zap.Error(purgeErr))
+				}
+			})
+			if err != nil {
+				return err
+			}
 			c.Start()
 			return nil
 		},
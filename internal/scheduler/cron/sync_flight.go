@@ -3,12 +3,16 @@ package cron
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
+	"pickup/internal/scheduler/events"
 	"pickup/internal/scheduler/models"
+	"pickup/internal/scheduler/service"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -16,6 +20,7 @@ import (
 
 type FlightResult struct {
 	FlightNo    string
+	Airport     string
 	Terminal    string
 	ArrivalTime time.Time
 }
@@ -27,88 +32,194 @@ type FlightAPIResponse struct {
 }
 
 type SyncFlightService struct {
-	db      *gorm.DB
-	logger  *zap.Logger
-	baseURL string
-	client  *http.Client
+	db        *gorm.DB
+	logger    *zap.Logger
+	provider  FlightProvider
+	conflicts *service.ShiftConflictService
+	broker    *events.Broker
 }
 
-func NewSyncFlightService(db *gorm.DB, logger *zap.Logger) *SyncFlightService {
-	return &SyncFlightService{
-		db:      db,
-		logger:  logger,
-		baseURL: strings.TrimSpace(os.Getenv("FLIGHT_API_URL")),
-		client:  &http.Client{Timeout: 8 * time.Second},
+// syncedStatuses 航班同步回写的需求状态；已发布的需求同样回写，班次冲突检测才能覆盖已发布班次。
+var syncedStatuses = []models.RequestStatus{models.RequestStatusPending, models.RequestStatusAssigned, models.RequestStatusPublished}
+
+func NewSyncFlightService(db *gorm.DB, logger *zap.Logger, conflicts *service.ShiftConflictService, broker *events.Broker) *SyncFlightService {
+	svc := &SyncFlightService{db: db, logger: logger, conflicts: conflicts, broker: broker}
+	if baseURL := strings.TrimSpace(os.Getenv("FLIGHT_API_URL")); baseURL != "" {
+		svc.provider = NewHTTPFlightProvider(baseURL, &http.Client{Timeout: 8 * time.Second})
 	}
+	return svc
+}
+
+// SetProvider 替换航班数据源（主要用于测试）。
+func (s *SyncFlightService) SetProvider(provider FlightProvider) {
+	s.provider = provider
 }
 
 func (s *SyncFlightService) SyncFlightData(ctx context.Context) error {
-	if strings.TrimSpace(s.baseURL) == "" {
+	if s.provider == nil {
 		s.logger.Info("flight sync skipped: FLIGHT_API_URL not configured")
 		return nil
 	}
 
-	var flightNos []string
-	today := time.Now().Format("2006-01-02")
-	if err := s.db.Model(&models.Request{}).
-		Where("arrival_date = ? AND status IN ?", today, []models.RequestStatus{models.RequestStatusPending, models.RequestStatusAssigned}).
-		Distinct().
-		Pluck("flight_no", &flightNos).Error; err != nil {
+	type flightRow struct {
+		FlightNo string
+		Airport  string
+		Terminal string
+	}
+	var rows []flightRow
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	if err := s.db.WithContext(ctx).Model(&models.Request{}).
+		Select("flight_no, MAX(airport) AS airport, MAX(terminal) AS terminal").
+		Where("arrival_date = ? AND status IN ?", today, syncedStatuses).
+		Group("flight_no").
+		Scan(&rows).Error; err != nil {
 		return err
 	}
-	if len(flightNos) == 0 {
+	if len(rows) == 0 {
 		return nil
 	}
 
-	s.logger.Info("flight sync placeholder: API integration reserved for future", zap.Int("flight_count", len(flightNos)))
+	updates := make([]FlightResult, 0, len(rows))
+	for _, row := range rows {
+		result, err := s.fetchFlight(ctx, row.FlightNo, now)
+		if err != nil {
+			s.logger.Warn("fetch flight failed", zap.String("flight_no", row.FlightNo), zap.Error(err))
+			continue
+		}
+		if result.Terminal == "" {
+			result.Terminal = row.Terminal
+		}
+		if result.Airport == "" {
+			result.Airport = row.Airport
+		}
+		updates = append(updates, *result)
+	}
+
+	changed, err := s.changedRequests(ctx, now, updates)
+	if err != nil {
+		return err
+	}
+	if err := s.batchUpdateByFlightNo(ctx, now, updates); err != nil {
+		return err
+	}
+	for _, u := range updates {
+		if ids := changed[u.FlightNo]; len(ids) > 0 {
+			s.broker.Publish(events.FlightTimeChanged, map[string]any{
+				"flight_no":    u.FlightNo,
+				"arrival_date": today,
+				"arrival_time": u.ArrivalTime,
+				"terminal":     u.Terminal,
+				"request_ids":  ids,
+			})
+		}
+	}
+	s.logger.Info("flight sync finished", zap.Int("flight_count", len(rows)), zap.Int("updated_count", len(updates)))
+
+	if s.conflicts == nil || len(updates) == 0 {
+		return nil
+	}
+	flightNos := make([]string, 0, len(updates))
+	for _, u := range updates {
+		flightNos = append(flightNos, u.FlightNo)
+	}
+	conflicts, err := s.conflicts.Detect(ctx, flightNos)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		s.logger.Warn("shift conflicts detected after flight sync", zap.Int("conflict_count", len(conflicts)))
+	}
 	return nil
 }
 
-func (s *SyncFlightService) fetchFlight(ctx context.Context, flightNo string) (*FlightResult, error) {
-	return nil, fmt.Errorf("flight api integration placeholder")
+func (s *SyncFlightService) fetchFlight(ctx context.Context, flightNo string, date time.Time) (*FlightResult, error) {
+	if s.provider == nil {
+		return nil, fmt.Errorf("flight provider not configured")
+	}
+	return s.provider.FetchFlight(ctx, flightNo, date)
+}
+
+// changedRequests 按航班号列出到达时间将被改写的需求，用于广播航班时间变化。
+func (s *SyncFlightService) changedRequests(ctx context.Context, arrivalDate time.Time, updates []FlightResult) (map[string][]uint, error) {
+	if len(updates) == 0 {
+		return nil, nil
+	}
+	arrival := make(map[string]time.Time, len(updates))
+	for _, u := range updates {
+		arrival[u.FlightNo] = u.ArrivalTime
+	}
+	var reqs []models.Request
+	if err := s.db.WithContext(ctx).Select("id", "flight_no", "arrival_time_api").
+		Where("flight_no IN ? AND arrival_date = ? AND status IN ?", slices.Collect(maps.Keys(arrival)), arrivalDate.Format("2006-01-02"),
+			syncedStatuses).
+		Order("id ASC").
+		Find(&reqs).Error; err != nil {
+		return nil, err
+	}
+	changed := make(map[string][]uint)
+	for _, req := range reqs {
+		if req.ArrivalTimeAPI == nil || !req.ArrivalTimeAPI.Equal(arrival[req.FlightNo]) {
+			changed[req.FlightNo] = append(changed[req.FlightNo], req.ID)
+		}
+	}
+	return changed, nil
 }
 
-func (s *SyncFlightService) batchUpdateByFlightNo(ctx context.Context, updates []FlightResult) error {
+// batchUpdateByFlightNo 按航班号批量回写指定到达日期的 pending/assigned/published 需求。
+func (s *SyncFlightService) batchUpdateByFlightNo(ctx context.Context, arrivalDate time.Time, updates []FlightResult) error {
 	if len(updates) == 0 {
 		return nil
 	}
 
+	buffers, err := service.LoadTerminalBuffers(s.db.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
 	terminalCase := "CASE flight_no"
 	arrivalCase := "CASE flight_no"
 	bufferCase := "CASE flight_no"
 	pickupCase := "CASE flight_no"
-	args := make([]any, 0, len(updates)*7)
+	// 各 CASE 的占位符在 SQL 中按列分组出现，参数也须按列分组拼接。
+	terminalArgs := make([]any, 0, len(updates)*2)
+	arrivalArgs := make([]any, 0, len(updates)*2)
+	bufferArgs := make([]any, 0, len(updates)*2)
+	pickupArgs := make([]any, 0, len(updates)*2)
 	inArgs := make([]string, 0, len(updates))
+	flightArgs := make([]any, 0, len(updates)+1)
 
 	for _, u := range updates {
-		buffer := 45
-		if u.Terminal == "T5" {
-			buffer = 90
-		}
+		_, buffer := buffers.Lookup(u.Airport, u.Terminal)
 		pickup := u.ArrivalTime.Add(time.Duration(buffer) * time.Minute)
 
 		terminalCase += " WHEN ? THEN ?"
-		args = append(args, u.FlightNo, u.Terminal)
+		terminalArgs = append(terminalArgs, u.FlightNo, u.Terminal)
 		arrivalCase += " WHEN ? THEN ?"
-		args = append(args, u.FlightNo, u.ArrivalTime)
+		arrivalArgs = append(arrivalArgs, u.FlightNo, u.ArrivalTime)
 		bufferCase += " WHEN ? THEN ?"
-		args = append(args, u.FlightNo, buffer)
+		bufferArgs = append(bufferArgs, u.FlightNo, buffer)
 		pickupCase += " WHEN ? THEN ?"
-		args = append(args, u.FlightNo, pickup)
+		pickupArgs = append(pickupArgs, u.FlightNo, pickup)
 
 		inArgs = append(inArgs, "?")
+		flightArgs = append(flightArgs, u.FlightNo)
 	}
 	terminalCase += " END"
 	arrivalCase += " END"
 	bufferCase += " END"
 	pickupCase += " END"
 
-	for _, u := range updates {
-		args = append(args, u.FlightNo)
-	}
+	args := make([]any, 0, len(updates)*9+1)
+	args = append(args, terminalArgs...)
+	args = append(args, arrivalArgs...)
+	args = append(args, bufferArgs...)
+	args = append(args, pickupArgs...)
+	args = append(args, flightArgs...)
+	args = append(args, arrivalDate.Format("2006-01-02"))
 
 	query := fmt.Sprintf(
-		"UPDATE requests SET terminal = %s, arrival_time_api = %s, pickup_buffer = %s, calc_pickup_time = %s WHERE flight_no IN (%s) AND status IN ('pending','assigned')",
+		"UPDATE requests SET terminal = %s, arrival_time_api = %s, pickup_buffer = %s, calc_pickup_time = %s, version = version + 1 WHERE flight_no IN (%s) AND arrival_date = ? AND status IN ('pending','assigned','published')",
 		terminalCase,
 		arrivalCase,
 		bufferCase,
@@ -0,0 +1,162 @@
+// Package events 进程内的管理端变更广播，供 SSE 推送使用；多实例部署时每个实例只广播本实例产生的事件。
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+const (
+	RequestCreated    = "request.created"
+	RequestUpdated    = "request.updated"
+	StudentAssigned   = "shift.student_assigned"
+	StudentRemoved    = "shift.student_removed"
+	StaffAssigned     = "shift.staff_assigned"
+	ShiftPublished    = "shift.published"
+	ShiftUpdated      = "shift.updated"
+	ShiftDeleted      = "shift.deleted"
+	FlightTimeChanged = "flight.time_changed"
+)
+
+const (
+	// historySize 保留最近的事件供 Last-Event-ID 续传。
+	historySize = 1024
+	// subscriberBuffer 每个连接最多积压的事件数，写满即断开该连接。
+	subscriberBuffer = 64
+)
+
+// Event 一条变更通知；ID 单调递增，Data 为 JSON 对象。
+type Event struct {
+	ID   uint64
+	Type string
+	Data json.RawMessage
+}
+
+// Broker 发布不阻塞：某个连接消费过慢、缓冲写满时直接关闭该连接，
+// 客户端带 Last-Event-ID 重连后从历史中补齐，不影响其他连接和发布方。
+// nil *Broker 可以安全调用 Publish，表示不广播。
+type Broker struct {
+	mu      sync.Mutex
+	lastID  uint64
+	history []Event
+	subs    map[*Subscription]struct{}
+	closed  bool
+}
+
+// NewBroker ID 从启动时刻的毫秒数 ×1000 起算，重启后新 ID 总大于旧 ID，
+// 客户端带着旧 ID 重连时落在历史之外，会收到 reset 而不是错位的事件。
+func NewBroker() *Broker {
+	return &Broker{
+		lastID: uint64(time.Now().UnixMilli()) * 1000,
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+// RegisterBroker 停机时先关闭所有订阅，长连接随之结束，HTTP 服务才能按时退出。
+func RegisterBroker(lc fx.Lifecycle, b *Broker) {
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			b.Close()
+			return nil
+		},
+	})
+}
+
+// Publish 广播一条事件；data 序列化失败时丢弃。
+func (b *Broker) Publish(typ string, data any) {
+	if b == nil {
+		return
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.lastID++
+	ev := Event{ID: b.lastID, Type: typ, Data: raw}
+	if len(b.history) == historySize {
+		copy(b.history, b.history[1:])
+		b.history[len(b.history)-1] = ev
+	} else {
+		b.history = append(b.history, ev)
+	}
+	for sub := range b.subs {
+		select {
+		case sub.ch <- ev:
+		default:
+			b.dropLocked(sub)
+		}
+	}
+}
+
+// Subscription 一个连接的订阅。先发送 Backlog，再读取 Events；Events 关闭表示连接因积压被断开或服务停止。
+type Subscription struct {
+	Events <-chan Event
+	// Backlog 续传时 Last-Event-ID 之后已发布的事件。
+	Backlog []Event
+	// Reset 为 true 表示 Last-Event-ID 已不在历史中，客户端应整体重新加载。
+	Reset bool
+	// LastID 订阅时最新的事件 ID，Reset 时作为新的续传起点。
+	LastID uint64
+
+	ch     chan Event
+	broker *Broker
+}
+
+// Subscribe resume 为 false 时只接收之后的新事件；为 true 时补发 lastEventID 之后的历史事件。
+func (b *Broker) Subscribe(lastEventID uint64, resume bool) *Subscription {
+	ch := make(chan Event, subscriberBuffer)
+	sub := &Subscription{Events: ch, ch: ch, broker: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sub.LastID = b.lastID
+	if resume {
+		oldest := b.lastID - uint64(len(b.history))
+		if lastEventID < oldest || lastEventID > b.lastID {
+			sub.Reset = true
+		} else {
+			sub.Backlog = append([]Event(nil), b.history[len(b.history)-int(b.lastID-lastEventID):]...)
+		}
+	}
+	if b.closed {
+		close(ch)
+		return sub
+	}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Close 取消订阅，可重复调用。
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.dropLocked(s)
+}
+
+// Close 关闭全部订阅，之后的 Publish 不再广播。
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subs {
+		b.dropLocked(sub)
+	}
+}
+
+func (b *Broker) dropLocked(sub *Subscription) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	close(sub.ch)
+}
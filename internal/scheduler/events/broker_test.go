@@ -0,0 +1,85 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker_PublishAndResume(t *testing.T) {
+	b := NewBroker()
+	live := b.Subscribe(0, false)
+	defer live.Close()
+
+	b.Publish(RequestCreated, map[string]any{"request_id": 1})
+	b.Publish(StudentAssigned, map[string]any{"shift_id": 2, "request_id": 1})
+	first := <-live.Events
+	second := <-live.Events
+	assert.Equal(t, RequestCreated, first.Type)
+	assert.JSONEq(t, `{"request_id":1}`, string(first.Data))
+	assert.Equal(t, first.ID+1, second.ID)
+
+	// 从第一条之后续传，只补发第二条。
+	resumed := b.Subscribe(first.ID, true)
+	defer resumed.Close()
+	assert.False(t, resumed.Reset)
+	require.Len(t, resumed.Backlog, 1)
+	assert.Equal(t, second, resumed.Backlog[0])
+
+	upToDate := b.Subscribe(second.ID, true)
+	defer upToDate.Close()
+	assert.False(t, upToDate.Reset)
+	assert.Empty(t, upToDate.Backlog)
+
+	// 上一个进程的 ID 或未来的 ID 都不在历史中。
+	assert.True(t, b.Subscribe(first.ID-2, true).Reset)
+	stale := b.Subscribe(second.ID+1, true)
+	assert.True(t, stale.Reset)
+	assert.Equal(t, second.ID, stale.LastID)
+}
+
+func TestBroker_HistoryWindow(t *testing.T) {
+	b := NewBroker()
+	start := b.Subscribe(0, false).LastID
+	for i := 0; i < historySize+10; i++ {
+		b.Publish(RequestUpdated, map[string]any{"n": i})
+	}
+	assert.True(t, b.Subscribe(start+5, true).Reset)
+	sub := b.Subscribe(start+10, true)
+	assert.False(t, sub.Reset)
+	require.Len(t, sub.Backlog, historySize)
+	assert.Equal(t, start+11, sub.Backlog[0].ID)
+}
+
+func TestBroker_SlowSubscriberDropped(t *testing.T) {
+	b := NewBroker()
+	slow := b.Subscribe(0, false)
+	fast := b.Subscribe(0, false)
+	defer fast.Close()
+
+	for i := 0; i < subscriberBuffer+1; i++ {
+		b.Publish(RequestUpdated, map[string]any{"n": i})
+		<-fast.Events
+	}
+	// 缓冲写满后慢连接被关闭，已缓冲的事件仍可读完；发布方与其他连接不受影响。
+	n := 0
+	for range slow.Events {
+		n++
+	}
+	assert.Equal(t, subscriberBuffer, n)
+	slow.Close()
+
+	b.Publish(ShiftPublished, map[string]any{"shift_id": 1})
+	ev := <-fast.Events
+	assert.Equal(t, ShiftPublished, ev.Type)
+
+	b.Close()
+	_, open := <-fast.Events
+	assert.False(t, open)
+	_, open = <-b.Subscribe(0, false).Events
+	assert.False(t, open)
+
+	var none *Broker
+	none.Publish(RequestCreated, nil)
+}
@@ -0,0 +1,59 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	table := Table{
+		Header: []string{"name", "phone", "bags"},
+		Rows: [][]any{
+			{"李雷", "+1 217 555 0100", 2},
+			{"=HYPERLINK(\"x\")", "-1+1", uint(0)},
+		},
+	}
+	require.NoError(t, WriteCSV(&buf, table))
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "\ufeffname,phone,bags\n"))
+	assert.Contains(t, out, "李雷,+1 217 555 0100,2\n")
+	assert.Contains(t, out, `"'=HYPERLINK(""x"")",'-1+1,0`)
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	table := Table{Header: []string{"name", "bags"}, Rows: [][]any{{"A & B", 3}}}
+	require.NoError(t, WriteXLSX(&buf, "2026-08-20", table))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		body, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		parts[f.Name] = string(body)
+	}
+	assert.Len(t, parts, 5)
+	assert.Contains(t, parts["xl/workbook.xml"], `<sheet name="2026-08-20"`)
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="A1" t="inlineStr"><is><t xml:space="preserve">name</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">A &amp; B</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="B2"><v>3</v></c>`)
+}
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "BA", columnName(52))
+}
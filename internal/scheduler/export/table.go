@@ -0,0 +1,58 @@
+// Package export 将表格数据写成 CSV 或 XLSX，XLSX 只依赖标准库 archive/zip。
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Table 一张表：Header 为表头，Rows 中的值为 string 或整数。
+type Table struct {
+	Header []string
+	Rows   [][]any
+}
+
+// WriteCSV 写入带 UTF-8 BOM 的 CSV，便于 Excel 正确识别中文。
+func WriteCSV(w io.Writer, t Table) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Header); err != nil {
+		return err
+	}
+	record := make([]string, len(t.Header))
+	for _, row := range t.Rows {
+		record = record[:0]
+		for _, v := range row {
+			record = append(record, csvCell(v))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvCell 以 = + - @ 开头的文本前加单引号，防止被表格软件当作公式执行；纯数字的 +/- 开头（如电话）保持原样。
+func csvCell(v any) string {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	if s == "" {
+		return s
+	}
+	switch s[0] {
+	case '=', '@', '\t', '\r':
+		return "'" + s
+	case '+', '-':
+		if strings.Trim(s[1:], "0123456789 ") != "" {
+			return "'" + s
+		}
+	}
+	return s
+}
@@ -0,0 +1,159 @@
+// Package ical 生成 iCalendar（RFC 5545）订阅内容，时区定义由 time.Location 推导。
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	localLayout = "20060102T150405"
+	utcLayout   = "20060102T150405Z"
+	// maxLineOctets RFC 5545 建议每行不超过 75 字节，超出部分折行。
+	maxLineOctets = 75
+)
+
+// Event 一个日程；UID 需在多次生成间保持不变，Sequence 在时间等内容变更时递增，客户端据此更新而非新建。
+type Event struct {
+	UID         string
+	Sequence    int
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Location    string
+	Description string
+}
+
+// Calendar Events 的时间统一按 Location 输出，并附带对应的 VTIMEZONE。
+type Calendar struct {
+	Name     string
+	Location *time.Location
+	Events   []Event
+}
+
+// Write 以 CRLF 换行写出完整的 VCALENDAR；stamp 为本次生成时间（DTSTAMP）。
+func Write(w io.Writer, cal Calendar, stamp time.Time) error {
+	loc := cal.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	tzid := loc.String()
+	var b bytes.Buffer
+	line := func(s string) { writeFolded(&b, s) }
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//UIUC Pickup//Scheduler//ZH")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	if cal.Name != "" {
+		line("X-WR-CALNAME:" + escapeText(cal.Name))
+	}
+	line("X-WR-TIMEZONE:" + tzid)
+
+	from, to := stamp, stamp
+	for _, e := range cal.Events {
+		if e.Start.Before(from) {
+			from = e.Start
+		}
+		if e.End.After(to) {
+			to = e.End
+		}
+	}
+	writeTimezone(line, loc, from, to)
+
+	for _, e := range cal.Events {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID)
+		line(fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+		line("DTSTAMP:" + stamp.UTC().Format(utcLayout))
+		line(fmt.Sprintf("DTSTART;TZID=%s:%s", tzid, e.Start.In(loc).Format(localLayout)))
+		line(fmt.Sprintf("DTEND;TZID=%s:%s", tzid, e.End.In(loc).Format(localLayout)))
+		line("SUMMARY:" + escapeText(e.Summary))
+		if e.Location != "" {
+			line("LOCATION:" + escapeText(e.Location))
+		}
+		if e.Description != "" {
+			line("DESCRIPTION:" + escapeText(e.Description))
+		}
+		line("STATUS:CONFIRMED")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// writeTimezone 写出覆盖 [from, to] 的 VTIMEZONE：从 from 所在时段起逐个列出时区切换，
+// 直到 to 之后的第一次切换，保证冬令时与夏令时定义都出现。
+// 没有切换记录的时区（如 UTC）输出一个固定偏移的 STANDARD。
+func writeTimezone(line func(string), loc *time.Location, from, to time.Time) {
+	line("BEGIN:VTIMEZONE")
+	line("TZID:" + loc.String())
+	at, _ := from.In(loc).ZoneBounds()
+	if at.IsZero() {
+		name, offset := from.In(loc).Zone()
+		writeZone(line, "STANDARD", "19700101T000000", offset, offset, name)
+	}
+	for !at.IsZero() {
+		name, offset := at.Zone()
+		_, prev := at.Add(-time.Second).Zone()
+		kind := "STANDARD"
+		if at.IsDST() {
+			kind = "DAYLIGHT"
+		}
+		// DTSTART 为切换时刻按切换前偏移表示的本地时间
+		onset := at.In(time.FixedZone("", prev)).Format(localLayout)
+		writeZone(line, kind, onset, prev, offset, name)
+		if at.After(to) {
+			break
+		}
+		_, at = at.ZoneBounds()
+	}
+	line("END:VTIMEZONE")
+}
+
+func writeZone(line func(string), kind, onset string, from, to int, name string) {
+	line("BEGIN:" + kind)
+	line("DTSTART:" + onset)
+	line("TZOFFSETFROM:" + formatOffset(from))
+	line("TZOFFSETTO:" + formatOffset(to))
+	line("TZNAME:" + name)
+	line("END:" + kind)
+}
+
+// formatOffset -18000 → -0500。
+func formatOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign = '-'
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+// escapeText 按 RFC 5545 TEXT 转义反斜杠、分号、逗号与换行。
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeFolded 超过 75 字节的行在字符边界处折行，续行以空格开头。
+func writeFolded(b *bytes.Buffer, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,71 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err)
+	start := time.Date(2026, 8, 20, 14, 30, 0, 0, chicago)
+	cal := Calendar{
+		Name:     "接机安排",
+		Location: chicago,
+		Events: []Event{{
+			UID:         "shift-1@test",
+			Sequence:    2,
+			Start:       start.UTC(),
+			End:         start.Add(2 * time.Hour),
+			Summary:     "接机 UA881",
+			Location:    "ORD T5",
+			Description: "车辆: SUV, 7 座\n航班: UA881; AA100",
+		}},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, cal, time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)))
+	out := buf.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-TIMEZONE:America/Chicago\r\n",
+		"BEGIN:VTIMEZONE\r\nTZID:America/Chicago\r\n",
+		// 夏令时从 3 月开始；之后 11 月的切换也要列出
+		"BEGIN:DAYLIGHT\r\nDTSTART:20260308T020000\r\nTZOFFSETFROM:-0600\r\nTZOFFSETTO:-0500\r\nTZNAME:CDT\r\nEND:DAYLIGHT\r\n",
+		"BEGIN:STANDARD\r\nDTSTART:20261101T020000\r\nTZOFFSETFROM:-0500\r\nTZOFFSETTO:-0600\r\nTZNAME:CST\r\nEND:STANDARD\r\n",
+		"UID:shift-1@test\r\nSEQUENCE:2\r\nDTSTAMP:20260801T000000Z\r\n",
+		"DTSTART;TZID=America/Chicago:20260820T143000\r\n",
+		"DTEND;TZID=America/Chicago:20260820T163000\r\n",
+		`DESCRIPTION:车辆: SUV\, 7 座\n航班: UA881\; AA100` + "\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		assert.Contains(t, out, want)
+	}
+	assert.Equal(t, 1, strings.Count(out, "BEGIN:STANDARD"))
+}
+
+func TestWriteFixedZone(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, Calendar{Location: time.UTC}, time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Contains(t, buf.String(), "BEGIN:STANDARD\r\nDTSTART:19700101T000000\r\nTZOFFSETFROM:+0000\r\nTZOFFSETTO:+0000\r\nTZNAME:UTC\r\n")
+}
+
+func TestWriteFolded(t *testing.T) {
+	var buf bytes.Buffer
+	writeFolded(&buf, "SUMMARY:"+strings.Repeat("接", 40))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	require.Len(t, lines, 2)
+	assert.LessOrEqual(t, len(lines[0]), maxLineOctets)
+	assert.True(t, strings.HasPrefix(lines[1], " "))
+	assert.Equal(t, "SUMMARY:"+strings.Repeat("接", 40), lines[0]+lines[1][1:])
+}
+
+func TestFormatOffset(t *testing.T) {
+	assert.Equal(t, "-0500", formatOffset(-5*3600))
+	assert.Equal(t, "+0530", formatOffset(5*3600+30*60))
+}
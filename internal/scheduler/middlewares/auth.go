@@ -67,3 +67,7 @@ func UserID(c *gin.Context) (uint, bool) {
 	id, ok := idRaw.(uint)
 	return id, ok
 }
+
+func UserRole(c *gin.Context) string {
+	return c.GetString("user_role")
+}
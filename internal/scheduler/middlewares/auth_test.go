@@ -126,3 +126,21 @@ func TestAssignStaff_AdminOnlyChain(t *testing.T) {
 	r.ServeHTTP(w2, req2)
 	assert.Equal(t, http.StatusOK, w2.Code)
 }
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/p", RequestID(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"request_id": RequestIDFrom(c)})
+	})
+
+	w1 := httptest.NewRecorder()
+	req1 := httptest.NewRequest(http.MethodGet, "/p", nil)
+	req1.Header.Set(RequestIDHeader, "abc")
+	r.ServeHTTP(w1, req1)
+	assert.Equal(t, "abc", w1.Header().Get(RequestIDHeader))
+
+	w2 := httptest.NewRecorder()
+	r.ServeHTTP(w2, httptest.NewRequest(http.MethodGet, "/p", nil))
+	assert.Len(t, w2.Header().Get(RequestIDHeader), 32)
+}
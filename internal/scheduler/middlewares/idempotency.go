@@ -0,0 +1,166 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"pickup/internal/scheduler/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayHeader 重放的响应带 Idempotent-Replayed: true。
+	IdempotentReplayHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLen = 128
+	// maxIdempotentBody 读入内存计算指纹的请求体上限，需大于导入文件上限。
+	maxIdempotentBody = 8 << 20
+)
+
+// replayHeaders 随响应一起保存并在重放时还原的响应头。
+var replayHeaders = []string{"Content-Type", "Content-Disposition", "ETag"}
+
+// Idempotency 对带 Idempotency-Key 的 POST 请求按 (用户, key, 路径) 保存首次响应，重试时原样重放。
+// 同一 key 换了查询参数或请求体返回 409；5xx 响应不保存，可用同一 key 重试。
+// 需挂在 JWTAuth 之后；没有 key 或不是 POST 的请求直接放行。
+func Idempotency(store *service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 128 characters"})
+			return
+		}
+		userID, ok := UserID(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBody+1))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(body) > maxIdempotentBody {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		route := c.Request.Method + " " + c.Request.URL.Path
+		sum := sha256.New()
+		sum.Write([]byte(c.Request.URL.RawQuery))
+		sum.Write([]byte{0})
+		writeBodyFingerprint(sum, c.ContentType(), c.GetHeader("Content-Type"), body)
+		record, replay, err := store.Begin(userID, key, route, hex.EncodeToString(sum.Sum(nil)), time.Now())
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, service.ErrIdempotencyKeyReused) || errors.Is(err, service.ErrIdempotencyInProgress) {
+				status = http.StatusConflict
+			}
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if replay {
+			var header map[string]string
+			_ = json.Unmarshal(record.Headers, &header)
+			for k, v := range header {
+				c.Header(k, v)
+			}
+			c.Header(IdempotentReplayHeader, "true")
+			c.Status(record.StatusCode)
+			_, _ = c.Writer.Write(record.Body)
+			c.Abort()
+			return
+		}
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		if w.Status() >= http.StatusInternalServerError {
+			if err := store.Release(record.ID); err != nil {
+				_ = c.Error(err)
+			}
+			return
+		}
+		header := make(map[string]string, len(replayHeaders))
+		for _, k := range replayHeaders {
+			if v := w.Header().Get(k); v != "" {
+				header[k] = v
+			}
+		}
+		if err := store.Complete(record.ID, w.Status(), header, w.body.Bytes()); err != nil {
+			_ = c.Error(err)
+		}
+	}
+}
+
+// writeBodyFingerprint 把请求体写入指纹。multipart 的分隔符每次随机生成，
+// 因此按字段名、文件名与内容摘要计算，重试时换了分隔符仍视为同一请求；解析失败则退回原始字节。
+func writeBodyFingerprint(w io.Writer, mediaType, contentType string, body []byte) {
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		w.Write(body)
+		return
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil || params["boundary"] == "" {
+		w.Write(body)
+		return
+	}
+	var parts []string
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			w.Write(body)
+			return
+		}
+		content := sha256.New()
+		if _, err := io.Copy(content, part); err != nil {
+			w.Write(body)
+			return
+		}
+		parts = append(parts, part.FormName()+"\x00"+part.FileName()+"\x00"+hex.EncodeToString(content.Sum(nil)))
+	}
+	sort.Strings(parts)
+	for _, p := range parts {
+		w.Write([]byte(p))
+		w.Write([]byte{0})
+	}
+}
+
+// recordingWriter 在写出响应的同时保留一份副本。
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
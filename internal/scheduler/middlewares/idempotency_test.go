@@ -0,0 +1,161 @@
+package middlewares
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/service"
+	"pickup/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newIdempotencyStore(t *testing.T) *service.IdempotencyService {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`CREATE TABLE idempotency_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		idempotency_key TEXT NOT NULL,
+		route TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		headers TEXT,
+		body BLOB,
+		expires_at DATETIME NOT NULL,
+		created_at DATETIME,
+		UNIQUE (user_id, idempotency_key, route)
+	);`).Error)
+	return service.NewIdempotencyService(db)
+}
+
+func TestIdempotency_ReplaysFirstResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtUtil := utils.NewJWTUtil("secret", time.Hour, "issuer")
+	token, err := jwtUtil.GenerateToken(123, "admin")
+	require.NoError(t, err)
+	otherToken, err := jwtUtil.GenerateToken(456, "admin")
+	require.NoError(t, err)
+
+	calls := 0
+	failNext := false
+	r := gin.New()
+	r.Use(JWTAuth(jwtUtil), Idempotency(newIdempotencyStore(t)))
+	r.POST("/drivers", func(c *gin.Context) {
+		calls++
+		if failNext {
+			failNext = false
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+			return
+		}
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Header("ETag", `"0"`)
+		c.JSON(http.StatusCreated, gin.H{"id": calls, "echo": string(body)})
+	})
+	r.PUT("/drivers", func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusNoContent)
+	})
+
+	do := func(method, token, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/drivers", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := do(http.MethodPost, token, "k1", `{"name":"d1"}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(IdempotentReplayHeader))
+
+	retry := do(http.MethodPost, token, "k1", `{"name":"d1"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayHeader))
+	assert.Equal(t, `"0"`, retry.Header().Get("ETag"))
+	assert.Contains(t, retry.Header().Get("Content-Type"), "application/json")
+	assert.Equal(t, 1, calls)
+
+	// 同一 key 换了请求体。
+	conflict := do(http.MethodPost, token, "k1", `{"name":"d2"}`)
+	assert.Equal(t, http.StatusConflict, conflict.Code)
+	assert.Equal(t, 1, calls)
+
+	// 其他用户的同名 key 互不影响；没有 key 或非 POST 请求照常执行。
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, otherToken, "k1", `{"name":"d1"}`).Code)
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, token, "", `{"name":"d1"}`).Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPut, token, "k1", `{"name":"d1"}`).Code)
+	assert.Equal(t, 4, calls)
+
+	// 5xx 不保存，同一 key 可以重试。
+	failNext = true
+	assert.Equal(t, http.StatusInternalServerError, do(http.MethodPost, token, "k2", `{}`).Code)
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, token, "k2", `{}`).Code)
+	assert.Equal(t, 6, calls)
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, token, strings.Repeat("k", maxIdempotencyKeyLen+1), `{}`).Code)
+	assert.Equal(t, 6, calls)
+}
+
+func TestIdempotency_MultipartRetryWithNewBoundary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtUtil := utils.NewJWTUtil("secret", time.Hour, "issuer")
+	token, err := jwtUtil.GenerateToken(123, "admin")
+	require.NoError(t, err)
+
+	calls := 0
+	r := gin.New()
+	r.Use(JWTAuth(jwtUtil), Idempotency(newIdempotencyStore(t)))
+	r.POST("/imports/requests", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"created": calls})
+	})
+
+	// 每次上传都由 multipart.Writer 生成新的随机分隔符，与客户端重试时一致。
+	upload := func(csv string) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		require.NoError(t, mw.WriteField("dry_run", "false"))
+		fw, err := mw.CreateFormFile("file", "requests.csv")
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(csv))
+		require.NoError(t, err)
+		require.NoError(t, mw.Close())
+		req := httptest.NewRequest(http.MethodPost, "/imports/requests", &buf)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set(IdempotencyKeyHeader, "import-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	csv := "name,phone,flight_no,arrival_date,terminal\nLi,13800000000,UA881,2026-08-20,T5\n"
+	first := upload(csv)
+	require.Equal(t, http.StatusOK, first.Code)
+	retry := upload(csv)
+	assert.Equal(t, http.StatusOK, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayHeader))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// 文件内容不同仍视为复用 key。
+	assert.Equal(t, http.StatusConflict, upload(csv+"Wang,13900000000,AA100,2026-08-20,T3\n").Code)
+	assert.Equal(t, 1, calls)
+}
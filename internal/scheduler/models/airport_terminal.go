@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// AirportTerminal 接机航站楼配置，BufferMinutes 为航班落地到接机的缓冲（含入境/行李时间）。
+type AirportTerminal struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	AirportCode   string    `gorm:"column:airport_code;type:varchar(8);not null;uniqueIndex:uk_airport_terminals_code,priority:1" json:"airport_code"`
+	Terminal      string    `gorm:"type:varchar(10);not null;uniqueIndex:uk_airport_terminals_code,priority:2" json:"terminal"`
+	International bool      `gorm:"not null;default:false" json:"international"`
+	BufferMinutes int       `gorm:"column:buffer_minutes;not null;default:45" json:"buffer_minutes"`
+	MeetingPoint  string    `gorm:"column:meeting_point;type:varchar(255);not null;default:''" json:"meeting_point"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (AirportTerminal) TableName() string {
+	return "airport_terminals"
+}
+
+// DefaultAirportTerminals 空表初始化数据，与早期硬编码的 ORD 缓冲保持一致。
+func DefaultAirportTerminals() []AirportTerminal {
+	return []AirportTerminal{
+		{AirportCode: "ORD", Terminal: "T1", BufferMinutes: 45},
+		{AirportCode: "ORD", Terminal: "T2", BufferMinutes: 45},
+		{AirportCode: "ORD", Terminal: "T3", BufferMinutes: 45},
+		{AirportCode: "ORD", Terminal: "T5", International: true, BufferMinutes: 90},
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog 管理员/志愿者写操作审计记录，与业务变更在同一事务内写入。
+// ActorID 为 0 表示系统任务。
+type AuditLog struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	ActorID    uint            `gorm:"column:actor_id;not null;index:idx_audit_logs_actor_id" json:"actor_id"`
+	ActorRole  string          `gorm:"column:actor_role;type:varchar(16);not null;default:''" json:"actor_role"`
+	Action     string          `gorm:"type:varchar(64);not null;index:idx_audit_logs_action" json:"action"`
+	EntityType string          `gorm:"column:entity_type;type:varchar(32);not null;index:idx_audit_logs_entity,priority:1" json:"entity_type"`
+	EntityID   uint            `gorm:"column:entity_id;not null;index:idx_audit_logs_entity,priority:2" json:"entity_id"`
+	Before     json.RawMessage `gorm:"column:before_data;type:json" json:"before"`
+	After      json.RawMessage `gorm:"column:after_data;type:json" json:"after"`
+	RequestID  string          `gorm:"column:request_id;type:varchar(64);not null;default:''" json:"request_id"`
+	CreatedAt  time.Time       `gorm:"index:idx_audit_logs_created_at" json:"created_at"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// CalendarFeed 用户的日历订阅；Nonce 参与签名，重新生成或删除即吊销旧链接。
+type CalendarFeed struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"column:user_id;not null;uniqueIndex:uk_calendar_feeds_user_id" json:"user_id"`
+	Nonce     string    `gorm:"type:varchar(32);not null" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (CalendarFeed) TableName() string {
+	return "calendar_feeds"
+}
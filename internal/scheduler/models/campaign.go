@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Campaign 一期接机活动（如某学期开学），需求、班次与司机名单按活动隔离。
+// 同一时间至多一个 Active 活动，管理端与学生端查询默认限定在该活动内。
+type Campaign struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Name      string         `gorm:"type:varchar(64);not null" json:"name"`
+	StartDate time.Time      `gorm:"column:start_date;type:date;not null" json:"start_date"`
+	EndDate   time.Time      `gorm:"column:end_date;type:date;not null" json:"end_date"`
+	Status    CampaignStatus `gorm:"type:enum('open','closed');not null;default:'open'" json:"status"`
+	Active    bool           `gorm:"not null;default:false;index:idx_campaigns_active" json:"active"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+func (Campaign) TableName() string {
+	return "campaigns"
+}
@@ -0,0 +1,25 @@
+package models
+
+// CapacityMetric 单项容量占用，Remaining 不小于 0。
+type CapacityMetric struct {
+	Used      int `json:"used"`
+	Limit     int `json:"limit"`
+	Remaining int `json:"remaining"`
+}
+
+// ShiftCapacity 班次座位与行李占用汇总（非数据表）。
+type ShiftCapacity struct {
+	ShiftID     uint           `json:"shift_id"`
+	Seats       CapacityMetric `json:"seats"`
+	CheckedBags CapacityMetric `json:"checked_bags"`
+	CarryOnBags CapacityMetric `json:"carry_on_bags"`
+	Overloaded  bool           `json:"overloaded"`
+}
+
+func NewCapacityMetric(used, limit int) CapacityMetric {
+	remaining := limit - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return CapacityMetric{Used: used, Limit: limit, Remaining: remaining}
+}
@@ -1,13 +1,21 @@
 package models
 
-// Driver 运力池/车辆表
+import "time"
+
+// Driver 运力池/车辆表，UserID 关联司机本人的登录账号。
+// ArchivedAt 非空表示已归档：不再出现在司机列表与自动排班中，历史班次保留。
+// Version 每次写入加一，对外作为 ETag。
 type Driver struct {
-	ID         uint   `gorm:"primaryKey" json:"id"`
-	Name       string `gorm:"type:varchar(64);not null" json:"name"`
-	CarModel   string `gorm:"column:car_model;type:varchar(64);not null" json:"car_model"`
-	MaxSeats   int    `gorm:"column:max_seats;not null" json:"max_seats"`
-	MaxChecked int    `gorm:"column:max_checked;not null" json:"max_checked"`
-	MaxCarryOn int    `gorm:"column:max_carry_on;not null" json:"max_carry_on"`
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Name       string     `gorm:"type:varchar(64);not null" json:"name"`
+	CarModel   string     `gorm:"column:car_model;type:varchar(64);not null" json:"car_model"`
+	MaxSeats   int        `gorm:"column:max_seats;not null" json:"max_seats"`
+	MaxChecked int        `gorm:"column:max_checked;not null" json:"max_checked"`
+	MaxCarryOn int        `gorm:"column:max_carry_on;not null" json:"max_carry_on"`
+	CampaignID *uint      `gorm:"column:campaign_id;index:idx_drivers_campaign_id" json:"campaign_id,omitempty"`
+	UserID     *uint      `gorm:"column:user_id;uniqueIndex:uk_drivers_user_id" json:"user_id,omitempty"`
+	ArchivedAt *time.Time `gorm:"column:archived_at;type:datetime" json:"archived_at,omitempty"`
+	Version    uint       `gorm:"column:version;not null;default:0" json:"version"`
 }
 
 func (Driver) TableName() string {
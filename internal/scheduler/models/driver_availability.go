@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// DriverAvailability 司机可出车时间窗，[StartTime, EndTime)。
+// 司机未登记任何时间窗时视为不限时间。
+type DriverAvailability struct {
+	ID        uint               `gorm:"primaryKey" json:"id"`
+	DriverID  uint               `gorm:"column:driver_id;not null;index:idx_driver_availabilities_driver_id" json:"driver_id"`
+	StartTime time.Time          `gorm:"column:start_time;type:datetime;not null" json:"start_time"`
+	EndTime   time.Time          `gorm:"column:end_time;type:datetime;not null" json:"end_time"`
+	Source    AvailabilitySource `gorm:"type:enum('self','admin');not null;default:'admin'" json:"source"`
+	Note      string             `gorm:"type:varchar(255);not null;default:''" json:"note"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+func (DriverAvailability) TableName() string {
+	return "driver_availabilities"
+}
+
+// Covers 时间窗是否完整覆盖 [from, to)。
+func (a DriverAvailability) Covers(from, to time.Time) bool {
+	return !a.StartTime.After(from) && !a.EndTime.Before(to)
+}
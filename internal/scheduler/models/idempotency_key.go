@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// IdempotencyKey 按 (用户, Idempotency-Key, 路由) 保存 POST 请求的首次响应，重试时原样重放。
+// StatusCode 为 0 表示首个请求仍在处理；Fingerprint 为查询串与请求体的摘要，用于识别 key 被挪用。
+type IdempotencyKey struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	UserID      uint            `gorm:"column:user_id;not null;uniqueIndex:uk_idempotency_keys,priority:1" json:"user_id"`
+	Key         string          `gorm:"column:idempotency_key;type:varchar(128);not null;uniqueIndex:uk_idempotency_keys,priority:2" json:"key"`
+	Route       string          `gorm:"type:varchar(191);not null;uniqueIndex:uk_idempotency_keys,priority:3" json:"route"`
+	Fingerprint string          `gorm:"type:char(64);not null" json:"-"`
+	StatusCode  int             `gorm:"column:status_code;not null;default:0" json:"status_code"`
+	Headers     json.RawMessage `gorm:"type:json" json:"-"`
+	Body        []byte          `gorm:"type:mediumblob" json:"-"`
+	ExpiresAt   time.Time       `gorm:"column:expires_at;type:datetime;not null;index:idx_idempotency_keys_expires_at" json:"expires_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
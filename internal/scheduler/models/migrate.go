@@ -5,8 +5,10 @@ import "gorm.io/gorm"
 // AutoMigrate 自动迁移调度域表。
 func AutoMigrate(db *gorm.DB) error {
 	if err := db.AutoMigrate(
+		&Campaign{},
 		&User{},
 		&Driver{},
+		&DriverAvailability{},
 		&Request{},
 		&Shift{},
 	); err != nil {
@@ -20,8 +22,27 @@ func AutoMigrate(db *gorm.DB) error {
 		return err
 	}
 
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
 		&ShiftRequest{},
 		&ShiftStaff{},
-	)
+		&StaffAvailability{},
+		&ShiftConflict{},
+		&AuditLog{},
+		&Notification{},
+		&AirportTerminal{},
+		&CalendarFeed{},
+		&IdempotencyKey{},
+	); err != nil {
+		return err
+	}
+
+	var terminals int64
+	if err := db.Model(&AirportTerminal{}).Count(&terminals).Error; err != nil {
+		return err
+	}
+	if terminals == 0 {
+		defaults := DefaultAirportTerminals()
+		return db.Create(&defaults).Error
+	}
+	return nil
 }
@@ -14,6 +14,15 @@ func TestTableNames(t *testing.T) {
 		{"shift", (Shift{}).TableName(), "shifts"},
 		{"shift_request", (ShiftRequest{}).TableName(), "shift_requests"},
 		{"shift_staff", (ShiftStaff{}).TableName(), "shift_staffs"},
+		{"shift_conflict", (ShiftConflict{}).TableName(), "shift_conflicts"},
+		{"audit_log", (AuditLog{}).TableName(), "audit_logs"},
+		{"notification", (Notification{}).TableName(), "notifications"},
+		{"airport_terminal", (AirportTerminal{}).TableName(), "airport_terminals"},
+		{"campaign", (Campaign{}).TableName(), "campaigns"},
+		{"driver_availability", (DriverAvailability{}).TableName(), "driver_availabilities"},
+		{"staff_availability", (StaffAvailability{}).TableName(), "staff_availabilities"},
+		{"calendar_feed", (CalendarFeed{}).TableName(), "calendar_feeds"},
+		{"idempotency_key", (IdempotencyKey{}).TableName(), "idempotency_keys"},
 	}
 	for _, tc := range cases {
 		if tc.got != tc.want {
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Notification 学生订阅消息发送记录，每次发送尝试一行。
+type Notification struct {
+	ID         uint               `gorm:"primaryKey" json:"id"`
+	UserID     uint               `gorm:"column:user_id;not null;index:idx_notifications_user_id" json:"user_id"`
+	RequestID  uint               `gorm:"column:request_id;not null;index:idx_notifications_request_id" json:"request_id"`
+	ShiftID    uint               `gorm:"column:shift_id;not null;index:idx_notifications_shift_id" json:"shift_id"`
+	Event      string             `gorm:"type:varchar(32);not null" json:"event"`
+	TemplateID string             `gorm:"column:template_id;type:varchar(64);not null;default:''" json:"template_id"`
+	Status     NotificationStatus `gorm:"type:enum('sent','failed','skipped');not null" json:"status"`
+	ErrCode    int                `gorm:"column:err_code;not null;default:0" json:"err_code"`
+	ErrMsg     string             `gorm:"column:err_msg;type:varchar(255);not null;default:''" json:"err_msg"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
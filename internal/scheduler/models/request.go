@@ -3,24 +3,31 @@ package models
 import "time"
 
 // Request 学生接机需求表
+// Version 每次写入加一，对外作为 ETag。
 type Request struct {
 	ID             uint          `gorm:"primaryKey" json:"id"`
 	UserID         uint          `gorm:"column:user_id;not null;index:idx_requests_user_id" json:"user_id"`
 	FlightNo       string        `gorm:"column:flight_no;type:varchar(20);not null;index:idx_requests_flight_no" json:"flight_no"`
 	ArrivalDate    time.Time     `gorm:"column:arrival_date;type:date;not null;index:idx_requests_arrival_date" json:"arrival_date"`
+	Airport        string        `gorm:"type:varchar(8);not null;default:''" json:"airport"`
 	Terminal       string        `gorm:"type:varchar(10);not null" json:"terminal"`
+	Passengers     int           `gorm:"not null;default:1" json:"passengers"`
 	CheckedBags    int           `gorm:"column:checked_bags;not null;default:0" json:"checked_bags"`
 	CarryOnBags    int           `gorm:"column:carry_on_bags;not null;default:0" json:"carry_on_bags"`
-	Status         RequestStatus `gorm:"type:enum('pending','assigned','published');not null;default:'pending';index:idx_requests_status" json:"status"`
+	Status         RequestStatus `gorm:"type:enum('pending','assigned','published','canceled');not null;default:'pending';index:idx_requests_status" json:"status"`
 	ArrivalTimeAPI *time.Time    `gorm:"column:arrival_time_api;type:datetime" json:"arrival_time_api,omitempty"`
 	PickupBuffer   int           `gorm:"column:pickup_buffer;not null;default:45" json:"pickup_buffer"`
 	CalcPickupTime *time.Time    `gorm:"column:calc_pickup_time;type:datetime" json:"calc_pickup_time,omitempty"`
+	CanceledAt     *time.Time    `gorm:"column:canceled_at;type:datetime" json:"canceled_at,omitempty"`
+	CampaignID     *uint         `gorm:"column:campaign_id;index:idx_requests_campaign_id" json:"campaign_id,omitempty"`
+	Version        uint          `gorm:"column:version;not null;default:0" json:"version"`
 	CreatedAt      time.Time     `json:"created_at"`
 	UpdatedAt      time.Time     `json:"updated_at"`
 
-	User   *User   `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"user,omitempty"`
-	Shifts []Shift `gorm:"many2many:shift_requests;joinForeignKey:RequestID;joinReferences:ShiftID" json:"-"`
-	Shift  *Shift  `gorm:"-" json:"shift,omitempty"`
+	User     *User         `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"user,omitempty"`
+	Shifts   []Shift       `gorm:"many2many:shift_requests;joinForeignKey:RequestID;joinReferences:ShiftID" json:"-"`
+	Shift    *Shift        `gorm:"-" json:"shift,omitempty"`
+	Boarding *ShiftRequest `gorm:"-" json:"boarding,omitempty"`
 }
 
 func (Request) TableName() string {
@@ -3,16 +3,32 @@ package models
 import "time"
 
 // Shift 调度班次表
+// 司机在 [DepartureTime, DepartureTime+EstimatedMinutes) 内视为占用；Warning 为创建/修改时的非阻断提示。
+// DriverStatus 记录司机接受/拒绝，改派司机或改期后重置为 pending。
+// ScheduleSeq 改派司机、改期或修改预计用时后加一，作为日历订阅中事件的 SEQUENCE。
+// Version 每次写入加一，对外作为 ETag。
 type Shift struct {
-	ID            uint        `gorm:"primaryKey" json:"id"`
-	DriverID      uint        `gorm:"column:driver_id;not null;index:idx_shifts_driver_id" json:"driver_id"`
-	DepartureTime time.Time   `gorm:"column:departure_time;type:datetime;not null;index:idx_shifts_departure_time" json:"departure_time"`
-	Status        ShiftStatus `gorm:"type:enum('draft','published');not null;default:'draft';index:idx_shifts_status" json:"status"`
-	CreatedAt     time.Time   `json:"created_at"`
+	ID                uint              `gorm:"primaryKey" json:"id"`
+	DriverID          uint              `gorm:"column:driver_id;not null;index:idx_shifts_driver_id" json:"driver_id"`
+	DepartureTime     time.Time         `gorm:"column:departure_time;type:datetime;not null;index:idx_shifts_departure_time" json:"departure_time"`
+	EstimatedMinutes  int               `gorm:"column:estimated_minutes;not null;default:120" json:"estimated_minutes"`
+	Status            ShiftStatus       `gorm:"type:enum('draft','published','in_progress','completed','canceled');not null;default:'draft';index:idx_shifts_status" json:"status"`
+	StartedAt         *time.Time        `gorm:"column:started_at;type:datetime" json:"started_at,omitempty"`
+	CompletedAt       *time.Time        `gorm:"column:completed_at;type:datetime" json:"completed_at,omitempty"`
+	CapacityPolicy    *CapacityPolicy   `gorm:"column:capacity_policy;type:enum('warn','reject','allow_with_reason')" json:"capacity_policy,omitempty"`
+	DriverStatus      ShiftDriverStatus `gorm:"column:driver_status;type:enum('pending','accepted','declined');not null;default:'pending'" json:"driver_status"`
+	DriverRespondedAt *time.Time        `gorm:"column:driver_responded_at;type:datetime" json:"driver_responded_at,omitempty"`
+	DriverNote        string            `gorm:"column:driver_note;type:varchar(255);not null;default:''" json:"driver_note,omitempty"`
+	ScheduleSeq       int               `gorm:"column:schedule_seq;not null;default:0" json:"schedule_seq"`
+	CampaignID        *uint             `gorm:"column:campaign_id;index:idx_shifts_campaign_id" json:"campaign_id,omitempty"`
+	Version           uint              `gorm:"column:version;not null;default:0" json:"version"`
+	CreatedAt         time.Time         `json:"created_at"`
 
-	Driver   *Driver   `gorm:"foreignKey:DriverID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"driver,omitempty"`
-	Requests []Request `gorm:"many2many:shift_requests;joinForeignKey:ShiftID;joinReferences:RequestID" json:"requests,omitempty"`
-	Staffs   []User    `gorm:"many2many:shift_staffs;joinForeignKey:ShiftID;joinReferences:StaffID" json:"staffs,omitempty"`
+	Driver   *Driver        `gorm:"foreignKey:DriverID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"driver,omitempty"`
+	Requests []Request      `gorm:"many2many:shift_requests;joinForeignKey:ShiftID;joinReferences:RequestID" json:"requests,omitempty"`
+	Staffs   []User         `gorm:"many2many:shift_staffs;joinForeignKey:ShiftID;joinReferences:StaffID" json:"staffs,omitempty"`
+	Capacity *ShiftCapacity `gorm:"-" json:"capacity,omitempty"`
+	Warning  string         `gorm:"-" json:"warning,omitempty"`
 }
 
 func (Shift) TableName() string {
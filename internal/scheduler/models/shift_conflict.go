@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// ShiftConflict 航班变动后班次与已绑定需求的时间冲突记录。
+// DeltaMinutes = CalcPickupTime - DepartureTime，正数表示学生晚于发车。
+type ShiftConflict struct {
+	ID             uint              `gorm:"primaryKey" json:"id"`
+	ShiftID        uint              `gorm:"column:shift_id;not null;index:idx_shift_conflicts_shift_id" json:"shift_id"`
+	RequestID      uint              `gorm:"column:request_id;not null;index:idx_shift_conflicts_request_id" json:"request_id"`
+	Kind           ShiftConflictKind `gorm:"type:enum('late_pickup','early_pickup');not null" json:"kind"`
+	CalcPickupTime time.Time         `gorm:"column:calc_pickup_time;type:datetime;not null" json:"calc_pickup_time"`
+	DepartureTime  time.Time         `gorm:"column:departure_time;type:datetime;not null" json:"departure_time"`
+	DeltaMinutes   int               `gorm:"column:delta_minutes;not null" json:"delta_minutes"`
+	DetectedAt     time.Time         `gorm:"column:detected_at;type:datetime;not null" json:"detected_at"`
+	ResolvedAt     *time.Time        `gorm:"column:resolved_at;type:datetime;index:idx_shift_conflicts_resolved_at" json:"resolved_at,omitempty"`
+
+	Shift   *Shift   `gorm:"foreignKey:ShiftID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"shift,omitempty"`
+	Request *Request `gorm:"foreignKey:RequestID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"request,omitempty"`
+}
+
+func (ShiftConflict) TableName() string {
+	return "shift_conflicts"
+}
@@ -1,10 +1,17 @@
 package models
 
+import "time"
+
 // ShiftRequest 班次与需求中间表。
 // 约束：request_id 全局唯一，确保一个需求只能在一个班次中。
+// 接机当天由志愿者在此记录登车状态。
 type ShiftRequest struct {
-	ShiftID   uint `gorm:"column:shift_id;primaryKey;not null" json:"shift_id"`
-	RequestID uint `gorm:"column:request_id;primaryKey;not null;uniqueIndex:uk_shift_requests_request_id" json:"request_id"`
+	ShiftID        uint           `gorm:"column:shift_id;primaryKey;not null" json:"shift_id"`
+	RequestID      uint           `gorm:"column:request_id;primaryKey;not null;uniqueIndex:uk_shift_requests_request_id" json:"request_id"`
+	BoardingStatus BoardingStatus `gorm:"column:boarding_status;type:enum('waiting','boarded','no_show');not null;default:'waiting';index:idx_shift_requests_boarding_status" json:"boarding_status"`
+	CheckedInAt    *time.Time     `gorm:"column:checked_in_at;type:datetime" json:"checked_in_at,omitempty"`
+	CheckedInBy    *uint          `gorm:"column:checked_in_by" json:"checked_in_by,omitempty"`
+	OverloadReason *string        `gorm:"column:overload_reason;type:varchar(255)" json:"overload_reason,omitempty"`
 
 	Shift   *Shift   `gorm:"foreignKey:ShiftID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
 	Request *Request `gorm:"foreignKey:RequestID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
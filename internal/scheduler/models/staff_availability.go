@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// StaffAvailability 志愿者可值班时间窗，[StartTime, EndTime)。
+// 未登记任何时间窗时视为不限时间。
+type StaffAvailability struct {
+	ID        uint               `gorm:"primaryKey" json:"id"`
+	StaffID   uint               `gorm:"column:staff_id;not null;index:idx_staff_availabilities_staff_id" json:"staff_id"`
+	StartTime time.Time          `gorm:"column:start_time;type:datetime;not null" json:"start_time"`
+	EndTime   time.Time          `gorm:"column:end_time;type:datetime;not null" json:"end_time"`
+	Source    AvailabilitySource `gorm:"type:enum('self','admin');not null;default:'admin'" json:"source"`
+	Note      string             `gorm:"type:varchar(255);not null;default:''" json:"note"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+func (StaffAvailability) TableName() string {
+	return "staff_availabilities"
+}
+
+// Covers 时间窗是否完整覆盖 [from, to)。
+func (a StaffAvailability) Covers(from, to time.Time) bool {
+	return !a.StartTime.After(from) && !a.EndTime.Before(to)
+}
@@ -8,6 +8,7 @@ const (
 	UserRoleStudent UserRole = "student"
 	UserRoleStaff   UserRole = "staff"
 	UserRoleAdmin   UserRole = "admin"
+	UserRoleDriver  UserRole = "driver"
 )
 
 type RequestStatus string
@@ -16,13 +17,75 @@ const (
 	RequestStatusPending   RequestStatus = "pending"
 	RequestStatusAssigned  RequestStatus = "assigned"
 	RequestStatusPublished RequestStatus = "published"
+	RequestStatusCanceled  RequestStatus = "canceled"
 )
 
 type ShiftStatus string
 
 const (
-	ShiftStatusDraft     ShiftStatus = "draft"
-	ShiftStatusPublished ShiftStatus = "published"
+	ShiftStatusDraft      ShiftStatus = "draft"
+	ShiftStatusPublished  ShiftStatus = "published"
+	ShiftStatusInProgress ShiftStatus = "in_progress"
+	ShiftStatusCompleted  ShiftStatus = "completed"
+	ShiftStatusCanceled   ShiftStatus = "canceled"
+)
+
+// ShiftDriverStatus 司机对已发布班次的确认状态。
+type ShiftDriverStatus string
+
+const (
+	ShiftDriverStatusPending  ShiftDriverStatus = "pending"
+	ShiftDriverStatusAccepted ShiftDriverStatus = "accepted"
+	ShiftDriverStatusDeclined ShiftDriverStatus = "declined"
+)
+
+type BoardingStatus string
+
+const (
+	BoardingStatusWaiting BoardingStatus = "waiting"
+	BoardingStatusBoarded BoardingStatus = "boarded"
+	BoardingStatusNoShow  BoardingStatus = "no_show"
+)
+
+// CapacityPolicy 班次超载处理策略。
+type CapacityPolicy string
+
+const (
+	CapacityPolicyWarn            CapacityPolicy = "warn"
+	CapacityPolicyReject          CapacityPolicy = "reject"
+	CapacityPolicyAllowWithReason CapacityPolicy = "allow_with_reason"
+)
+
+// CampaignStatus 活动是否仍接受学生提交需求。
+type CampaignStatus string
+
+const (
+	CampaignStatusOpen   CampaignStatus = "open"
+	CampaignStatusClosed CampaignStatus = "closed"
+)
+
+// AvailabilitySource 司机/志愿者时间窗由本人自报还是管理员录入。
+type AvailabilitySource string
+
+const (
+	AvailabilitySourceSelf  AvailabilitySource = "self"
+	AvailabilitySourceAdmin AvailabilitySource = "admin"
+)
+
+// NotificationStatus 订阅消息发送结果。
+type NotificationStatus string
+
+const (
+	NotificationStatusSent    NotificationStatus = "sent"
+	NotificationStatusFailed  NotificationStatus = "failed"
+	NotificationStatusSkipped NotificationStatus = "skipped"
+)
+
+type ShiftConflictKind string
+
+const (
+	ShiftConflictLatePickup  ShiftConflictKind = "late_pickup"
+	ShiftConflictEarlyPickup ShiftConflictKind = "early_pickup"
 )
 
 // DateOnly 用于 GORM date 字段。
@@ -3,6 +3,7 @@ package scheduler
 import (
 	"pickup/internal/scheduler/controllers"
 	"pickup/internal/scheduler/cron"
+	"pickup/internal/scheduler/events"
 	"pickup/internal/scheduler/service"
 
 	"go.uber.org/fx"
@@ -12,15 +13,27 @@ import (
 func Provide() fx.Option {
 	return fx.Options(
 		fx.Provide(
+			events.NewBroker,
+			service.NewSchedulerLocation,
 			service.NewShiftAssignmentService,
+			service.NewNotificationService,
 			service.NewAuthService,
 			service.NewStudentService,
 			service.NewAdminService,
+			service.NewShiftConflictService,
+			service.NewStaffService,
+			service.NewDriverService,
+			service.NewCalendarService,
+			service.NewIdempotencyService,
 			controllers.NewAuthController,
 			controllers.NewStudentController,
 			controllers.NewAdminController,
+			controllers.NewStaffController,
+			controllers.NewDriverController,
+			controllers.NewCalendarController,
+			controllers.NewStreamController,
 			cron.NewSyncFlightService,
 		),
-		fx.Invoke(cron.RegisterCron),
+		fx.Invoke(cron.RegisterCron, events.RegisterBroker),
 	)
 }
@@ -3,45 +3,113 @@ package routes
 import (
 	"pickup/internal/scheduler/controllers"
 	"pickup/internal/scheduler/middlewares"
+	"pickup/internal/scheduler/service"
 	"pickup/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(r *gin.Engine, authCtl *controllers.AuthController, studentCtl *controllers.StudentController, adminCtl *controllers.AdminController, jwtUtil *utils.JWTUtil) {
+func RegisterRoutes(r *gin.Engine, authCtl *controllers.AuthController, studentCtl *controllers.StudentController, adminCtl *controllers.AdminController, staffCtl *controllers.StaffController, driverCtl *controllers.DriverController, calendarCtl *controllers.CalendarController, streamCtl *controllers.StreamController, idempotency *service.IdempotencyService, jwtUtil *utils.JWTUtil) {
 	api := r.Group("/api/v1")
+	api.Use(middlewares.RequestID())
+	// 登录之后的 POST 都可带 Idempotency-Key；幂等记录按用户区分，所以挂在 JWTAuth 之后。
+	idem := middlewares.Idempotency(idempotency)
 
 	auth := api.Group("/auth")
 	auth.POST("/login", authCtl.Login)
 
 	authProtected := auth.Group("")
-	authProtected.Use(middlewares.JWTAuth(jwtUtil))
+	authProtected.Use(middlewares.JWTAuth(jwtUtil), idem)
 	authProtected.POST("/bind-phone", authCtl.BindPhone)
 	authProtected.GET("/me", authCtl.Me)
 
+	calendar := api.Group("/calendar")
+	calendar.GET("/:token", calendarCtl.Feed)
+	calendar.POST("/feed", middlewares.JWTAuth(jwtUtil), idem, calendarCtl.IssueFeed)
+	calendar.DELETE("/feed", middlewares.JWTAuth(jwtUtil), calendarCtl.RevokeFeed)
+
 	student := api.Group("/student")
-	student.Use(middlewares.JWTAuth(jwtUtil), middlewares.RequireRoles("student"))
+	student.Use(middlewares.JWTAuth(jwtUtil), middlewares.RequireRoles("student"), idem)
 	student.POST("/requests", studentCtl.CreateRequest)
 	student.GET("/requests/my", studentCtl.MyRequests)
 	student.PUT("/requests/:id", studentCtl.UpdateRequest)
+	student.POST("/requests/:id/cancel", studentCtl.CancelRequest)
 
 	admin := api.Group("/admin")
-	admin.Use(middlewares.JWTAuth(jwtUtil), middlewares.RequireRoles("staff"))
+	admin.Use(middlewares.JWTAuth(jwtUtil), middlewares.RequireRoles("admin"), idem)
 	admin.GET("/drivers", adminCtl.ListDrivers)
 	admin.POST("/drivers", adminCtl.CreateDriver)
 	admin.PUT("/drivers/:id", adminCtl.UpdateDriver)
+	admin.GET("/drivers/available", adminCtl.AvailableDrivers)
+	admin.GET("/drivers/:id/availability", adminCtl.ListDriverAvailability)
+	admin.POST("/drivers/:id/availability", adminCtl.CreateDriverAvailability)
+	admin.DELETE("/drivers/:id/availability/:availabilityId", adminCtl.DeleteDriverAvailability)
+	admin.POST("/drivers/:id/link-user", adminCtl.LinkDriverUser)
+	admin.POST("/drivers/:id/unlink-user", adminCtl.UnlinkDriverUser)
+	admin.POST("/drivers/:id/archive", adminCtl.ArchiveDriver)
+	admin.POST("/drivers/:id/unarchive", adminCtl.UnarchiveDriver)
+	admin.GET("/campaigns", adminCtl.ListCampaigns)
+	admin.GET("/campaigns/active", adminCtl.ActiveCampaign)
+	admin.POST("/campaigns", adminCtl.CreateCampaign)
+	admin.PUT("/campaigns/:id", adminCtl.UpdateCampaign)
+	admin.POST("/campaigns/:id/activate", adminCtl.ActivateCampaign)
+	admin.GET("/terminals", adminCtl.ListTerminals)
+	admin.POST("/terminals", adminCtl.CreateTerminal)
+	admin.PUT("/terminals/:id", adminCtl.UpdateTerminal)
+	admin.DELETE("/terminals/:id", adminCtl.DeleteTerminal)
 	admin.GET("/shifts/dashboard", adminCtl.Dashboard)
+	admin.GET("/stream", streamCtl.Stream)
+	admin.GET("/shifts/conflicts", adminCtl.ShiftConflicts)
 	admin.GET("/requests/pending", adminCtl.PendingRequests)
-	admin.GET("/users", middlewares.RequireRoles("admin"), adminCtl.ListUsers)
-	admin.POST("/users/:id/set-staff", middlewares.RequireRoles("admin"), adminCtl.SetStaff)
-	admin.POST("/users/:id/unset-staff", middlewares.RequireRoles("admin"), adminCtl.UnsetStaff)
+	admin.PUT("/requests/:id", adminCtl.UpdateRequest)
+	admin.GET("/users", adminCtl.ListUsers)
+	admin.GET("/staff/:id/schedule", adminCtl.StaffSchedule)
+	admin.GET("/staff/:id/availability", adminCtl.ListStaffAvailability)
+	admin.POST("/staff/:id/availability", adminCtl.CreateStaffAvailability)
+	admin.DELETE("/staff/:id/availability/:availabilityId", adminCtl.DeleteStaffAvailability)
+	admin.POST("/users/:id/set-staff", adminCtl.SetStaff)
+	admin.POST("/users/:id/unset-staff", adminCtl.UnsetStaff)
 	admin.POST("/shifts", adminCtl.CreateShift)
 	admin.PUT("/shifts/:id", adminCtl.UpdateShift)
+	admin.GET("/shifts/:id/capacity", adminCtl.ShiftCapacity)
+	admin.GET("/shifts/:id/notifications", adminCtl.ShiftNotifications)
 	admin.POST("/shifts/:id/assign-student", adminCtl.AssignStudent)
 	admin.POST("/shifts/:id/remove-student", adminCtl.RemoveStudent)
-	admin.POST("/shifts/:id/assign-staff", middlewares.RequireRoles("admin"), adminCtl.AssignStaff)
+	admin.POST("/shifts/:id/assign-staff", adminCtl.AssignStaff)
 	admin.POST("/shifts/:id/remove-staff", adminCtl.RemoveStaff)
 	admin.POST("/shifts/:id/publish", adminCtl.PublishShift)
+	admin.POST("/shifts/:id/start", adminCtl.StartShift)
+	admin.POST("/shifts/:id/complete", adminCtl.CompleteShift)
+	admin.POST("/shifts/:id/cancel", adminCtl.CancelShift)
+	admin.DELETE("/shifts/:id", adminCtl.DeleteShift)
+	admin.POST("/plans/preview", adminCtl.PreviewPlan)
+	admin.POST("/plans/commit", adminCtl.CommitPlan)
+	admin.POST("/imports/requests", adminCtl.ImportRequests)
+	admin.GET("/reports/no-shows", adminCtl.NoShowReport)
+	admin.GET("/exports/manifests", adminCtl.ExportManifests)
+	admin.GET("/audit", adminCtl.AuditLogs)
+
+	staff := api.Group("/staff")
+	staff.Use(middlewares.JWTAuth(jwtUtil), middlewares.RequireRoles("staff"), idem)
+	staff.GET("/shifts/my", staffCtl.MyShifts)
+	staff.GET("/shifts/:id", staffCtl.MyShift)
+	staff.POST("/shifts/:id/start", staffCtl.StartShift)
+	staff.POST("/shifts/:id/complete", staffCtl.CompleteShift)
+	staff.POST("/shifts/:id/check-in", staffCtl.CheckIn)
+	staff.GET("/exports/manifests", staffCtl.ExportManifests)
+	staff.GET("/availability", staffCtl.MyAvailability)
+	staff.POST("/availability", staffCtl.CreateMyAvailability)
+	staff.DELETE("/availability/:availabilityId", staffCtl.DeleteMyAvailability)
+
+	driver := api.Group("/driver")
+	driver.Use(middlewares.JWTAuth(jwtUtil), middlewares.RequireRoles("driver"), idem)
+	driver.GET("/shifts", driverCtl.MyShifts)
+	driver.GET("/shifts/:id", driverCtl.MyShift)
+	driver.POST("/shifts/:id/accept", driverCtl.AcceptShift)
+	driver.POST("/shifts/:id/decline", driverCtl.DeclineShift)
+	driver.GET("/availability", driverCtl.MyAvailability)
+	driver.POST("/availability", driverCtl.CreateMyAvailability)
+	driver.DELETE("/availability/:availabilityId", driverCtl.DeleteMyAvailability)
 
 	api.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
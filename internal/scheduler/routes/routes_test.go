@@ -7,17 +7,19 @@ import (
 	"time"
 
 	"pickup/internal/scheduler/controllers"
+	"pickup/internal/scheduler/service"
 	"pickup/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRegisterRoutes_HealthAndProtected(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	jwtUtil := utils.NewJWTUtil("secret", time.Hour, "issuer")
-	RegisterRoutes(r, &controllers.AuthController{}, &controllers.StudentController{}, &controllers.AdminController{}, jwtUtil)
+	RegisterRoutes(r, &controllers.AuthController{}, &controllers.StudentController{}, &controllers.AdminController{}, &controllers.StaffController{}, &controllers.DriverController{}, &controllers.CalendarController{}, &controllers.StreamController{}, &service.IdempotencyService{}, jwtUtil)
 
 	w1 := httptest.NewRecorder()
 	req1 := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
@@ -29,3 +31,33 @@ func TestRegisterRoutes_HealthAndProtected(t *testing.T) {
 	r.ServeHTTP(w2, req2)
 	assert.Equal(t, http.StatusUnauthorized, w2.Code)
 }
+
+func TestRegisterRoutes_RoleGroups(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	jwtUtil := utils.NewJWTUtil("secret", time.Hour, "issuer")
+	RegisterRoutes(r, &controllers.AuthController{}, &controllers.StudentController{}, &controllers.AdminController{}, &controllers.StaffController{}, &controllers.DriverController{}, &controllers.CalendarController{}, &controllers.StreamController{}, &service.IdempotencyService{}, jwtUtil)
+
+	cases := []struct {
+		role   string
+		method string
+		path   string
+	}{
+		{"staff", http.MethodGet, "/api/v1/admin/drivers"},
+		{"staff", http.MethodGet, "/api/v1/admin/stream"},
+		{"staff", http.MethodPost, "/api/v1/admin/shifts/1/assign-student"},
+		{"driver", http.MethodGet, "/api/v1/staff/shifts/my"},
+		{"student", http.MethodGet, "/api/v1/staff/shifts/my"},
+		{"student", http.MethodGet, "/api/v1/driver/shifts"},
+		{"staff", http.MethodGet, "/api/v1/driver/shifts"},
+	}
+	for _, tc := range cases {
+		token, err := jwtUtil.GenerateToken(1, tc.role)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code, tc.role+" "+tc.path)
+	}
+}
@@ -3,16 +3,26 @@ package service
 import (
 	"context"
 	"errors"
+	"slices"
 	"time"
 
+	"pickup/internal/scheduler/events"
 	"pickup/internal/scheduler/models"
 
+	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AdminService struct {
 	db       *gorm.DB
 	assigner *ShiftAssignmentService
+	notifier *NotificationService
+	broker   *events.Broker
+	loc      *time.Location
+	actor    Actor
+	// campaign 非 nil 时覆盖当前活动，见 WithCampaign。
+	campaign *uint
 }
 
 type DriverDTO struct {
@@ -21,20 +31,40 @@ type DriverDTO struct {
 	MaxSeats   int
 	MaxChecked int
 	MaxCarryOn int
+	// IfMatch 仅用于更新：非 nil 时须等于当前 version，否则返回 ErrVersionMismatch。
+	IfMatch *uint
 }
 
 type ShiftUpdateDTO struct {
-	DriverID      *uint
-	DepartureTime *time.Time
+	DriverID         *uint
+	DepartureTime    *time.Time
+	EstimatedMinutes *int
+	// CapacityPolicy 非 nil 时覆盖全局策略，空字符串表示恢复全局策略。
+	CapacityPolicy *models.CapacityPolicy
+	// IfMatch 非 nil 时须等于当前 version，否则返回 ErrVersionMismatch。
+	IfMatch *uint
 }
 
-func NewAdminService(db *gorm.DB, assigner *ShiftAssignmentService) *AdminService {
-	return &AdminService{db: db, assigner: assigner}
+// NewAdminService notifier 为 nil 时发布班次不发送通知；broker 为 nil 时不广播变更；
+// loc 为调度时区，用于解释导入的时间。
+func NewAdminService(db *gorm.DB, assigner *ShiftAssignmentService, notifier *NotificationService, broker *events.Broker, loc *time.Location) *AdminService {
+	return &AdminService{db: db, assigner: assigner, notifier: notifier, broker: broker, loc: loc}
 }
 
-func (s *AdminService) ListDrivers() ([]models.Driver, error) {
+var ErrDriverNotFound = errors.New("driver not found")
+
+// ListDrivers includeArchived 为 false 时不返回已归档司机。
+func (s *AdminService) ListDrivers(includeArchived bool) ([]models.Driver, error) {
+	campaignID, err := s.campaignScope()
+	if err != nil {
+		return nil, err
+	}
+	query := s.db.Scopes(inCampaign("campaign_id", campaignID))
+	if !includeArchived {
+		query = query.Where("archived_at IS NULL")
+	}
 	var drivers []models.Driver
-	err := s.db.Find(&drivers).Error
+	err = query.Find(&drivers).Error
 	return drivers, err
 }
 
@@ -44,15 +74,27 @@ func (s *AdminService) ListUsers() ([]models.User, error) {
 	return users, err
 }
 
+// CreateDriver 新司机加入当前活动的名单。
 func (s *AdminService) CreateDriver(input DriverDTO) (*models.Driver, error) {
+	campaignID, err := s.campaignScope()
+	if err != nil {
+		return nil, err
+	}
 	driver := models.Driver{
 		Name:       input.Name,
 		CarModel:   input.CarModel,
 		MaxSeats:   input.MaxSeats,
 		MaxChecked: input.MaxChecked,
 		MaxCarryOn: input.MaxCarryOn,
+		CampaignID: campaignID,
 	}
-	if err := s.db.Create(&driver).Error; err != nil {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&driver).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, s.actor, "driver.create", auditEntityDriver, driver.ID, nil, driver)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &driver, nil
@@ -65,38 +107,224 @@ func (s *AdminService) UpdateDriver(driverID uint, input DriverDTO) (*models.Dri
 		"max_seats":    input.MaxSeats,
 		"max_checked":  input.MaxChecked,
 		"max_carry_on": input.MaxCarryOn,
+		"version":      bumpVersion,
 	}
-	if err := s.db.Model(&models.Driver{}).Where("id = ?", driverID).Updates(updates).Error; err != nil {
+	var driver models.Driver
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Driver
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, driverID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDriverNotFound
+			}
+			return err
+		}
+		if err := checkVersion(before.Version, input.IfMatch); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Driver{}).Where("id = ?", driverID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&driver, driverID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, s.actor, "driver.update", auditEntityDriver, driverID, before, driver)
+	})
+	if err != nil {
 		return nil, err
 	}
+	return &driver, nil
+}
 
-	var driver models.Driver
-	if err := s.db.First(&driver, driverID).Error; err != nil {
+// DashboardShifts 按条件分页返回班次，默认只看未结束班次、按出发时间升序；只为当前页加载司机、乘客与志愿者。
+func (s *AdminService) DashboardShifts(filter ShiftFilter) (*Page[models.Shift], error) {
+	campaignID, err := s.campaignScope()
+	if err != nil {
 		return nil, err
 	}
-	return &driver, nil
+	order, err := parseSort(filter.Sort, "departure_time", "shifts", shiftSortColumns)
+	if err != nil {
+		return nil, err
+	}
+	base, err := filter.apply(s.db.Model(&models.Shift{}).Scopes(inCampaign("shifts.campaign_id", campaignID)))
+	if err != nil {
+		return nil, err
+	}
+	page, err := paginate(base, order, filter.PageQuery,
+		func(shift models.Shift) (time.Time, uint) { return shift.DepartureTime, shift.ID },
+		func(db *gorm.DB) *gorm.DB { return db.Preload("Driver").Preload("Requests").Preload("Staffs") })
+	if err != nil {
+		return nil, err
+	}
+	if err := attachShiftBoarding(s.db, page.Items); err != nil {
+		return nil, err
+	}
+	if err := attachShiftCapacity(s.db, page.Items); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// PendingRequests 按条件分页返回待分配需求，默认按到达日期升序。
+func (s *AdminService) PendingRequests(filter RequestFilter) (*Page[models.Request], error) {
+	campaignID, err := s.campaignScope()
+	if err != nil {
+		return nil, err
+	}
+	order, err := parseSort(filter.Sort, "arrival_date", "requests", requestSortColumns)
+	if err != nil {
+		return nil, err
+	}
+	base := filter.apply(s.db.Model(&models.Request{}).
+		Scopes(inCampaign("requests.campaign_id", campaignID)).
+		Where("requests.status = ?", models.RequestStatusPending), s.loc)
+	return paginate(base, order, filter.PageQuery,
+		func(req models.Request) (time.Time, uint) { return req.ArrivalDate, req.ID })
+}
+
+// RequestUpdateDTO 管理员修正需求的人数与行李，nil 字段保持不变。
+type RequestUpdateDTO struct {
+	Passengers  *int
+	CheckedBags *int
+	CarryOnBags *int
+	// IfMatch 非 nil 时须等于当前 version，否则返回 ErrVersionMismatch。
+	IfMatch *uint
 }
 
-func (s *AdminService) DashboardShifts() ([]models.Shift, error) {
-	var shifts []models.Shift
-	err := s.db.
-		Where("status IN ?", []models.ShiftStatus{models.ShiftStatusDraft, models.ShiftStatusPublished}).
-		Preload("Driver").
-		Preload("Requests").
-		Preload("Staffs").
-		Find(&shifts).Error
-	return shifts, err
+// UpdateRequestResult Warning 为 capacity_overload 时表示修改后所在班次超载。
+type UpdateRequestResult struct {
+	Request *models.Request `json:"request"`
+	Warning string          `json:"warning,omitempty"`
+}
+
+// UpdateRequest 管理员修改需求人数/行李；已绑定班次时按容量策略重新校验，reject 策略下超载返回 *CapacityExceededError。
+func (s *AdminService) UpdateRequest(requestID uint, input RequestUpdateDTO) (*UpdateRequestResult, error) {
+	updates := map[string]any{}
+	if input.Passengers != nil {
+		if !validPassengers(*input.Passengers) {
+			return nil, ErrInvalidPassengers
+		}
+		updates["passengers"] = *input.Passengers
+	}
+	if input.CheckedBags != nil {
+		if *input.CheckedBags < 0 {
+			return nil, ErrNegativeCheckedBags
+		}
+		updates["checked_bags"] = *input.CheckedBags
+	}
+	if input.CarryOnBags != nil {
+		if *input.CarryOnBags < 0 {
+			return nil, ErrNegativeCarryOnBags
+		}
+		updates["carry_on_bags"] = *input.CarryOnBags
+	}
+	if len(updates) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+
+	result := &UpdateRequestResult{}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Request
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, requestID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRequestNotFound
+			}
+			return err
+		}
+		if err := checkVersion(before.Version, input.IfMatch); err != nil {
+			return err
+		}
+		if before.Status == models.RequestStatusCanceled {
+			return ErrRequestAlreadyCanceled
+		}
+		updates["version"] = bumpVersion
+		if err := tx.Model(&models.Request{}).Where("id = ?", requestID).Updates(updates).Error; err != nil {
+			return err
+		}
+		var req models.Request
+		if err := tx.First(&req, requestID).Error; err != nil {
+			return err
+		}
+		result.Request = &req
+
+		var binding models.ShiftRequest
+		if err := tx.Where("request_id = ?", requestID).Limit(1).Find(&binding).Error; err != nil {
+			return err
+		}
+		if binding.ShiftID != 0 {
+			var shift models.Shift
+			if err := tx.Preload("Driver").First(&shift, binding.ShiftID).Error; err != nil {
+				return err
+			}
+			usages, err := loadShiftUsage(tx, []uint{shift.ID})
+			if err != nil {
+				return err
+			}
+			capacity := buildShiftCapacity(shift.ID, shift.Driver, usages[shift.ID])
+			if capacity.Overloaded {
+				if s.assigner.policyFor(shift) == models.CapacityPolicyReject {
+					return &CapacityExceededError{
+						Seats: capacity.Seats.Used, MaxSeats: capacity.Seats.Limit,
+						Checked: capacity.CheckedBags.Used, MaxChecked: capacity.CheckedBags.Limit,
+						CarryOn: capacity.CarryOnBags.Used, MaxCarryOn: capacity.CarryOnBags.Limit,
+					}
+				}
+				result.Warning = "capacity_overload"
+			}
+		}
+		return recordAudit(tx, s.actor, "request.update", auditEntityRequest, requestID, before, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.broker.Publish(events.RequestUpdated, requestEvent(result.Request))
+	return result, nil
 }
 
-func (s *AdminService) PendingRequests() ([]models.Request, error) {
-	var reqs []models.Request
-	err := s.db.Where("status = ?", models.RequestStatusPending).Find(&reqs).Error
-	return reqs, err
+// shiftCampaignInTx 班次归属司机所在活动；scope 非 nil 时司机必须属于该活动。
+// 司机行加锁，同一司机的并发建班因此串行做重叠检查。
+func shiftCampaignInTx(tx *gorm.DB, driverID uint, scope *uint) (*uint, error) {
+	var driver models.Driver
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&driver, driverID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDriverNotFound
+		}
+		return nil, err
+	}
+	if scope != nil && !sameCampaign(driver.CampaignID, scope) {
+		return nil, ErrCampaignMismatch
+	}
+	if driver.ArchivedAt != nil {
+		return nil, ErrDriverArchived
+	}
+	return driver.CampaignID, nil
 }
 
-func (s *AdminService) CreateShift(driverID uint, departureTime time.Time) (*models.Shift, error) {
-	shift := models.Shift{DriverID: driverID, DepartureTime: departureTime, Status: models.ShiftStatusDraft}
-	if err := s.db.Create(&shift).Error; err != nil {
+// CreateShift estimatedMinutes 为 0 时使用 DefaultShiftMinutes；司机时段重叠时返回 *DriverOverlapError。
+func (s *AdminService) CreateShift(driverID uint, departureTime time.Time, estimatedMinutes int) (*models.Shift, error) {
+	minutes, err := shiftMinutes(estimatedMinutes)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := s.campaignScope()
+	if err != nil {
+		return nil, err
+	}
+	shift := models.Shift{DriverID: driverID, DepartureTime: departureTime, EstimatedMinutes: minutes, Status: models.ShiftStatusDraft}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		campaignID, err := shiftCampaignInTx(tx, driverID, scope)
+		if err != nil {
+			return err
+		}
+		shift.CampaignID = campaignID
+		if shift.Warning, err = checkDriverScheduleInTx(tx, driverID, departureTime, minutes, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(&shift).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, s.actor, "shift.create", auditEntityShift, shift.ID, nil, shift)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &shift, nil
@@ -110,46 +338,217 @@ func (s *AdminService) UpdateShift(shiftID uint, input ShiftUpdateDTO) (*models.
 	if input.DepartureTime != nil {
 		updates["departure_time"] = *input.DepartureTime
 	}
+	if input.EstimatedMinutes != nil {
+		if *input.EstimatedMinutes < 1 || *input.EstimatedMinutes > MaxShiftMinutes {
+			return nil, ErrInvalidShiftMinutes
+		}
+		updates["estimated_minutes"] = *input.EstimatedMinutes
+	}
+	if input.CapacityPolicy != nil {
+		switch {
+		case *input.CapacityPolicy == "":
+			updates["capacity_policy"] = nil
+		case validCapacityPolicy(*input.CapacityPolicy):
+			updates["capacity_policy"] = *input.CapacityPolicy
+		default:
+			return nil, errors.New("invalid capacity_policy")
+		}
+	}
 	if len(updates) == 0 {
 		return nil, errors.New("no fields to update")
 	}
-	if err := s.db.Model(&models.Shift{}).Where("id = ?", shiftID).Updates(updates).Error; err != nil {
-		return nil, err
-	}
-
 	var shift models.Shift
-	if err := s.db.Preload("Driver").First(&shift, shiftID).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Shift
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, shiftID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShiftNotFound
+			}
+			return err
+		}
+		if err := checkVersion(before.Version, input.IfMatch); err != nil {
+			return err
+		}
+		if input.DriverID != nil {
+			var driver models.Driver
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&driver, *input.DriverID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrDriverNotFound
+				}
+				return err
+			}
+			if !sameCampaign(driver.CampaignID, before.CampaignID) {
+				return ErrCampaignMismatch
+			}
+			if driver.ArchivedAt != nil && driver.ID != before.DriverID {
+				return ErrDriverArchived
+			}
+		}
+		var warning string
+		if reschedules(input) && slices.Contains(busyShiftStatuses, before.Status) {
+			driverID, departure, minutes := before.DriverID, before.DepartureTime, before.EstimatedMinutes
+			if input.DriverID != nil {
+				driverID = *input.DriverID
+			}
+			if input.DepartureTime != nil {
+				departure = *input.DepartureTime
+			}
+			if input.EstimatedMinutes != nil {
+				minutes = *input.EstimatedMinutes
+			}
+			if input.DriverID == nil {
+				// 换司机时上面已锁住新司机；原司机改期同样要与并发建班互斥。
+				if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Driver{}, driverID).Error; err != nil {
+					return err
+				}
+			}
+			var err error
+			if warning, err = checkDriverScheduleInTx(tx, driverID, departure, minutes, shiftID); err != nil {
+				return err
+			}
+			// 改期或改时长同样可能让已分配的志愿者撞班，处理方式与 AssignStaff 一致。
+			if input.DepartureTime != nil || input.EstimatedMinutes != nil {
+				var staffIDs []uint
+				if err := tx.Table("shift_staffs").Where("shift_id = ?", shiftID).Order("staff_id ASC").Pluck("staff_id", &staffIDs).Error; err != nil {
+					return err
+				}
+				moved := before
+				moved.DepartureTime, moved.EstimatedMinutes = departure, minutes
+				for _, staffID := range staffIDs {
+					if err := lockStaffInTx(tx, staffID); err != nil {
+						return err
+					}
+					staffWarning, err := checkStaffScheduleInTx(tx, staffID, moved)
+					if err != nil {
+						return err
+					}
+					if warning == "" {
+						warning = staffWarning
+					}
+				}
+			}
+		}
+		// 改派司机或改期后需要司机重新确认。
+		reassigned := (input.DriverID != nil && *input.DriverID != before.DriverID) ||
+			(input.DepartureTime != nil && !input.DepartureTime.Equal(before.DepartureTime))
+		if reassigned {
+			updates["driver_status"] = models.ShiftDriverStatusPending
+			updates["driver_responded_at"] = nil
+			updates["driver_note"] = ""
+		}
+		// 日历事件的车辆或起止时间变化时 SEQUENCE 加一。
+		if reassigned || (input.EstimatedMinutes != nil && *input.EstimatedMinutes != before.EstimatedMinutes) {
+			updates["schedule_seq"] = gorm.Expr("schedule_seq + 1")
+		}
+		updates["version"] = bumpVersion
+		if err := tx.Model(&models.Shift{}).Where("id = ?", shiftID).Updates(updates).Error; err != nil {
+			return err
+		}
+		// 改期后重新判定已绑定需求的接机冲突，结果见 ListShiftConflicts。
+		if input.DepartureTime != nil && !input.DepartureTime.Equal(before.DepartureTime) {
+			if _, err := detectConflictsInTx(tx, func(db *gorm.DB) *gorm.DB {
+				return db.Where("sr.shift_id = ?", shiftID)
+			}); err != nil {
+				return err
+			}
+		}
+		if err := tx.Preload("Driver").First(&shift, shiftID).Error; err != nil {
+			return err
+		}
+		shift.Warning = warning
+		after := shift
+		after.Driver = nil
+		return recordAudit(tx, s.actor, "shift.update", auditEntityShift, shiftID, before, after)
+	})
+	if err != nil {
 		return nil, err
 	}
+	s.broker.Publish(events.ShiftUpdated, shiftChangeEvent(&shift))
 	return &shift, nil
 }
 
-func (s *AdminService) AssignStudent(shiftID, requestID uint) (AssignStudentResult, error) {
-	return s.assigner.AssignStudentToShift(context.Background(), shiftID, requestID)
+// reschedules 修改司机、发车时间或预计用时需要重新检查司机排班。
+func reschedules(input ShiftUpdateDTO) bool {
+	return input.DriverID != nil || input.DepartureTime != nil || input.EstimatedMinutes != nil
 }
 
-func (s *AdminService) RemoveStudent(shiftID, requestID uint) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("shift_id = ? AND request_id = ?", shiftID, requestID).Delete(&models.ShiftRequest{}).Error; err != nil {
+// requestBinding 审计中记录需求与班次的绑定关系。
+type requestBinding struct {
+	ShiftID *uint                `json:"shift_id"`
+	Status  models.RequestStatus `json:"status"`
+	Warning string               `json:"warning,omitempty"`
+	Reason  string               `json:"reason,omitempty"`
+}
+
+// AssignStudent reason 仅在 allow_with_reason 策略下超载时必填。
+// 加入已发布班次时需求直接为 published，并在提交后通知该学生。
+func (s *AdminService) AssignStudent(shiftID, requestID uint, reason string) (AssignStudentResult, error) {
+	var result AssignStudentResult
+	err := s.db.WithContext(context.Background()).Transaction(func(tx *gorm.DB) error {
+		res, err := s.assigner.assignInTx(tx, shiftID, requestID, reason)
+		if err != nil {
 			return err
 		}
-		return tx.Table("requests").Where("id = ?", requestID).Update("status", models.RequestStatusPending).Error
+		result = res
+		return recordAudit(tx, s.actor, "request.assign", auditEntityRequest, requestID,
+			requestBinding{Status: models.RequestStatusPending},
+			requestBinding{ShiftID: &shiftID, Status: res.Status, Warning: res.Warning, Reason: res.Reason})
 	})
+	if err != nil {
+		return AssignStudentResult{}, err
+	}
+	s.broker.Publish(events.StudentAssigned, map[string]any{"shift_id": shiftID, "request_id": requestID, "warning": result.Warning})
+	if result.Status == models.RequestStatusPublished {
+		s.notifyShiftPublished(shiftID)
+	}
+	return result, nil
 }
 
-func (s *AdminService) AssignStaff(shiftID, staffID uint) error {
-	var user models.User
-	if err := s.db.First(&user, staffID).Error; err != nil {
+// RemoveStudent 解绑后需求回到 pending；已取消的需求返回 ErrRequestAlreadyCanceled，
+// 需求不在该班次上返回 ErrRequestNotOnShift，两种情况都不改动需求。
+func (s *AdminService) RemoveStudent(shiftID, requestID uint) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var req models.Request
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").First(&req, requestID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRequestNotFound
+			}
+			return err
+		}
+		if req.Status == models.RequestStatusCanceled {
+			return ErrRequestAlreadyCanceled
+		}
+		res := tx.Where("shift_id = ? AND request_id = ?", shiftID, requestID).Delete(&models.ShiftRequest{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrRequestNotOnShift
+		}
+		if err := tx.Table("requests").Where("id = ?", requestID).Updates(map[string]any{
+			"status":  models.RequestStatusPending,
+			"version": bumpVersion,
+		}).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, s.actor, "request.remove", auditEntityRequest, requestID,
+			requestBinding{ShiftID: &shiftID, Status: req.Status},
+			requestBinding{Status: models.RequestStatusPending})
+	})
+	if err != nil {
 		return err
 	}
-	if user.Role != models.UserRoleStaff && user.Role != models.UserRoleAdmin {
-		return errors.New("user is not staff")
-	}
-	return s.db.Table("shift_staffs").Create(map[string]any{"shift_id": shiftID, "staff_id": staffID}).Error
+	s.broker.Publish(events.StudentRemoved, map[string]any{"shift_id": shiftID, "request_id": requestID})
+	return nil
 }
 
 func (s *AdminService) RemoveStaff(shiftID, staffID uint) error {
-	return s.db.Where("shift_id = ? AND staff_id = ?", shiftID, staffID).Delete(&models.ShiftStaff{}).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("shift_id = ? AND staff_id = ?", shiftID, staffID).Delete(&models.ShiftStaff{}).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, s.actor, "shift.remove_staff", auditEntityShift, shiftID, map[string]any{"staff_id": staffID}, nil)
+	})
 }
 
 func (s *AdminService) SetUserStaff(userID uint) (*models.User, error) {
@@ -160,8 +559,7 @@ func (s *AdminService) SetUserStaff(userID uint) (*models.User, error) {
 	if user.Role == models.UserRoleAdmin {
 		return nil, errors.New("cannot change admin role")
 	}
-	user.Role = models.UserRoleStaff
-	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Update("role", user.Role).Error; err != nil {
+	if err := s.changeUserRole(&user, models.UserRoleStaff, "user.set_staff"); err != nil {
 		return nil, err
 	}
 	return &user, nil
@@ -175,20 +573,61 @@ func (s *AdminService) UnsetUserStaff(userID uint) (*models.User, error) {
 	if user.Role == models.UserRoleAdmin {
 		return nil, errors.New("cannot change admin role")
 	}
-	user.Role = models.UserRoleStudent
-	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Update("role", user.Role).Error; err != nil {
+	if err := s.changeUserRole(&user, models.UserRoleStudent, "user.unset_staff"); err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (s *AdminService) PublishShift(shiftID uint) error {
+func (s *AdminService) changeUserRole(user *models.User, role models.UserRole, action string) error {
+	before := user.Role
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Model(&models.Shift{}).Where("id = ?", shiftID).Update("status", models.ShiftStatusPublished).Error; err != nil {
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("role", role).Error; err != nil {
+			return err
+		}
+		user.Role = role
+		return recordAudit(tx, s.actor, action, auditEntityUser, user.ID,
+			map[string]any{"role": before}, map[string]any{"role": role})
+	})
+}
+
+// PublishShift 发布草稿班次，已分配的需求随之 published 并通知学生；
+// 已发布的班次可再次发布，用于同步之后加入的需求。
+func (s *AdminService) PublishShift(shiftID uint) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var shift models.Shift
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").First(&shift, shiftID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShiftNotFound
+			}
+			return err
+		}
+		if shift.Status == models.ShiftStatusPublished {
+			// 重复发布：状态与版本不变，只把之后加入的需求同步为 published 并补发通知。
+			if err := recordAudit(tx, s.actor, "shift.republish", auditEntityShift, shiftID, nil, nil); err != nil {
+				return err
+			}
+		} else if _, err := transitionShiftInTx(tx, s.actor, "shift.publish", shiftID, models.ShiftStatusPublished, nil); err != nil {
 			return err
 		}
 		return tx.Table("requests").
 			Where("id IN (SELECT request_id FROM shift_requests WHERE shift_id = ?)", shiftID).
-			Update("status", models.RequestStatusPublished).Error
+			Updates(map[string]any{"status": models.RequestStatusPublished, "version": bumpVersion}).Error
 	})
+	if err != nil {
+		return err
+	}
+	s.broker.Publish(events.ShiftPublished, map[string]any{"shift_id": shiftID})
+	s.notifyShiftPublished(shiftID)
+	return nil
+}
+
+// notifyShiftPublished 通知在提交后发送，失败只记录结果，不影响写入；已发送过的学生不会重复通知。
+func (s *AdminService) notifyShiftPublished(shiftID uint) {
+	if s.notifier == nil {
+		return
+	}
+	if _, err := s.notifier.NotifyShiftPublished(shiftID); err != nil {
+		s.notifier.logger.Warn("notify shift published failed", zap.Uint("shift_id", shiftID), zap.Error(err))
+	}
 }
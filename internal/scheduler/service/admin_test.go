@@ -13,17 +13,17 @@ import (
 
 func TestAdminService_CoreFlows(t *testing.T) {
 	db := newTestDB(t)
-	assigner := NewShiftAssignmentService(db)
-	svc := NewAdminService(db, assigner)
+	assigner := NewShiftAssignmentService(db, nil)
+	svc := NewAdminService(db, assigner, nil, nil, time.UTC)
 
 	driver, err := svc.CreateDriver(DriverDTO{Name: "d1", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4})
 	require.NoError(t, err)
-	list, err := svc.ListDrivers()
+	list, err := svc.ListDrivers(false)
 	require.NoError(t, err)
 	require.Len(t, list, 1)
 	assert.Equal(t, driver.ID, list[0].ID)
 
-	shift, err := svc.CreateShift(driver.ID, time.Now())
+	shift, err := svc.CreateShift(driver.ID, time.Now(), 0)
 	require.NoError(t, err)
 
 	req := models.Request{UserID: 1, FlightNo: "AA1", ArrivalDate: time.Now(), Terminal: "T1", Status: models.RequestStatusPending}
@@ -39,19 +39,55 @@ func TestAdminService_CoreFlows(t *testing.T) {
 	require.NoError(t, db.First(&updatedReq, req.ID).Error)
 	assert.Equal(t, models.RequestStatusPending, updatedReq.Status)
 
-	pending, err := svc.PendingRequests()
+	pending, err := svc.PendingRequests(RequestFilter{})
 	require.NoError(t, err)
-	require.Len(t, pending, 1)
+	require.Len(t, pending.Items, 1)
 
-	dashboard, err := svc.DashboardShifts()
+	dashboard, err := svc.DashboardShifts(ShiftFilter{})
 	require.NoError(t, err)
-	require.Len(t, dashboard, 1)
-	assert.Equal(t, driver.ID, dashboard[0].Driver.ID)
+	require.Len(t, dashboard.Items, 1)
+	assert.Equal(t, driver.ID, dashboard.Items[0].Driver.ID)
+}
+
+func TestAdminService_RemoveStudentGuards(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+	student := NewStudentService(db, nil, time.UTC)
+
+	driver, err := svc.CreateDriver(DriverDTO{Name: "d1", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4})
+	require.NoError(t, err)
+	shiftA, err := svc.CreateShift(driver.ID, time.Date(2026, 8, 20, 10, 0, 0, 0, time.UTC), 0)
+	require.NoError(t, err)
+	shiftB, err := svc.CreateShift(driver.ID, time.Date(2026, 8, 20, 14, 0, 0, 0, time.UTC), 0)
+	require.NoError(t, err)
+
+	bound, err := student.CreateRequest(2, CreateRequestInput{FlightNo: "AA1", ArrivalDate: "2026-08-20", Terminal: "T1", ExpectedArrivalTime: "2026-08-20 09:00:00"})
+	require.NoError(t, err)
+	_, err = svc.AssignStudent(shiftA.ID, bound.ID, "")
+	require.NoError(t, err)
+
+	// 需求在另一个班次上：不解绑、不改状态。
+	assert.ErrorIs(t, svc.RemoveStudent(shiftB.ID, bound.ID), ErrRequestNotOnShift)
+	var stored models.Request
+	require.NoError(t, db.First(&stored, bound.ID).Error)
+	assert.Equal(t, models.RequestStatusAssigned, stored.Status)
+	var bindings int64
+	require.NoError(t, db.Model(&models.ShiftRequest{}).Where("shift_id = ? AND request_id = ?", shiftA.ID, bound.ID).Count(&bindings).Error)
+	assert.Equal(t, int64(1), bindings)
+
+	// 学生已撤销的需求不能被移回 pending。
+	_, err = student.CancelRequest(2, bound.ID)
+	require.NoError(t, err)
+	assert.ErrorIs(t, svc.RemoveStudent(shiftA.ID, bound.ID), ErrRequestAlreadyCanceled)
+	require.NoError(t, db.First(&stored, bound.ID).Error)
+	assert.Equal(t, models.RequestStatusCanceled, stored.Status)
+
+	assert.ErrorIs(t, svc.RemoveStudent(shiftA.ID, 999), ErrRequestNotFound)
 }
 
 func TestAdminService_AssignStaff_EdgeCases(t *testing.T) {
 	db := newTestDB(t)
-	svc := NewAdminService(db, NewShiftAssignmentService(db))
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
 
 	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
 	require.NoError(t, db.Create(&driver).Error)
@@ -60,18 +96,21 @@ func TestAdminService_AssignStaff_EdgeCases(t *testing.T) {
 
 	student := models.User{OpenID: "u-student", Name: "stu", Role: models.UserRoleStudent}
 	require.NoError(t, db.Create(&student).Error)
-	err := svc.AssignStaff(shift.ID, student.ID)
-	assert.ErrorContains(t, err, "user is not staff")
+	_, err := svc.AssignStaff(shift.ID, student.ID, "")
+	assert.ErrorIs(t, err, ErrUserNotStaff)
 
 	staff := models.User{OpenID: "u-staff", Name: "staff", Role: models.UserRoleStaff}
 	require.NoError(t, db.Create(&staff).Error)
-	require.NoError(t, svc.AssignStaff(shift.ID, staff.ID))
+	_, err = svc.AssignStaff(999, staff.ID, "")
+	assert.ErrorIs(t, err, ErrShiftNotFound)
+	_, err = svc.AssignStaff(shift.ID, staff.ID, "")
+	require.NoError(t, err)
 	require.NoError(t, svc.RemoveStaff(shift.ID, staff.ID))
 }
 
 func TestAdminService_UpdateDriverAndShift(t *testing.T) {
 	db := newTestDB(t)
-	svc := NewAdminService(db, NewShiftAssignmentService(db))
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
 
 	driver, err := svc.CreateDriver(DriverDTO{Name: "d1", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4})
 	require.NoError(t, err)
@@ -81,7 +120,7 @@ func TestAdminService_UpdateDriverAndShift(t *testing.T) {
 	assert.Equal(t, "d2", updatedDriver.Name)
 	assert.Equal(t, "Van", updatedDriver.CarModel)
 
-	shift, err := svc.CreateShift(driver.ID, time.Now())
+	shift, err := svc.CreateShift(driver.ID, time.Now(), 0)
 	require.NoError(t, err)
 
 	newDriver, err := svc.CreateDriver(DriverDTO{Name: "d3", CarModel: "Sedan", MaxSeats: 3, MaxChecked: 2, MaxCarryOn: 2})
@@ -94,11 +133,26 @@ func TestAdminService_UpdateDriverAndShift(t *testing.T) {
 
 	_, err = svc.UpdateShift(shift.ID, ShiftUpdateDTO{})
 	assert.ErrorContains(t, err, "no fields to update")
+
+	reject := models.CapacityPolicyReject
+	updatedShift, err = svc.UpdateShift(shift.ID, ShiftUpdateDTO{CapacityPolicy: &reject})
+	require.NoError(t, err)
+	require.NotNil(t, updatedShift.CapacityPolicy)
+	assert.Equal(t, reject, *updatedShift.CapacityPolicy)
+
+	inherit := models.CapacityPolicy("")
+	updatedShift, err = svc.UpdateShift(shift.ID, ShiftUpdateDTO{CapacityPolicy: &inherit})
+	require.NoError(t, err)
+	assert.Nil(t, updatedShift.CapacityPolicy)
+
+	bogus := models.CapacityPolicy("bogus")
+	_, err = svc.UpdateShift(shift.ID, ShiftUpdateDTO{CapacityPolicy: &bogus})
+	assert.ErrorContains(t, err, "invalid capacity_policy")
 }
 
 func TestAdminService_UserRoleManagement(t *testing.T) {
 	db := newTestDB(t)
-	svc := NewAdminService(db, NewShiftAssignmentService(db))
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
 
 	student := models.User{OpenID: "u-stu", Name: "stu", Role: models.UserRoleStudent}
 	admin := models.User{OpenID: "u-admin", Name: "adm", Role: models.UserRoleAdmin}
@@ -123,3 +177,55 @@ func TestAdminService_UserRoleManagement(t *testing.T) {
 	_, err = svc.UnsetUserStaff(admin.ID)
 	assert.ErrorContains(t, err, "cannot change admin role")
 }
+
+func TestAdminService_DeleteShift(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC).WithActor(Actor{UserID: 1, Role: "admin"})
+
+	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	staff := models.User{OpenID: "u-staff", Name: "staff", Role: models.UserRoleStaff}
+	require.NoError(t, db.Create(&staff).Error)
+
+	newShift := func(status models.ShiftStatus) (models.Shift, models.Request) {
+		shift := models.Shift{DriverID: driver.ID, DepartureTime: time.Now(), Status: status}
+		require.NoError(t, db.Create(&shift).Error)
+		req := models.Request{UserID: 1, FlightNo: "AA1", ArrivalDate: time.Now(), Terminal: "T1", Status: models.RequestStatusAssigned}
+		require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+		require.NoError(t, db.Table("shift_requests").Create(map[string]any{"shift_id": shift.ID, "request_id": req.ID}).Error)
+		require.NoError(t, db.Create(&models.ShiftStaff{ShiftID: shift.ID, StaffID: staff.ID}).Error)
+		return shift, req
+	}
+	assertReleased := func(shift models.Shift, req models.Request) {
+		var got models.Request
+		require.NoError(t, db.First(&got, req.ID).Error)
+		assert.Equal(t, models.RequestStatusPending, got.Status)
+		var links, staffs, shifts int64
+		require.NoError(t, db.Table("shift_requests").Where("shift_id = ?", shift.ID).Count(&links).Error)
+		require.NoError(t, db.Model(&models.ShiftStaff{}).Where("shift_id = ?", shift.ID).Count(&staffs).Error)
+		require.NoError(t, db.Model(&models.Shift{}).Where("id = ?", shift.ID).Count(&shifts).Error)
+		assert.Zero(t, links+staffs+shifts)
+	}
+
+	draft, draftReq := newShift(models.ShiftStatusDraft)
+	require.NoError(t, svc.DeleteShift(draft.ID, false))
+	assertReleased(draft, draftReq)
+
+	published, publishedReq := newShift(models.ShiftStatusPublished)
+	assert.ErrorIs(t, svc.DeleteShift(published.ID, false), ErrShiftPublished)
+	var kept models.Request
+	require.NoError(t, db.First(&kept, publishedReq.ID).Error)
+	assert.Equal(t, models.RequestStatusAssigned, kept.Status)
+	require.NoError(t, svc.DeleteShift(published.ID, true))
+	assertReleased(published, publishedReq)
+
+	running, _ := newShift(models.ShiftStatusInProgress)
+	assert.ErrorIs(t, svc.DeleteShift(running.ID, true), ErrShiftNotDeletable)
+	assert.ErrorIs(t, svc.DeleteShift(999, true), ErrShiftNotFound)
+
+	var audits []models.AuditLog
+	require.NoError(t, db.Where("action = ?", "shift.delete").Find(&audits).Error)
+	require.Len(t, audits, 2)
+	assert.Equal(t, draft.ID, audits[0].EntityID)
+	assert.Contains(t, string(audits[0].Before), "request_ids")
+}
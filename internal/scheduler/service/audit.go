@@ -0,0 +1,100 @@
+package service
+
+import (
+	"encoding/json"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+)
+
+// 审计实体类型。
+const (
+	auditEntityDriver   = "driver"
+	auditEntityShift    = "shift"
+	auditEntityRequest  = "request"
+	auditEntityUser     = "user"
+	auditEntityTerminal = "terminal"
+	auditEntityCampaign = "campaign"
+)
+
+// Actor 发起写操作的登录用户及请求 ID，由控制器从请求上下文构造。
+type Actor struct {
+	UserID    uint
+	Role      string
+	RequestID string
+}
+
+// AuditFilter 审计日志查询条件，零值字段不参与过滤。
+type AuditFilter struct {
+	ActorID    uint
+	EntityType string
+	EntityID   uint
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+}
+
+const defaultAuditLimit = 200
+
+// recordAudit 在调用方事务内写入一条审计记录；before/after 为 nil 时存 NULL。
+func recordAudit(tx *gorm.DB, actor Actor, action, entityType string, entityID uint, before, after any) error {
+	entry := models.AuditLog{
+		ActorID:    actor.UserID,
+		ActorRole:  actor.Role,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		RequestID:  actor.RequestID,
+	}
+	var err error
+	if entry.Before, err = auditJSON(before); err != nil {
+		return err
+	}
+	if entry.After, err = auditJSON(after); err != nil {
+		return err
+	}
+	return tx.Create(&entry).Error
+}
+
+func auditJSON(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// WithActor 返回绑定操作者的服务副本，后续写操作的审计记录归属该操作者。
+func (s *AdminService) WithActor(actor Actor) *AdminService {
+	clone := *s
+	clone.actor = actor
+	return &clone
+}
+
+// ListAuditLogs 按操作者、实体与时间范围查询审计日志，按时间倒序。
+func (s *AdminService) ListAuditLogs(filter AuditFilter) ([]models.AuditLog, error) {
+	query := s.db.Model(&models.AuditLog{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	logs := make([]models.AuditLog, 0)
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&logs).Error
+	return logs, err
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/clause"
+)
+
+func TestAdminService_AuditLogs(t *testing.T) {
+	db := newTestDB(t)
+	base := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+	svc := base.WithActor(Actor{UserID: 9, Role: "staff", RequestID: "req-1"})
+
+	driver, err := svc.CreateDriver(DriverDTO{Name: "d1", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4})
+	require.NoError(t, err)
+	shift, err := svc.CreateShift(driver.ID, time.Now(), 0)
+	require.NoError(t, err)
+	req := models.Request{UserID: 1, FlightNo: "AA1", ArrivalDate: time.Now(), Terminal: "T1", Status: models.RequestStatusPending}
+	require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+
+	_, err = svc.AssignStudent(shift.ID, req.ID, "")
+	require.NoError(t, err)
+	require.NoError(t, svc.PublishShift(shift.ID))
+	require.NoError(t, svc.RemoveStudent(shift.ID, req.ID))
+
+	// 失败的操作随事务回滚，不留审计记录
+	_, err = svc.AssignStudent(shift.ID, 999, "")
+	require.Error(t, err)
+
+	logs, err := base.ListAuditLogs(AuditFilter{EntityType: auditEntityRequest, EntityID: req.ID})
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	assert.Equal(t, "request.remove", logs[0].Action)
+	assert.Equal(t, "request.assign", logs[1].Action)
+	assert.Equal(t, uint(9), logs[1].ActorID)
+	assert.Equal(t, "staff", logs[1].ActorRole)
+	assert.Equal(t, "req-1", logs[1].RequestID)
+
+	var before, after map[string]any
+	require.NoError(t, json.Unmarshal(logs[0].Before, &before))
+	require.NoError(t, json.Unmarshal(logs[0].After, &after))
+	assert.Equal(t, "published", before["status"])
+	assert.EqualValues(t, shift.ID, before["shift_id"])
+	assert.Equal(t, "pending", after["status"])
+	assert.Nil(t, after["shift_id"])
+
+	shiftLogs, err := base.ListAuditLogs(AuditFilter{EntityType: auditEntityShift, EntityID: shift.ID})
+	require.NoError(t, err)
+	require.Len(t, shiftLogs, 2)
+	assert.Equal(t, "shift.publish", shiftLogs[0].Action)
+
+	all, err := base.ListAuditLogs(AuditFilter{ActorID: 9})
+	require.NoError(t, err)
+	assert.Len(t, all, 5)
+	none, err := base.ListAuditLogs(AuditFilter{ActorID: 10})
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	future := time.Now().Add(time.Hour)
+	later, err := base.ListAuditLogs(AuditFilter{From: &future})
+	require.NoError(t, err)
+	assert.Empty(t, later)
+	limited, err := base.ListAuditLogs(AuditFilter{To: &future, Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+}
@@ -118,7 +118,69 @@ func (s *AuthService) BindPhone(userID uint, phoneCode string) error {
 		updates["role"] = models.UserRoleAdmin
 	}
 
-	return s.db.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+			return err
+		}
+		return claimImportedUser(tx, s.logger, userID, normalizePhone(phone))
+	})
+}
+
+// claimImportedUser 把批量导入时按该手机号创建的占位用户的需求转给当前用户，并删除占位用户。
+// 当前用户在同一活动内已有有效需求时，两条中仍为 pending 的一条被取消（都为 pending 时取消导入的一条），
+// 保证每个活动只有一条有效需求；两条都已排班则放弃认领并记录日志，由管理员处理。
+func claimImportedUser(tx *gorm.DB, logger *zap.Logger, userID uint, phone string) error {
+	var placeholder models.User
+	if err := tx.Where("open_id = ? AND id <> ?", importOpenIDPrefix+phone, userID).Limit(1).Find(&placeholder).Error; err != nil {
+		return err
+	}
+	if placeholder.ID == 0 {
+		return nil
+	}
+
+	var imported, own []models.Request
+	if err := tx.Where("user_id = ? AND status <> ?", placeholder.ID, models.RequestStatusCanceled).Find(&imported).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ? AND status <> ?", userID, models.RequestStatusCanceled).Find(&own).Error; err != nil {
+		return err
+	}
+	var duplicates []uint
+	for _, in := range imported {
+		for _, mine := range own {
+			if !sameCampaign(in.CampaignID, mine.CampaignID) {
+				continue
+			}
+			switch {
+			case in.Status == models.RequestStatusPending:
+				duplicates = append(duplicates, in.ID)
+			case mine.Status == models.RequestStatusPending:
+				duplicates = append(duplicates, mine.ID)
+			default:
+				logger.Warn("skip claiming imported user: both requests are scheduled",
+					zap.Uint("user_id", userID), zap.Uint("placeholder_id", placeholder.ID),
+					zap.Uint("imported_request_id", in.ID), zap.Uint("request_id", mine.ID))
+				return nil
+			}
+		}
+	}
+	if len(duplicates) > 0 {
+		if err := tx.Model(&models.Request{}).Where("id IN ?", duplicates).
+			Updates(map[string]any{"status": models.RequestStatusCanceled, "version": bumpVersion}).Error; err != nil {
+			return err
+		}
+		logger.Info("canceled duplicate request while claiming imported user",
+			zap.Uint("user_id", userID), zap.Uint("placeholder_id", placeholder.ID), zap.Uints("request_ids", duplicates))
+	}
+
+	if err := tx.Model(&models.Request{}).Where("user_id = ?", placeholder.ID).
+		Updates(map[string]any{"user_id": userID, "version": bumpVersion}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.User{}).Where("id = ? AND name = ?", userID, "wx_user").Update("name", placeholder.Name).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&models.User{}, placeholder.ID).Error
 }
 
 func (s *AuthService) GetMe(userID uint) (*models.User, error) {
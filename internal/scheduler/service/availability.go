@@ -0,0 +1,227 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultShiftMinutes 未指定预计用时时的班次时长。
+	DefaultShiftMinutes = 120
+	// MaxShiftMinutes 单个班次预计用时上限，也是重叠查询的回看范围。
+	MaxShiftMinutes = 720
+
+	warningOutsideAvailability = "outside_availability"
+)
+
+var (
+	ErrInvalidShiftMinutes  = fmt.Errorf("estimated_minutes must be between 1 and %d", MaxShiftMinutes)
+	ErrInvalidAvailability  = errors.New("end_time must be after start_time")
+	ErrAvailabilityNotFound = errors.New("availability window not found")
+	ErrAvailabilityNotSelf  = errors.New("availability window was entered by an admin")
+)
+
+// busyShiftStatuses 占用司机时间的班次状态。
+var busyShiftStatuses = []models.ShiftStatus{models.ShiftStatusDraft, models.ShiftStatusPublished, models.ShiftStatusInProgress}
+
+// DriverOverlapError 司机在同一时段已有其他班次。
+type DriverOverlapError struct {
+	DriverID      uint
+	ShiftID       uint
+	DepartureTime time.Time
+}
+
+func (e *DriverOverlapError) Error() string {
+	return fmt.Sprintf("driver %d is already on shift %d departing at %s",
+		e.DriverID, e.ShiftID, e.DepartureTime.Format("2006-01-02 15:04"))
+}
+
+type AvailabilityDTO struct {
+	StartTime time.Time
+	EndTime   time.Time
+	Note      string
+}
+
+// shiftMinutes 0 表示使用默认时长。
+func shiftMinutes(minutes int) (int, error) {
+	if minutes == 0 {
+		return DefaultShiftMinutes, nil
+	}
+	if minutes < 0 || minutes > MaxShiftMinutes {
+		return 0, ErrInvalidShiftMinutes
+	}
+	return minutes, nil
+}
+
+func shiftEnd(departure time.Time, minutes int) time.Time {
+	return departure.Add(time.Duration(minutes) * time.Minute)
+}
+
+// loadBusyShifts 读取司机可能与 [from, to) 重叠的未结束班次。
+func loadBusyShifts(db *gorm.DB, driverIDs []uint, from, to time.Time, excludeShiftID uint) ([]models.Shift, error) {
+	query := db.Where("driver_id IN ? AND status IN ? AND departure_time < ? AND departure_time > ?",
+		driverIDs, busyShiftStatuses, to, from.Add(-MaxShiftMinutes*time.Minute))
+	if excludeShiftID != 0 {
+		query = query.Where("id <> ?", excludeShiftID)
+	}
+	var shifts []models.Shift
+	if err := query.Order("departure_time ASC").Find(&shifts).Error; err != nil {
+		return nil, err
+	}
+	busy := shifts[:0]
+	for _, shift := range shifts {
+		if shiftEnd(shift.DepartureTime, shift.EstimatedMinutes).After(from) {
+			busy = append(busy, shift)
+		}
+	}
+	return busy, nil
+}
+
+// availabilityWindow 司机与志愿者时间窗的共同行为。
+type availabilityWindow interface {
+	Covers(from, to time.Time) bool
+}
+
+// coveredByAvailability 未登记时间窗时视为可用；否则需有单个时间窗完整覆盖 [from, to)。
+func coveredByAvailability[W availabilityWindow](windows []W, from, to time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	for _, w := range windows {
+		if w.Covers(from, to) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDriverScheduleInTx 与司机其他班次重叠时返回 *DriverOverlapError；超出时间窗只返回警告。
+func checkDriverScheduleInTx(tx *gorm.DB, driverID uint, departure time.Time, minutes int, excludeShiftID uint) (string, error) {
+	end := shiftEnd(departure, minutes)
+	busy, err := loadBusyShifts(tx, []uint{driverID}, departure, end, excludeShiftID)
+	if err != nil {
+		return "", err
+	}
+	if len(busy) > 0 {
+		return "", &DriverOverlapError{DriverID: driverID, ShiftID: busy[0].ID, DepartureTime: busy[0].DepartureTime}
+	}
+	var windows []models.DriverAvailability
+	if err := tx.Where("driver_id = ?", driverID).Find(&windows).Error; err != nil {
+		return "", err
+	}
+	if !coveredByAvailability(windows, departure, end) {
+		return warningOutsideAvailability, nil
+	}
+	return "", nil
+}
+
+// AvailableDrivers 返回在 [from, to) 内没有班次且时间窗覆盖该时段的司机。
+func (s *AdminService) AvailableDrivers(from, to time.Time) ([]models.Driver, error) {
+	if !to.After(from) {
+		return nil, errors.New("to must be after from")
+	}
+	drivers, err := s.ListDrivers(false)
+	if err != nil || len(drivers) == 0 {
+		return drivers, err
+	}
+	driverIDs := make([]uint, 0, len(drivers))
+	for _, d := range drivers {
+		driverIDs = append(driverIDs, d.ID)
+	}
+
+	shifts, err := loadBusyShifts(s.db, driverIDs, from, to, 0)
+	if err != nil {
+		return nil, err
+	}
+	busy := make(map[uint]bool, len(shifts))
+	for _, shift := range shifts {
+		busy[shift.DriverID] = true
+	}
+	var rows []models.DriverAvailability
+	if err := s.db.Where("driver_id IN ?", driverIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	windows := make(map[uint][]models.DriverAvailability, len(drivers))
+	for _, w := range rows {
+		windows[w.DriverID] = append(windows[w.DriverID], w)
+	}
+
+	available := make([]models.Driver, 0, len(drivers))
+	for _, d := range drivers {
+		if !busy[d.ID] && coveredByAvailability(windows[d.ID], from, to) {
+			available = append(available, d)
+		}
+	}
+	return available, nil
+}
+
+func (s *AdminService) ListDriverAvailability(driverID uint) ([]models.DriverAvailability, error) {
+	var items []models.DriverAvailability
+	err := s.db.Where("driver_id = ?", driverID).Order("start_time ASC").Find(&items).Error
+	return items, err
+}
+
+func (s *AdminService) CreateDriverAvailability(driverID uint, input AvailabilityDTO) (*models.DriverAvailability, error) {
+	return createDriverAvailability(s.db, s.actor, driverID, input, models.AvailabilitySourceAdmin)
+}
+
+func (s *AdminService) DeleteDriverAvailability(driverID, windowID uint) error {
+	return deleteDriverAvailability(s.db, s.actor, driverID, windowID, false)
+}
+
+// createDriverAvailability 管理员录入与司机自报共用，source 记录来源。
+func createDriverAvailability(db *gorm.DB, actor Actor, driverID uint, input AvailabilityDTO, source models.AvailabilitySource) (*models.DriverAvailability, error) {
+	if !input.EndTime.After(input.StartTime) {
+		return nil, ErrInvalidAvailability
+	}
+	window := models.DriverAvailability{
+		DriverID:  driverID,
+		StartTime: input.StartTime,
+		EndTime:   input.EndTime,
+		Source:    source,
+		Note:      strings.TrimSpace(input.Note),
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var driver models.Driver
+		if err := tx.Select("id").First(&driver, driverID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDriverNotFound
+			}
+			return err
+		}
+		if err := tx.Create(&window).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, "driver.availability.create", auditEntityDriver, driverID, nil, window)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+// deleteDriverAvailability selfOnly 为 true 时只能删除本人自报的时间窗。
+func deleteDriverAvailability(db *gorm.DB, actor Actor, driverID, windowID uint, selfOnly bool) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var before models.DriverAvailability
+		if err := tx.Where("driver_id = ?", driverID).First(&before, windowID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAvailabilityNotFound
+			}
+			return err
+		}
+		if selfOnly && before.Source != models.AvailabilitySourceSelf {
+			return ErrAvailabilityNotSelf
+		}
+		if err := tx.Delete(&models.DriverAvailability{}, windowID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, "driver.availability.delete", auditEntityDriver, driverID, before, nil)
+	})
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminService_DriverDoubleBooking(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC).WithActor(Actor{UserID: 1, Role: "admin"})
+
+	driver, err := svc.CreateDriver(DriverDTO{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4})
+	require.NoError(t, err)
+	base := time.Date(2026, 8, 20, 10, 0, 0, 0, time.UTC)
+
+	first, err := svc.CreateShift(driver.ID, base, 0)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultShiftMinutes, first.EstimatedMinutes)
+	assert.Empty(t, first.Warning)
+
+	_, err = svc.CreateShift(driver.ID, base.Add(90*time.Minute), 60)
+	var overlap *DriverOverlapError
+	require.ErrorAs(t, err, &overlap)
+	assert.Equal(t, first.ID, overlap.ShiftID)
+	_, err = svc.CreateShift(driver.ID, base.Add(-30*time.Minute), 0)
+	assert.ErrorAs(t, err, &overlap)
+	_, err = svc.CreateShift(driver.ID, base, MaxShiftMinutes+1)
+	assert.ErrorIs(t, err, ErrInvalidShiftMinutes)
+
+	// 首尾相接不算重叠
+	second, err := svc.CreateShift(driver.ID, base.Add(2*time.Hour), 60)
+	require.NoError(t, err)
+
+	longer := 150
+	_, err = svc.UpdateShift(first.ID, ShiftUpdateDTO{EstimatedMinutes: &longer})
+	assert.ErrorAs(t, err, &overlap)
+	later := base.Add(5 * time.Hour)
+	_, err = svc.UpdateShift(first.ID, ShiftUpdateDTO{DepartureTime: &later, EstimatedMinutes: &longer})
+	require.NoError(t, err)
+
+	// 已取消的班次不占用司机
+	require.NoError(t, db.Model(&models.Shift{}).Where("id = ?", second.ID).Update("status", models.ShiftStatusCanceled).Error)
+	_, err = svc.CreateShift(driver.ID, base.Add(2*time.Hour), 60)
+	require.NoError(t, err)
+}
+
+func TestAdminService_ConcurrentCreateShift(t *testing.T) {
+	db := newTestDB(t)
+	// sqlite 不支持行锁，单连接让事务像 MySQL 中被司机行锁阻塞时一样依次执行。
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC).WithActor(Actor{UserID: 1, Role: "admin"})
+
+	driver, err := svc.CreateDriver(DriverDTO{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4})
+	require.NoError(t, err)
+	base := time.Date(2026, 8, 20, 10, 0, 0, 0, time.UTC)
+
+	const workers = 8
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = svc.CreateShift(driver.ID, base.Add(time.Duration(i)*time.Minute), 60)
+		}()
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		var overlap *DriverOverlapError
+		assert.ErrorAs(t, err, &overlap)
+	}
+	assert.Equal(t, 1, created)
+	var stored int64
+	require.NoError(t, db.Model(&models.Shift{}).Where("driver_id = ?", driver.ID).Count(&stored).Error)
+	assert.Equal(t, int64(1), stored)
+}
+
+func TestAdminService_DriverAvailability(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC).WithActor(Actor{UserID: 1, Role: "admin"})
+
+	windowed, err := svc.CreateDriver(DriverDTO{Name: "windowed", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4})
+	require.NoError(t, err)
+	open, err := svc.CreateDriver(DriverDTO{Name: "open", CarModel: "Van", MaxSeats: 6, MaxChecked: 6, MaxCarryOn: 6})
+	require.NoError(t, err)
+	day := time.Date(2026, 8, 20, 0, 0, 0, 0, time.UTC)
+
+	_, err = svc.CreateDriverAvailability(windowed.ID, AvailabilityDTO{StartTime: day.Add(12 * time.Hour), EndTime: day.Add(8 * time.Hour)})
+	assert.ErrorIs(t, err, ErrInvalidAvailability)
+	_, err = svc.CreateDriverAvailability(999, AvailabilityDTO{StartTime: day, EndTime: day.Add(time.Hour)})
+	assert.ErrorIs(t, err, ErrDriverNotFound)
+	window, err := svc.CreateDriverAvailability(windowed.ID, AvailabilityDTO{StartTime: day.Add(8 * time.Hour), EndTime: day.Add(14 * time.Hour), Note: " mornings "})
+	require.NoError(t, err)
+	assert.Equal(t, models.AvailabilitySourceAdmin, window.Source)
+	assert.Equal(t, "mornings", window.Note)
+
+	inside, err := svc.CreateShift(windowed.ID, day.Add(9*time.Hour), 60)
+	require.NoError(t, err)
+	assert.Empty(t, inside.Warning)
+	outside, err := svc.CreateShift(windowed.ID, day.Add(13*time.Hour), 120)
+	require.NoError(t, err)
+	assert.Equal(t, "outside_availability", outside.Warning)
+
+	free, err := svc.AvailableDrivers(day.Add(11*time.Hour), day.Add(12*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, free, 2)
+	free, err = svc.AvailableDrivers(day.Add(9*time.Hour), day.Add(10*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, free, 1)
+	assert.Equal(t, open.ID, free[0].ID)
+	free, err = svc.AvailableDrivers(day.Add(16*time.Hour), day.Add(17*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, free, 1)
+	assert.Equal(t, open.ID, free[0].ID)
+	_, err = svc.AvailableDrivers(day, day)
+	assert.Error(t, err)
+
+	items, err := svc.ListDriverAvailability(windowed.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.ErrorIs(t, svc.DeleteDriverAvailability(open.ID, window.ID), ErrAvailabilityNotFound)
+	require.NoError(t, svc.DeleteDriverAvailability(windowed.ID, window.ID))
+
+	var actions []string
+	require.NoError(t, db.Model(&models.AuditLog{}).Where("entity_type = ? AND entity_id = ?", auditEntityDriver, windowed.ID).Order("id").Pluck("action", &actions).Error)
+	assert.Equal(t, []string{"driver.create", "driver.availability.create", "driver.availability.delete"}, actions)
+}
+
+func TestSelfServiceAvailability(t *testing.T) {
+	db := newTestDB(t)
+	admin := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+	drivers := NewDriverService(db).WithActor(Actor{UserID: 1, Role: "driver"})
+	staffSvc := NewStaffService(db, nil, time.UTC).WithActor(Actor{UserID: 2, Role: "staff"})
+	day := time.Date(2026, 8, 20, 0, 0, 0, 0, time.UTC)
+	slot := AvailabilityDTO{StartTime: day.Add(8 * time.Hour), EndTime: day.Add(12 * time.Hour)}
+
+	driverUser := models.User{OpenID: "drv", Name: "Wang", Role: models.UserRoleDriver}
+	staff := models.User{OpenID: "stf", Name: "Lee", Role: models.UserRoleStaff}
+	student := models.User{OpenID: "stu", Name: "Li", Role: models.UserRoleStudent}
+	for _, u := range []*models.User{&driverUser, &staff, &student} {
+		require.NoError(t, db.Create(u).Error)
+	}
+
+	_, err := drivers.CreateMyAvailability(driverUser.ID, slot)
+	assert.ErrorIs(t, err, ErrDriverNotLinked)
+	driver, err := admin.CreateDriver(DriverDTO{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4})
+	require.NoError(t, err)
+	_, err = admin.LinkDriverUser(driver.ID, driverUser.ID)
+	require.NoError(t, err)
+
+	own, err := drivers.CreateMyAvailability(driverUser.ID, slot)
+	require.NoError(t, err)
+	assert.Equal(t, models.AvailabilitySourceSelf, own.Source)
+	assert.Equal(t, driver.ID, own.DriverID)
+	entered, err := admin.CreateDriverAvailability(driver.ID, AvailabilityDTO{StartTime: day.Add(14 * time.Hour), EndTime: day.Add(18 * time.Hour)})
+	require.NoError(t, err)
+	items, err := drivers.MyAvailability(driverUser.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	// 自报时间窗同样参与排班检查。
+	shift, err := admin.CreateShift(driver.ID, day.Add(12*time.Hour), 60)
+	require.NoError(t, err)
+	assert.Equal(t, "outside_availability", shift.Warning)
+
+	// 只能删除自报的时间窗；管理员录入的保留。
+	assert.ErrorIs(t, drivers.DeleteMyAvailability(driverUser.ID, entered.ID), ErrAvailabilityNotSelf)
+	require.NoError(t, drivers.DeleteMyAvailability(driverUser.ID, own.ID))
+	assert.ErrorIs(t, drivers.DeleteMyAvailability(driverUser.ID, own.ID), ErrAvailabilityNotFound)
+
+	_, err = staffSvc.CreateMyAvailability(student.ID, slot)
+	assert.ErrorIs(t, err, ErrUserNotStaff)
+	_, err = staffSvc.CreateMyAvailability(staff.ID, AvailabilityDTO{StartTime: slot.EndTime, EndTime: slot.StartTime})
+	assert.ErrorIs(t, err, ErrInvalidAvailability)
+	mine, err := staffSvc.CreateMyAvailability(staff.ID, slot)
+	require.NoError(t, err)
+	assert.Equal(t, models.AvailabilitySourceSelf, mine.Source)
+	adminWindow, err := admin.CreateStaffAvailability(staff.ID, slot)
+	require.NoError(t, err)
+	windows, err := staffSvc.MyAvailability(staff.ID)
+	require.NoError(t, err)
+	assert.Len(t, windows, 2)
+	assert.ErrorIs(t, staffSvc.DeleteMyAvailability(staff.ID, adminWindow.ID), ErrAvailabilityNotSelf)
+	assert.ErrorIs(t, staffSvc.DeleteMyAvailability(student.ID, mine.ID), ErrAvailabilityNotFound)
+	require.NoError(t, staffSvc.DeleteMyAvailability(staff.ID, mine.ID))
+
+	var actors []uint
+	require.NoError(t, db.Model(&models.AuditLog{}).Where("action = ?", "user.availability.create").Order("id").Pluck("actor_id", &actors).Error)
+	assert.Equal(t, []uint{2, 0}, actors)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+)
+
+// NoShowItem 未到乘客报表行。
+type NoShowItem struct {
+	ShiftID       uint      `json:"shift_id"`
+	DepartureTime time.Time `json:"departure_time"`
+	RequestID     uint      `json:"request_id"`
+	UserID        uint      `json:"user_id"`
+	Name          string    `json:"name"`
+	Phone         string    `json:"phone"`
+	FlightNo      string    `json:"flight_no"`
+	Terminal      string    `json:"terminal"`
+	CheckedInAt   time.Time `json:"checked_in_at"`
+	CheckedInBy   uint      `json:"checked_in_by"`
+}
+
+// loadBoarding 按需求 ID 读取登车记录；一个需求至多绑定一个班次。
+func loadBoarding(db *gorm.DB, requestIDs []uint) (map[uint]models.ShiftRequest, error) {
+	result := make(map[uint]models.ShiftRequest, len(requestIDs))
+	if len(requestIDs) == 0 {
+		return result, nil
+	}
+	var rows []models.ShiftRequest
+	if err := db.Where("request_id IN ?", requestIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.RequestID] = row
+	}
+	return result, nil
+}
+
+// attachShiftBoarding 为看板班次内的每个需求填充登车状态。
+func attachShiftBoarding(db *gorm.DB, shifts []models.Shift) error {
+	ids := make([]uint, 0)
+	for _, shift := range shifts {
+		for _, req := range shift.Requests {
+			ids = append(ids, req.ID)
+		}
+	}
+	boarding, err := loadBoarding(db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range shifts {
+		for j := range shifts[i].Requests {
+			if row, ok := boarding[shifts[i].Requests[j].ID]; ok {
+				shifts[i].Requests[j].Boarding = &row
+			}
+		}
+	}
+	return nil
+}
+
+// NoShowReport 统计发车日期在 [from, to] 内被登记为 no_show 的乘客。
+func (s *AdminService) NoShowReport(from, to time.Time) ([]NoShowItem, error) {
+	if to.Before(from) {
+		return nil, errors.New("invalid date range")
+	}
+	campaignID, err := s.campaignScope()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]NoShowItem, 0)
+	err = s.db.Table("shift_requests sr").
+		Select("sr.shift_id, s.departure_time, sr.request_id, r.user_id, u.name, u.phone, r.flight_no, r.terminal, sr.checked_in_at, sr.checked_in_by").
+		Joins("JOIN shifts s ON s.id = sr.shift_id").
+		Joins("JOIN requests r ON r.id = sr.request_id").
+		Joins("JOIN users u ON u.id = r.user_id").
+		Scopes(inCampaign("s.campaign_id", campaignID)).
+		Where("sr.boarding_status = ? AND s.departure_time >= ? AND s.departure_time < ?",
+			models.BoardingStatusNoShow, startOfDay(from, s.loc), startOfDay(to, s.loc).AddDate(0, 0, 1)).
+		Order("s.departure_time ASC, sr.request_id ASC").
+		Scan(&items).Error
+	return items, err
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"pickup/internal/config"
+	"pickup/internal/scheduler/ical"
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+)
+
+// CalendarLookback 订阅中保留的已过去班次天数。
+const CalendarLookback = 30 * 24 * time.Hour
+
+var ErrInvalidCalendarToken = errors.New("invalid calendar token")
+
+// calendarShiftStatuses 出现在订阅中的班次状态；草稿与已取消的班次不推送。
+var calendarShiftStatuses = []models.ShiftStatus{
+	models.ShiftStatusPublished,
+	models.ShiftStatusInProgress,
+	models.ShiftStatusCompleted,
+}
+
+type CalendarService struct {
+	db     *gorm.DB
+	secret []byte
+	loc    *time.Location
+}
+
+// NewCalendarService 令牌用 JWT 密钥签名；loc 为调度时区，见 NewSchedulerLocation。
+func NewCalendarService(db *gorm.DB, jwtCfg *config.JWTConfig, loc *time.Location) *CalendarService {
+	return &CalendarService{db: db, secret: []byte(jwtCfg.Secret), loc: loc}
+}
+
+// IssueFeed 为用户生成新的订阅令牌，旧令牌随即失效。
+func (s *CalendarService) IssueFeed(userID uint) (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	nonce := hex.EncodeToString(raw)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.CalendarFeed{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.CalendarFeed{UserID: userID, Nonce: nonce}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return s.sign(userID, nonce), nil
+}
+
+// RevokeFeed 删除用户的订阅，已分发的链接立即失效。
+func (s *CalendarService) RevokeFeed(userID uint) error {
+	return s.db.Where("user_id = ?", userID).Delete(&models.CalendarFeed{}).Error
+}
+
+// sign 令牌格式为 userID.nonce.签名。
+func (s *CalendarService) sign(userID uint, nonce string) string {
+	payload := fmt.Sprintf("%d.%s", userID, nonce)
+	return payload + "." + s.mac(payload)
+}
+
+func (s *CalendarService) mac(payload string) string {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// verify 先校验签名再查库，被吊销或重新生成过的令牌返回 ErrInvalidCalendarToken。
+func (s *CalendarService) verify(token string) (uint, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, ErrInvalidCalendarToken
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(s.mac(payload))) {
+		return 0, ErrInvalidCalendarToken
+	}
+	userID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidCalendarToken
+	}
+	var count int64
+	if err := s.db.Model(&models.CalendarFeed{}).
+		Where("user_id = ? AND nonce = ?", userID, parts[1]).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, ErrInvalidCalendarToken
+	}
+	return uint(userID), nil
+}
+
+// Feed 生成令牌所属用户的 .ics 内容：作为学生乘坐、作为志愿者值班或作为司机驾驶的已发布班次。
+// 每个班次的 UID 固定，改期后 SEQUENCE 递增，日历客户端会更新原有日程。
+func (s *CalendarService) Feed(token string, now time.Time) ([]byte, error) {
+	userID, err := s.verify(token)
+	if err != nil {
+		return nil, err
+	}
+	var shifts []models.Shift
+	err = s.db.
+		Where("status IN ? AND departure_time >= ?", calendarShiftStatuses, now.Add(-CalendarLookback)).
+		Where(s.db.
+			Where("id IN (?)", s.db.Table("shift_requests sr").Select("sr.shift_id").
+				Joins("JOIN requests r ON r.id = sr.request_id").Where("r.user_id = ?", userID)).
+			Or("id IN (?)", s.db.Table("shift_staffs").Select("shift_id").Where("staff_id = ?", userID)).
+			Or("driver_id IN (?)", s.db.Model(&models.Driver{}).Select("id").Where("user_id = ?", userID))).
+		Preload("Driver").
+		Preload("Requests").
+		Order("departure_time ASC, id ASC").
+		Find(&shifts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	cal := ical.Calendar{Name: "接机安排", Location: s.loc, Events: make([]ical.Event, 0, len(shifts))}
+	for _, shift := range shifts {
+		cal.Events = append(cal.Events, shiftEvent(shift, userID))
+	}
+	var buf bytes.Buffer
+	if err := ical.Write(&buf, cal, now); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// shiftEvent 学生只看到自己的航班；志愿者与司机看到整车乘客的航班。
+func shiftEvent(shift models.Shift, userID uint) ical.Event {
+	requests := shift.Requests
+	if own := slices.DeleteFunc(slices.Clone(requests), func(r models.Request) bool { return r.UserID != userID }); len(own) > 0 {
+		requests = own
+	}
+	var flights, places []string
+	for _, req := range requests {
+		place := strings.TrimSpace(req.Airport + " " + req.Terminal)
+		flights = append(flights, fmt.Sprintf("%s %s", req.FlightNo, place))
+		if !slices.Contains(places, place) {
+			places = append(places, place)
+		}
+	}
+
+	summary := fmt.Sprintf("接机班次 #%d", shift.ID)
+	if len(requests) == 1 {
+		summary = "接机 " + requests[0].FlightNo
+	}
+	var desc []string
+	if shift.Driver != nil {
+		desc = append(desc, fmt.Sprintf("车辆: %s（%s）", shift.Driver.CarModel, shift.Driver.Name))
+	}
+	if len(flights) > 0 {
+		desc = append(desc, "航班: "+strings.Join(flights, ", "))
+	}
+	return ical.Event{
+		UID:         fmt.Sprintf("shift-%d@pickup-scheduler", shift.ID),
+		Sequence:    shift.ScheduleSeq,
+		Start:       shift.DepartureTime,
+		End:         shiftEnd(shift.DepartureTime, shift.EstimatedMinutes),
+		Summary:     summary,
+		Location:    strings.Join(places, " / "),
+		Description: strings.Join(desc, "\n"),
+	}
+}
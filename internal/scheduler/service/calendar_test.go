@@ -0,0 +1,126 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"pickup/internal/config"
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/clause"
+)
+
+func TestCalendarService_FeedTokens(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewCalendarService(db, &config.JWTConfig{Secret: "secret"}, NewSchedulerLocation(&config.SchedulerConfig{Timezone: "America/Chicago"}))
+	now := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	token, err := svc.IssueFeed(7)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, "7."))
+	_, err = svc.Feed(token, now)
+	require.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	for _, forged := range []string{"", "7.x", parts[0] + "." + parts[1] + ".bad", "8." + parts[1] + "." + parts[2]} {
+		_, err = svc.Feed(forged, now)
+		assert.ErrorIs(t, err, ErrInvalidCalendarToken, forged)
+	}
+	other := NewCalendarService(db, &config.JWTConfig{Secret: "other"}, time.UTC)
+	_, err = other.Feed(token, now)
+	assert.ErrorIs(t, err, ErrInvalidCalendarToken)
+
+	// 重新生成后旧令牌失效
+	rotated, err := svc.IssueFeed(7)
+	require.NoError(t, err)
+	assert.NotEqual(t, token, rotated)
+	_, err = svc.Feed(token, now)
+	assert.ErrorIs(t, err, ErrInvalidCalendarToken)
+
+	require.NoError(t, svc.RevokeFeed(7))
+	_, err = svc.Feed(rotated, now)
+	assert.ErrorIs(t, err, ErrInvalidCalendarToken)
+}
+
+func TestCalendarService_FeedEvents(t *testing.T) {
+	db := newTestDB(t)
+	chicago := NewSchedulerLocation(&config.SchedulerConfig{Timezone: "America/Chicago"})
+	svc := NewCalendarService(db, &config.JWTConfig{Secret: "secret"}, chicago)
+	admin := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, chicago)
+	now := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	li := models.User{OpenID: "li", Name: "Li", Role: models.UserRoleStudent}
+	wang := models.User{OpenID: "wang", Name: "Wang", Role: models.UserRoleStudent}
+	staff := models.User{OpenID: "staff", Name: "s", Role: models.UserRoleStaff}
+	driverUser := models.User{OpenID: "drv", Name: "d", Role: models.UserRoleDriver}
+	for _, u := range []*models.User{&li, &wang, &staff, &driverUser} {
+		require.NoError(t, db.Create(u).Error)
+	}
+	driver := models.Driver{Name: "Zhang", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4, UserID: &driverUser.ID}
+	require.NoError(t, db.Create(&driver).Error)
+	departure, err := time.ParseInLocation("2006-01-02 15:04:05", "2026-08-20 14:30:00", chicago)
+	require.NoError(t, err)
+	published, err := admin.CreateShift(driver.ID, departure, 0)
+	require.NoError(t, err)
+	draft, err := admin.CreateShift(driver.ID, departure.Add(5*time.Hour), 0)
+	require.NoError(t, err)
+	for _, r := range []struct {
+		user   uint
+		flight string
+		shift  uint
+	}{{li.ID, "UA881", published.ID}, {wang.ID, "AA100", published.ID}, {li.ID, "DL7", draft.ID}} {
+		req := models.Request{UserID: r.user, FlightNo: r.flight, ArrivalDate: departure, Airport: "ORD", Terminal: "T5", Status: models.RequestStatusPublished}
+		require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+		require.NoError(t, db.Table("shift_requests").Create(map[string]any{"shift_id": r.shift, "request_id": req.ID}).Error)
+	}
+	require.NoError(t, db.Table("shift_staffs").Create(map[string]any{"shift_id": published.ID, "staff_id": staff.ID}).Error)
+	require.NoError(t, admin.PublishShift(published.ID))
+
+	feed := func(userID uint) string {
+		token, err := svc.IssueFeed(userID)
+		require.NoError(t, err)
+		body, err := svc.Feed(token, now)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	// 学生只看到自己的航班，草稿班次不出现
+	out := feed(li.ID)
+	assert.Equal(t, 1, strings.Count(out, "BEGIN:VEVENT"))
+	assert.Contains(t, out, "UID:shift-1@pickup-scheduler\r\nSEQUENCE:0\r\n")
+	assert.Contains(t, out, "DTSTART;TZID=America/Chicago:20260820T143000\r\n")
+	assert.Contains(t, out, "SUMMARY:接机 UA881\r\n")
+	assert.Contains(t, out, "LOCATION:ORD T5\r\n")
+	assert.Contains(t, out, `DESCRIPTION:车辆: SUV（Zhang）\n航班: UA881 ORD T5`+"\r\n")
+	assert.NotContains(t, out, "AA100")
+	assert.NotContains(t, out, "DL7")
+
+	for _, userID := range []uint{staff.ID, driverUser.ID} {
+		out = feed(userID)
+		assert.Contains(t, out, "SUMMARY:接机班次 #1\r\n")
+		assert.Contains(t, out, `航班: UA881 ORD T5\, AA100 ORD T5`)
+	}
+
+	// 改期后 UID 不变、SEQUENCE 递增
+	later := departure.Add(time.Hour)
+	_, err = admin.UpdateShift(published.ID, ShiftUpdateDTO{DepartureTime: &later})
+	require.NoError(t, err)
+	out = feed(li.ID)
+	assert.Contains(t, out, "UID:shift-1@pickup-scheduler\r\nSEQUENCE:1\r\n")
+	assert.Contains(t, out, "DTSTART;TZID=America/Chicago:20260820T153000\r\n")
+
+	minutes := 90
+	_, err = admin.UpdateShift(published.ID, ShiftUpdateDTO{EstimatedMinutes: &minutes})
+	require.NoError(t, err)
+	out = feed(li.ID)
+	assert.Contains(t, out, "SEQUENCE:2\r\n")
+	assert.Contains(t, out, "DTEND;TZID=America/Chicago:20260820T170000\r\n")
+	_, err = admin.UpdateShift(published.ID, ShiftUpdateDTO{EstimatedMinutes: &minutes})
+	require.NoError(t, err)
+	assert.Contains(t, feed(li.ID), "SEQUENCE:2\r\n")
+
+	assert.Equal(t, 0, strings.Count(feed(li.ID+100), "BEGIN:VEVENT"))
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCampaignNotFound       = errors.New("campaign not found")
+	ErrCampaignClosed         = errors.New("campaign is closed")
+	ErrCampaignMismatch       = errors.New("records belong to different campaigns")
+	ErrInvalidCampaign        = errors.New("campaign name is required and end_date must not be before start_date")
+	ErrArrivalOutsideCampaign = errors.New("arrival date is outside the active campaign")
+)
+
+type CampaignDTO struct {
+	Name      string
+	StartDate time.Time
+	EndDate   time.Time
+	// Status 为空时新建为 open、更新时保持不变。
+	Status models.CampaignStatus
+}
+
+func (d CampaignDTO) validate() error {
+	if strings.TrimSpace(d.Name) == "" || d.EndDate.Before(d.StartDate) {
+		return ErrInvalidCampaign
+	}
+	switch d.Status {
+	case "", models.CampaignStatusOpen, models.CampaignStatusClosed:
+		return nil
+	}
+	return errors.New("invalid campaign status")
+}
+
+// activeCampaign 返回当前活动；未设置活动时返回 nil，调用方按全局数据处理。
+func activeCampaign(db *gorm.DB) (*models.Campaign, error) {
+	var campaign models.Campaign
+	err := db.Where("active = ?", true).Order("id DESC").Limit(1).Find(&campaign).Error
+	if err != nil {
+		return nil, err
+	}
+	if campaign.ID == 0 {
+		return nil, nil
+	}
+	return &campaign, nil
+}
+
+// inCampaign 按活动过滤 column；campaignID 为 nil 时不过滤。
+func inCampaign(column string, campaignID *uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if campaignID == nil {
+			return db
+		}
+		return db.Where(column+" = ?", *campaignID)
+	}
+}
+
+// dateOnly 截掉时间部分，用于与 date 列比较。
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func sameCampaign(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// WithCampaign 返回限定在指定活动内查询的副本，覆盖默认的当前活动。
+func (s *AdminService) WithCampaign(campaignID uint) *AdminService {
+	clone := *s
+	clone.campaign = &campaignID
+	return &clone
+}
+
+// campaignScope 返回本次调用使用的活动 ID：显式指定优先，否则为当前活动，均无时为 nil。
+func (s *AdminService) campaignScope() (*uint, error) {
+	if s.campaign != nil {
+		return s.campaign, nil
+	}
+	campaign, err := activeCampaign(s.db)
+	if err != nil || campaign == nil {
+		return nil, err
+	}
+	return &campaign.ID, nil
+}
+
+func (s *AdminService) ListCampaigns() ([]models.Campaign, error) {
+	var items []models.Campaign
+	err := s.db.Order("start_date DESC, id DESC").Find(&items).Error
+	return items, err
+}
+
+// ActiveCampaign 未设置当前活动时返回 ErrCampaignNotFound。
+func (s *AdminService) ActiveCampaign() (*models.Campaign, error) {
+	campaign, err := activeCampaign(s.db)
+	if err != nil {
+		return nil, err
+	}
+	if campaign == nil {
+		return nil, ErrCampaignNotFound
+	}
+	return campaign, nil
+}
+
+func (s *AdminService) CreateCampaign(input CampaignDTO) (*models.Campaign, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+	campaign := models.Campaign{
+		Name:      strings.TrimSpace(input.Name),
+		StartDate: input.StartDate,
+		EndDate:   input.EndDate,
+		Status:    models.CampaignStatusOpen,
+	}
+	if input.Status != "" {
+		campaign.Status = input.Status
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&campaign).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, s.actor, "campaign.create", auditEntityCampaign, campaign.ID, nil, campaign)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// UpdateCampaign 关闭活动后学生不能再提交需求，已有数据不受影响。
+func (s *AdminService) UpdateCampaign(campaignID uint, input CampaignDTO) (*models.Campaign, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+	updates := map[string]any{
+		"name":       strings.TrimSpace(input.Name),
+		"start_date": input.StartDate,
+		"end_date":   input.EndDate,
+	}
+	if input.Status != "" {
+		updates["status"] = input.Status
+	}
+	var campaign models.Campaign
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Campaign
+		if err := tx.First(&before, campaignID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCampaignNotFound
+			}
+			return err
+		}
+		if err := tx.Model(&models.Campaign{}).Where("id = ?", campaignID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&campaign, campaignID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, s.actor, "campaign.update", auditEntityCampaign, campaignID, before, campaign)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// ActivateCampaign 将指定活动设为当前活动，其余活动同时取消激活。
+func (s *AdminService) ActivateCampaign(campaignID uint) (*models.Campaign, error) {
+	var campaign models.Campaign
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Campaign
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, campaignID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCampaignNotFound
+			}
+			return err
+		}
+		if err := tx.Model(&models.Campaign{}).Where("active = ? AND id <> ?", true, campaignID).Update("active", false).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Campaign{}).Where("id = ?", campaignID).Update("active", true).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&campaign, campaignID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, s.actor, "campaign.activate", auditEntityCampaign, campaignID, before, campaign)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
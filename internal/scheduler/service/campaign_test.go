@@ -0,0 +1,145 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminService_CampaignLifecycle(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC).WithActor(Actor{UserID: 1, Role: "admin"})
+
+	_, err := svc.ActiveCampaign()
+	assert.ErrorIs(t, err, ErrCampaignNotFound)
+
+	aug := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	_, err = svc.CreateCampaign(CampaignDTO{Name: " ", StartDate: aug, EndDate: aug})
+	assert.ErrorIs(t, err, ErrInvalidCampaign)
+	_, err = svc.CreateCampaign(CampaignDTO{Name: "Fall", StartDate: aug, EndDate: aug.AddDate(0, 0, -1)})
+	assert.ErrorIs(t, err, ErrInvalidCampaign)
+	_, err = svc.CreateCampaign(CampaignDTO{Name: "Fall", StartDate: aug, EndDate: aug, Status: "archived"})
+	assert.Error(t, err)
+
+	fall, err := svc.CreateCampaign(CampaignDTO{Name: "Fall 2026", StartDate: aug, EndDate: aug.AddDate(0, 1, 0)})
+	require.NoError(t, err)
+	assert.Equal(t, models.CampaignStatusOpen, fall.Status)
+	spring, err := svc.CreateCampaign(CampaignDTO{Name: "Spring 2027", StartDate: aug.AddDate(0, 5, 0), EndDate: aug.AddDate(0, 6, 0)})
+	require.NoError(t, err)
+
+	_, err = svc.ActivateCampaign(fall.ID)
+	require.NoError(t, err)
+	_, err = svc.ActivateCampaign(spring.ID)
+	require.NoError(t, err)
+	active, err := svc.ActiveCampaign()
+	require.NoError(t, err)
+	assert.Equal(t, spring.ID, active.ID)
+	var activeCount int64
+	require.NoError(t, db.Model(&models.Campaign{}).Where("active = ?", true).Count(&activeCount).Error)
+	assert.Equal(t, int64(1), activeCount)
+
+	closed, err := svc.UpdateCampaign(fall.ID, CampaignDTO{Name: "Fall 2026", StartDate: aug, EndDate: aug.AddDate(0, 1, 0), Status: models.CampaignStatusClosed})
+	require.NoError(t, err)
+	assert.Equal(t, models.CampaignStatusClosed, closed.Status)
+	_, err = svc.UpdateCampaign(999, CampaignDTO{Name: "x", StartDate: aug, EndDate: aug})
+	assert.ErrorIs(t, err, ErrCampaignNotFound)
+	_, err = svc.ActivateCampaign(999)
+	assert.ErrorIs(t, err, ErrCampaignNotFound)
+
+	items, err := svc.ListCampaigns()
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, spring.ID, items[0].ID)
+}
+
+func TestCampaignScoping(t *testing.T) {
+	db := newTestDB(t)
+	admin := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+	student := NewStudentService(db, nil, time.UTC)
+
+	// 未设置活动时沿用全局数据
+	legacy, err := student.CreateRequest(1, CreateRequestInput{FlightNo: "AA1", ArrivalDate: "2026-01-10", Terminal: "T1", ExpectedArrivalTime: "2026-01-10 10:00:00"})
+	require.NoError(t, err)
+	assert.Nil(t, legacy.CampaignID)
+	oldDriver, err := admin.CreateDriver(DriverDTO{Name: "old", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4})
+	require.NoError(t, err)
+
+	aug := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	fall, err := admin.CreateCampaign(CampaignDTO{Name: "Fall 2026", StartDate: aug, EndDate: aug.AddDate(0, 0, 30)})
+	require.NoError(t, err)
+	_, err = admin.ActivateCampaign(fall.ID)
+	require.NoError(t, err)
+
+	// 老生可在新活动中再次提交
+	_, err = student.CreateRequest(1, CreateRequestInput{FlightNo: "AA2", ArrivalDate: "2026-09-15", Terminal: "T1", ExpectedArrivalTime: "2026-09-15 10:00:00"})
+	assert.ErrorIs(t, err, ErrArrivalOutsideCampaign)
+	req, err := student.CreateRequest(1, CreateRequestInput{FlightNo: "AA2", ArrivalDate: "2026-08-20", Terminal: "T1", ExpectedArrivalTime: "2026-08-20 10:00:00"})
+	require.NoError(t, err)
+	require.NotNil(t, req.CampaignID)
+	assert.Equal(t, fall.ID, *req.CampaignID)
+	_, err = student.CreateRequest(1, CreateRequestInput{FlightNo: "AA3", ArrivalDate: "2026-08-21", Terminal: "T1", ExpectedArrivalTime: "2026-08-21 10:00:00"})
+	assert.ErrorContains(t, err, "user already has a request")
+
+	// 修改到达日期同样限制在活动范围内。
+	outside, inside := "2026-09-15", "2026-08-25"
+	_, err = student.UpdatePendingRequest(1, req.ID, UpdateRequestInput{ArrivalDate: &outside})
+	assert.ErrorIs(t, err, ErrArrivalOutsideCampaign)
+	req, err = student.UpdatePendingRequest(1, req.ID, UpdateRequestInput{ArrivalDate: &inside})
+	require.NoError(t, err)
+	assert.Equal(t, "2026-08-25", req.ArrivalDate.Format("2006-01-02"))
+
+	mine, err := student.ListMyRequests(1)
+	require.NoError(t, err)
+	require.Len(t, mine, 1)
+	assert.Equal(t, req.ID, mine[0].ID)
+
+	pending, err := admin.PendingRequests(RequestFilter{})
+	require.NoError(t, err)
+	require.Len(t, pending.Items, 1)
+	assert.Equal(t, req.ID, pending.Items[0].ID)
+
+	driver, err := admin.CreateDriver(DriverDTO{Name: "new", CarModel: "Van", MaxSeats: 6, MaxChecked: 6, MaxCarryOn: 6})
+	require.NoError(t, err)
+	drivers, err := admin.ListDrivers(false)
+	require.NoError(t, err)
+	require.Len(t, drivers, 1)
+	assert.Equal(t, driver.ID, drivers[0].ID)
+
+	_, err = admin.CreateShift(oldDriver.ID, aug, 0)
+	assert.ErrorIs(t, err, ErrCampaignMismatch)
+	_, err = admin.CreateShift(999, aug, 0)
+	assert.ErrorIs(t, err, ErrDriverNotFound)
+	shift, err := admin.CreateShift(driver.ID, aug.AddDate(0, 0, 19), 0)
+	require.NoError(t, err)
+	require.NotNil(t, shift.CampaignID)
+	_, err = admin.UpdateShift(shift.ID, ShiftUpdateDTO{DriverID: &oldDriver.ID})
+	assert.ErrorIs(t, err, ErrCampaignMismatch)
+
+	_, err = admin.AssignStudent(shift.ID, legacy.ID, "")
+	assert.ErrorIs(t, err, ErrCampaignMismatch)
+	_, err = admin.AssignStudent(shift.ID, req.ID, "")
+	require.NoError(t, err)
+
+	dashboard, err := admin.DashboardShifts(ShiftFilter{})
+	require.NoError(t, err)
+	assert.Len(t, dashboard.Items, 1)
+
+	// 显式指定其他活动
+	other := admin.WithCampaign(fall.ID + 1)
+	pending, err = other.PendingRequests(RequestFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, pending.Items)
+	dashboard, err = other.DashboardShifts(ShiftFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, dashboard.Items)
+
+	// 关闭后不再接受提交
+	_, err = admin.UpdateCampaign(fall.ID, CampaignDTO{Name: fall.Name, StartDate: fall.StartDate, EndDate: fall.EndDate, Status: models.CampaignStatusClosed})
+	require.NoError(t, err)
+	_, err = student.CreateRequest(2, CreateRequestInput{FlightNo: "AA4", ArrivalDate: "2026-08-20", Terminal: "T1", ExpectedArrivalTime: "2026-08-20 10:00:00"})
+	assert.ErrorIs(t, err, ErrCampaignClosed)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"errors"
+
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+)
+
+// shiftUsage 班次当前占用：需求按乘车人数占座，每位随车志愿者占一个座位。
+type shiftUsage struct {
+	Passengers int
+	Staff      int
+	Checked    int
+	CarryOn    int
+}
+
+func (u shiftUsage) seats() int {
+	return u.Passengers + u.Staff
+}
+
+// loadShiftUsage 按班次聚合已绑定乘客数、行李数与志愿者数，分配校验与容量展示共用。
+func loadShiftUsage(db *gorm.DB, shiftIDs []uint) (map[uint]shiftUsage, error) {
+	usage := make(map[uint]shiftUsage, len(shiftIDs))
+	if len(shiftIDs) == 0 {
+		return usage, nil
+	}
+
+	type requestAggregate struct {
+		ShiftID    uint
+		Passengers int
+		Checked    int
+		CarryOn    int
+	}
+	var requestRows []requestAggregate
+	if err := db.Table("shift_requests sr").
+		Select("sr.shift_id, COALESCE(SUM(r.passengers), 0) AS passengers, COALESCE(SUM(r.checked_bags), 0) AS checked, COALESCE(SUM(r.carry_on_bags), 0) AS carry_on").
+		Joins("JOIN requests r ON r.id = sr.request_id").
+		Where("sr.shift_id IN ?", shiftIDs).
+		Group("sr.shift_id").
+		Scan(&requestRows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range requestRows {
+		u := usage[row.ShiftID]
+		u.Passengers, u.Checked, u.CarryOn = row.Passengers, row.Checked, row.CarryOn
+		usage[row.ShiftID] = u
+	}
+
+	type staffAggregate struct {
+		ShiftID uint
+		Staff   int
+	}
+	var staffRows []staffAggregate
+	if err := db.Table("shift_staffs").
+		Select("shift_id, COUNT(*) AS staff").
+		Where("shift_id IN ?", shiftIDs).
+		Group("shift_id").
+		Scan(&staffRows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range staffRows {
+		u := usage[row.ShiftID]
+		u.Staff = row.Staff
+		usage[row.ShiftID] = u
+	}
+	return usage, nil
+}
+
+func buildShiftCapacity(shiftID uint, driver *models.Driver, u shiftUsage) *models.ShiftCapacity {
+	var limits models.Driver
+	if driver != nil {
+		limits = *driver
+	}
+	capacity := &models.ShiftCapacity{
+		ShiftID:     shiftID,
+		Seats:       models.NewCapacityMetric(u.seats(), limits.MaxSeats),
+		CheckedBags: models.NewCapacityMetric(u.Checked, limits.MaxChecked),
+		CarryOnBags: models.NewCapacityMetric(u.CarryOn, limits.MaxCarryOn),
+	}
+	capacity.Overloaded = u.seats() > limits.MaxSeats || u.Checked > limits.MaxChecked || u.CarryOn > limits.MaxCarryOn
+	return capacity
+}
+
+// attachShiftCapacity 为看板班次填充容量汇总，需已预加载 Driver。
+func attachShiftCapacity(db *gorm.DB, shifts []models.Shift) error {
+	ids := make([]uint, 0, len(shifts))
+	for _, shift := range shifts {
+		ids = append(ids, shift.ID)
+	}
+	usage, err := loadShiftUsage(db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range shifts {
+		shifts[i].Capacity = buildShiftCapacity(shifts[i].ID, shifts[i].Driver, usage[shifts[i].ID])
+	}
+	return nil
+}
+
+// ShiftCapacity 返回班次已用/剩余座位与行李容量。
+func (s *AdminService) ShiftCapacity(shiftID uint) (*models.ShiftCapacity, error) {
+	var shift models.Shift
+	if err := s.db.Preload("Driver").First(&shift, shiftID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShiftNotFound
+		}
+		return nil, err
+	}
+	usage, err := loadShiftUsage(s.db, []uint{shiftID})
+	if err != nil {
+		return nil, err
+	}
+	return buildShiftCapacity(shiftID, shift.Driver, usage[shiftID]), nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/clause"
+)
+
+func TestAdminService_ShiftCapacity(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+
+	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 3, MaxChecked: 2, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	shift := models.Shift{DriverID: driver.ID, DepartureTime: time.Now(), Status: models.ShiftStatusDraft}
+	require.NoError(t, db.Create(&shift).Error)
+	empty := models.Shift{DriverID: driver.ID, DepartureTime: time.Now(), Status: models.ShiftStatusDraft}
+	require.NoError(t, db.Create(&empty).Error)
+
+	staff := models.User{OpenID: "staff", Name: "s", Role: models.UserRoleStaff}
+	require.NoError(t, db.Create(&staff).Error)
+	require.NoError(t, db.Table("shift_staffs").Create(map[string]any{"shift_id": shift.ID, "staff_id": staff.ID}).Error)
+	for i, bags := range []int{2, 1} {
+		req := models.Request{UserID: uint(i + 1), FlightNo: "AA1", ArrivalDate: time.Now(), Terminal: "T1", Status: models.RequestStatusPending, CheckedBags: bags, CarryOnBags: 1}
+		require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+		_, err := svc.AssignStudent(shift.ID, req.ID, "")
+		require.NoError(t, err)
+	}
+
+	capacity, err := svc.ShiftCapacity(shift.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.CapacityMetric{Used: 3, Limit: 3, Remaining: 0}, capacity.Seats)
+	assert.Equal(t, models.CapacityMetric{Used: 3, Limit: 2, Remaining: 0}, capacity.CheckedBags)
+	assert.Equal(t, models.CapacityMetric{Used: 2, Limit: 4, Remaining: 2}, capacity.CarryOnBags)
+	assert.True(t, capacity.Overloaded)
+
+	_, err = svc.ShiftCapacity(999)
+	assert.ErrorIs(t, err, ErrShiftNotFound)
+
+	dashboard, err := svc.DashboardShifts(ShiftFilter{})
+	require.NoError(t, err)
+	require.Len(t, dashboard.Items, 2)
+	for _, item := range dashboard.Items {
+		require.NotNil(t, item.Capacity)
+		if item.ID == shift.ID {
+			assert.Equal(t, *capacity, *item.Capacity)
+		} else {
+			assert.Equal(t, models.CapacityMetric{Used: 0, Limit: 3, Remaining: 3}, item.Capacity.Seats)
+			assert.False(t, item.Capacity.Overloaded)
+		}
+	}
+}
+
+func TestPassengersCountTowardSeats(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC).WithActor(Actor{UserID: 1, Role: "admin"})
+	student := NewStudentService(db, nil, time.UTC)
+
+	driver := models.Driver{Name: "d", CarModel: "Van", MaxSeats: 4, MaxChecked: 6, MaxCarryOn: 6}
+	require.NoError(t, db.Create(&driver).Error)
+	shift := models.Shift{DriverID: driver.ID, DepartureTime: time.Now(), Status: models.ShiftStatusDraft}
+	require.NoError(t, db.Create(&shift).Error)
+
+	family, err := student.CreateRequest(1, CreateRequestInput{FlightNo: "AA1", ArrivalDate: "2026-03-01", Terminal: "T1", Passengers: 3, ExpectedArrivalTime: "2026-03-01 10:00:00"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, family.Passengers)
+	solo, err := student.CreateRequest(2, CreateRequestInput{FlightNo: "AA1", ArrivalDate: "2026-03-01", Terminal: "T1", ExpectedArrivalTime: "2026-03-01 10:00:00"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, solo.Passengers)
+
+	zero := 0
+	_, err = student.UpdatePendingRequest(2, solo.ID, UpdateRequestInput{Passengers: &zero})
+	assert.ErrorIs(t, err, ErrInvalidPassengers)
+
+	_, err = svc.AssignStudent(shift.ID, family.ID, "")
+	require.NoError(t, err)
+	result, err := svc.AssignStudent(shift.ID, solo.ID, "")
+	require.NoError(t, err)
+	assert.Empty(t, result.Warning)
+
+	capacity, err := svc.ShiftCapacity(shift.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.CapacityMetric{Used: 4, Limit: 4, Remaining: 0}, capacity.Seats)
+
+	// 管理员加人后超载：默认策略给出警告，reject 策略拒绝
+	two := 2
+	updated, err := svc.UpdateRequest(solo.ID, RequestUpdateDTO{Passengers: &two})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Request.Passengers)
+	assert.Equal(t, "capacity_overload", updated.Warning)
+
+	reject := models.CapacityPolicyReject
+	_, err = svc.UpdateShift(shift.ID, ShiftUpdateDTO{CapacityPolicy: &reject})
+	require.NoError(t, err)
+	three := 3
+	_, err = svc.UpdateRequest(solo.ID, RequestUpdateDTO{Passengers: &three})
+	var capErr *CapacityExceededError
+	require.ErrorAs(t, err, &capErr)
+	assert.Equal(t, 6, capErr.Seats)
+
+	_, err = svc.UpdateRequest(solo.ID, RequestUpdateDTO{Passengers: &zero})
+	assert.ErrorIs(t, err, ErrInvalidPassengers)
+	_, err = svc.UpdateRequest(999, RequestUpdateDTO{Passengers: &two})
+	assert.ErrorIs(t, err, ErrRequestNotFound)
+	_, err = svc.UpdateRequest(solo.ID, RequestUpdateDTO{})
+	assert.Error(t, err)
+
+	var actions []string
+	require.NoError(t, db.Model(&models.AuditLog{}).Where("entity_type = ? AND entity_id = ?", auditEntityRequest, solo.ID).Order("id").Pluck("action", &actions).Error)
+	assert.Contains(t, actions, "request.update")
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidShiftStatus = errors.New("invalid shift status")
+
+// activeShiftStatuses 看板默认展示的未结束班次状态。
+var activeShiftStatuses = []models.ShiftStatus{models.ShiftStatusDraft, models.ShiftStatusPublished, models.ShiftStatusInProgress}
+
+var (
+	shiftSortColumns   = map[string]string{"departure_time": "departure_time"}
+	requestSortColumns = map[string]string{"arrival_date": "arrival_date"}
+)
+
+// ShiftFilter 看板查询条件，零值字段不参与过滤。From/To 限定出发时间 [From, To)；
+// Statuses 为空时只看未结束班次；Terminal/FlightNo 匹配班次内任一乘客的需求。
+type ShiftFilter struct {
+	From     *time.Time
+	To       *time.Time
+	Statuses []models.ShiftStatus
+	DriverID uint
+	Terminal string
+	FlightNo string
+	PageQuery
+}
+
+func (f ShiftFilter) apply(query *gorm.DB) (*gorm.DB, error) {
+	statuses := f.Statuses
+	if len(statuses) == 0 {
+		statuses = activeShiftStatuses
+	}
+	for _, status := range statuses {
+		switch status {
+		case models.ShiftStatusDraft, models.ShiftStatusPublished, models.ShiftStatusInProgress,
+			models.ShiftStatusCompleted, models.ShiftStatusCanceled:
+		default:
+			return nil, ErrInvalidShiftStatus
+		}
+	}
+	query = query.Where("shifts.status IN ?", statuses)
+	if f.From != nil {
+		query = query.Where("shifts.departure_time >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("shifts.departure_time < ?", *f.To)
+	}
+	if f.DriverID != 0 {
+		query = query.Where("shifts.driver_id = ?", f.DriverID)
+	}
+	if terminal := strings.TrimSpace(f.Terminal); terminal != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM shift_requests sr JOIN requests r ON r.id = sr.request_id WHERE sr.shift_id = shifts.id AND r.terminal = ?)", terminal)
+	}
+	if flight := flightPattern(f.FlightNo); flight != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM shift_requests sr JOIN requests r ON r.id = sr.request_id WHERE sr.shift_id = shifts.id AND UPPER(r.flight_no) LIKE ?)", flight)
+	}
+	return query, nil
+}
+
+// RequestFilter 待分配需求查询条件，零值字段不参与过滤。到达日期按天存储，From/To 先换算到调度时区的日期：
+// 包含 From 所在日；To 为开区间上界，零点之后的 To 所在日整天计入，恰为零点时不含该日。
+type RequestFilter struct {
+	From     *time.Time
+	To       *time.Time
+	Airport  string
+	Terminal string
+	FlightNo string
+	PageQuery
+}
+
+func (f RequestFilter) apply(query *gorm.DB, loc *time.Location) *gorm.DB {
+	if f.From != nil {
+		query = query.Where("requests.arrival_date >= ?", dateOnly(startOfDay(*f.From, loc)))
+	}
+	if f.To != nil {
+		end := startOfDay(*f.To, loc)
+		if end.Before(*f.To) {
+			end = end.AddDate(0, 0, 1)
+		}
+		query = query.Where("requests.arrival_date < ?", dateOnly(end))
+	}
+	if airport := strings.TrimSpace(f.Airport); airport != "" {
+		query = query.Where("requests.airport = ?", strings.ToUpper(airport))
+	}
+	if terminal := strings.TrimSpace(f.Terminal); terminal != "" {
+		query = query.Where("requests.terminal = ?", terminal)
+	}
+	if flight := flightPattern(f.FlightNo); flight != "" {
+		query = query.Where("UPPER(requests.flight_no) LIKE ?", flight)
+	}
+	return query
+}
+
+// flightPattern 航班号按不区分大小写的包含匹配，只保留字母数字，避免 LIKE 通配符。
+func flightPattern(flightNo string) string {
+	flightNo = strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, strings.ToUpper(flightNo))
+	if flightNo == "" {
+		return ""
+	}
+	return "%" + flightNo + "%"
+}
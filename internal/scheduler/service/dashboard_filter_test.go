@@ -0,0 +1,163 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pickup/internal/config"
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/clause"
+)
+
+func TestAdminService_DashboardFiltersAndPaging(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+
+	suv := models.Driver{Name: "suv", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+	van := models.Driver{Name: "van", CarModel: "Van", MaxSeats: 6, MaxChecked: 6, MaxCarryOn: 6}
+	require.NoError(t, db.Create(&suv).Error)
+	require.NoError(t, db.Create(&van).Error)
+	day := time.Date(2026, 8, 20, 0, 0, 0, 0, time.UTC)
+	shifts := []models.Shift{
+		{DriverID: suv.ID, DepartureTime: day.Add(10 * time.Hour), Status: models.ShiftStatusDraft},
+		{DriverID: van.ID, DepartureTime: day.Add(10 * time.Hour), Status: models.ShiftStatusPublished},
+		{DriverID: suv.ID, DepartureTime: day.Add(14 * time.Hour), Status: models.ShiftStatusInProgress},
+		{DriverID: van.ID, DepartureTime: day.AddDate(0, 0, 1), Status: models.ShiftStatusDraft},
+		{DriverID: suv.ID, DepartureTime: day.Add(-24 * time.Hour), Status: models.ShiftStatusCompleted},
+	}
+	for i := range shifts {
+		require.NoError(t, db.Create(&shifts[i]).Error)
+	}
+	req := models.Request{UserID: 1, FlightNo: "UA881", ArrivalDate: day, Terminal: "T5", Status: models.RequestStatusAssigned}
+	require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+	require.NoError(t, db.Table("shift_requests").Create(map[string]any{"shift_id": shifts[1].ID, "request_id": req.ID}).Error)
+
+	// 默认只看未结束班次，按出发时间、id 升序
+	var ids []uint
+	filter := ShiftFilter{PageQuery: PageQuery{Limit: 2}}
+	for {
+		page, err := svc.DashboardShifts(filter)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), page.Total)
+		for _, shift := range page.Items {
+			ids = append(ids, shift.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []uint{shifts[0].ID, shifts[1].ID, shifts[2].ID, shifts[3].ID}, ids)
+
+	page, err := svc.DashboardShifts(ShiftFilter{PageQuery: PageQuery{Sort: "-departure_time", Limit: 1}})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, shifts[3].ID, page.Items[0].ID)
+	_, err = svc.DashboardShifts(ShiftFilter{PageQuery: PageQuery{Sort: "departure_time", Cursor: page.NextCursor}})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	next, err := svc.DashboardShifts(ShiftFilter{PageQuery: PageQuery{Sort: "-departure_time", Limit: 1, Cursor: page.NextCursor}})
+	require.NoError(t, err)
+	assert.Equal(t, shifts[2].ID, next.Items[0].ID)
+
+	to := day.AddDate(0, 0, 1)
+	page, err = svc.DashboardShifts(ShiftFilter{From: &day, To: &to, DriverID: suv.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), page.Total)
+	page, err = svc.DashboardShifts(ShiftFilter{Statuses: []models.ShiftStatus{models.ShiftStatusCompleted}})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, shifts[4].ID, page.Items[0].ID)
+	page, err = svc.DashboardShifts(ShiftFilter{Terminal: "T5", FlightNo: "ua88"})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, shifts[1].ID, page.Items[0].ID)
+	require.Len(t, page.Items[0].Requests, 1)
+
+	_, err = svc.DashboardShifts(ShiftFilter{Statuses: []models.ShiftStatus{"parked"}})
+	assert.ErrorIs(t, err, ErrInvalidShiftStatus)
+	_, err = svc.DashboardShifts(ShiftFilter{PageQuery: PageQuery{Sort: "driver"}})
+	assert.ErrorIs(t, err, ErrInvalidSort)
+	_, err = svc.DashboardShifts(ShiftFilter{PageQuery: PageQuery{Limit: MaxPageLimit + 1}})
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+	_, err = svc.DashboardShifts(ShiftFilter{PageQuery: PageQuery{Cursor: "%%"}})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestAdminService_PendingRequestsFiltersAndPaging(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+
+	day := time.Date(2026, 8, 20, 0, 0, 0, 0, time.UTC)
+	reqs := []models.Request{
+		{UserID: 1, FlightNo: "UA881", ArrivalDate: day, Airport: "ORD", Terminal: "T1", Status: models.RequestStatusPending},
+		{UserID: 2, FlightNo: "AA100", ArrivalDate: day, Airport: "ORD", Terminal: "T5", Status: models.RequestStatusPending},
+		{UserID: 3, FlightNo: "DL7", ArrivalDate: day.AddDate(0, 0, 1), Airport: "CMI", Terminal: "T1", Status: models.RequestStatusPending},
+		{UserID: 4, FlightNo: "UA882", ArrivalDate: day, Airport: "ORD", Terminal: "T1", Status: models.RequestStatusAssigned},
+	}
+	for i := range reqs {
+		require.NoError(t, db.Omit(clause.Associations).Create(&reqs[i]).Error)
+	}
+
+	page, err := svc.PendingRequests(RequestFilter{PageQuery: PageQuery{Limit: 2}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, reqs[0].ID, page.Items[0].ID)
+	require.NotEmpty(t, page.NextCursor)
+	page, err = svc.PendingRequests(RequestFilter{PageQuery: PageQuery{Limit: 2, Cursor: page.NextCursor}})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, reqs[2].ID, page.Items[0].ID)
+	assert.Empty(t, page.NextCursor)
+
+	page, err = svc.PendingRequests(RequestFilter{PageQuery: PageQuery{Sort: "-id"}})
+	require.NoError(t, err)
+	assert.Equal(t, reqs[2].ID, page.Items[0].ID)
+
+	to := day.AddDate(0, 0, 1)
+	page, err = svc.PendingRequests(RequestFilter{From: &day, To: &to, Terminal: "T1"})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, reqs[0].ID, page.Items[0].ID)
+	page, err = svc.PendingRequests(RequestFilter{Airport: "cmi"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), page.Total)
+	page, err = svc.PendingRequests(RequestFilter{FlightNo: "ua"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), page.Total)
+}
+
+func TestAdminService_PendingRequestsDateBounds(t *testing.T) {
+	db := newTestDB(t)
+	chicago := NewSchedulerLocation(&config.SchedulerConfig{Timezone: "America/Chicago"})
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, chicago)
+
+	day := time.Date(2026, 8, 20, 0, 0, 0, 0, time.UTC)
+	var ids []uint
+	for i, date := range []time.Time{day.AddDate(0, 0, -1), day, day.AddDate(0, 0, 1)} {
+		req := models.Request{UserID: uint(i + 1), FlightNo: "UA881", ArrivalDate: date, Terminal: "T1", Status: models.RequestStatusPending}
+		require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+		ids = append(ids, req.ID)
+	}
+	between := func(from, to time.Time) []uint {
+		t.Helper()
+		page, err := svc.PendingRequests(RequestFilter{From: &from, To: &to})
+		require.NoError(t, err)
+		got := make([]uint, 0, len(page.Items))
+		for _, req := range page.Items {
+			got = append(got, req.ID)
+		}
+		return got
+	}
+
+	// ?from=2026-08-20&to=2026-08-20：To 为芝加哥次日零点，恰好不含 21 日。
+	midnight := time.Date(2026, 8, 20, 0, 0, 0, 0, chicago)
+	assert.Equal(t, []uint{ids[1]}, between(midnight, midnight.AddDate(0, 0, 1)))
+	// RFC3339 边界同样先换算到芝加哥：UTC 21 日 03:00 是芝加哥 20 日，To 所在日整天计入。
+	utcNight := time.Date(2026, 8, 21, 3, 0, 0, 0, time.UTC)
+	assert.Equal(t, []uint{ids[1]}, between(utcNight, utcNight))
+	assert.Equal(t, []uint{ids[0], ids[1]}, between(midnight.Add(-time.Hour), utcNight))
+}
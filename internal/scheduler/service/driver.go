@@ -0,0 +1,408 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDriverNotLinked     = errors.New("user is not linked to a driver")
+	ErrDriverAlreadyLinked = errors.New("user is already linked to another driver")
+	ErrShiftNotForDriver   = errors.New("shift is not assigned to this driver")
+	ErrShiftNotRespondable = errors.New("only published shifts can be accepted or declined")
+	ErrUserNotLinkable     = errors.New("only student or driver accounts can be linked to a driver")
+	ErrDriverHasUser       = errors.New("driver is already linked to a user; unlink first")
+	ErrDriverNoteTooLong   = errors.New("reason must be at most 255 characters")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrDriverArchived      = errors.New("driver is archived")
+	ErrDriverHasShifts     = errors.New("driver still has draft, published or in-progress shifts")
+)
+
+// rosterShiftStatuses 司机端与志愿者端可见的班次状态；草稿班次仍可能调整，不对外展示。
+var rosterShiftStatuses = []models.ShiftStatus{models.ShiftStatusPublished, models.ShiftStatusInProgress}
+
+// ManifestEntry 班次乘客名单中的一行，供司机与导出使用。
+type ManifestEntry struct {
+	ShiftID        uint                  `json:"-"`
+	RequestID      uint                  `json:"request_id"`
+	Name           string                `json:"name"`
+	Phone          string                `json:"phone"`
+	FlightNo       string                `json:"flight_no"`
+	Airport        string                `json:"airport"`
+	Terminal       string                `json:"terminal"`
+	Passengers     int                   `json:"passengers"`
+	CheckedBags    int                   `json:"checked_bags"`
+	CarryOnBags    int                   `json:"carry_on_bags"`
+	CalcPickupTime *time.Time            `json:"calc_pickup_time,omitempty"`
+	BoardingStatus models.BoardingStatus `json:"boarding_status"`
+}
+
+// ShiftWithManifest 司机与志愿者视角的班次，附带乘客名单。
+type ShiftWithManifest struct {
+	models.Shift
+	Manifest []ManifestEntry `json:"manifest"`
+}
+
+// loadManifests 按班次读取乘客名单，按预计接机时间排序。
+func loadManifests(db *gorm.DB, shiftIDs []uint) (map[uint][]ManifestEntry, error) {
+	result := make(map[uint][]ManifestEntry, len(shiftIDs))
+	if len(shiftIDs) == 0 {
+		return result, nil
+	}
+	var rows []ManifestEntry
+	err := db.Table("shift_requests sr").
+		Select("sr.shift_id, sr.request_id, u.name, u.phone, r.flight_no, r.airport, r.terminal, r.passengers, r.checked_bags, r.carry_on_bags, r.calc_pickup_time, sr.boarding_status").
+		Joins("JOIN requests r ON r.id = sr.request_id").
+		Joins("JOIN users u ON u.id = r.user_id").
+		Where("sr.shift_id IN ?", shiftIDs).
+		Order("r.calc_pickup_time ASC, sr.request_id ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.ShiftID] = append(result[row.ShiftID], row)
+	}
+	return result, nil
+}
+
+// withManifests 为班次附上乘客名单，无乘客时为空数组；maskPhones 时手机号按 MaskPhone 脱敏。
+func withManifests(db *gorm.DB, shifts []models.Shift, maskPhones bool) ([]ShiftWithManifest, error) {
+	ids := make([]uint, 0, len(shifts))
+	for _, shift := range shifts {
+		ids = append(ids, shift.ID)
+	}
+	manifests, err := loadManifests(db, ids)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]ShiftWithManifest, 0, len(shifts))
+	for _, shift := range shifts {
+		manifest := manifests[shift.ID]
+		if manifest == nil {
+			manifest = make([]ManifestEntry, 0)
+		}
+		if maskPhones {
+			for i := range manifest {
+				manifest[i].Phone = MaskPhone(manifest[i].Phone)
+			}
+		}
+		items = append(items, ShiftWithManifest{Shift: shift, Manifest: manifest})
+	}
+	return items, nil
+}
+
+type DriverService struct {
+	db    *gorm.DB
+	actor Actor
+}
+
+func NewDriverService(db *gorm.DB) *DriverService {
+	return &DriverService{db: db}
+}
+
+// WithActor 返回绑定操作者的服务副本，用于审计。
+func (s *DriverService) WithActor(actor Actor) *DriverService {
+	clone := *s
+	clone.actor = actor
+	return &clone
+}
+
+// driverForUser 返回登录账号关联的司机记录。
+func (s *DriverService) driverForUser(userID uint) (*models.Driver, error) {
+	var driver models.Driver
+	if err := s.db.Where("user_id = ?", userID).First(&driver).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDriverNotLinked
+		}
+		return nil, err
+	}
+	return &driver, nil
+}
+
+// MyShifts 返回司机已发布或进行中的班次；草稿班次对司机不可见。
+func (s *DriverService) MyShifts(userID uint) ([]ShiftWithManifest, error) {
+	driver, err := s.driverForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	var shifts []models.Shift
+	err = s.db.Where("driver_id = ? AND status IN ?", driver.ID, rosterShiftStatuses).
+		Preload("Staffs").
+		Order("departure_time ASC, id ASC").
+		Find(&shifts).Error
+	if err != nil {
+		return nil, err
+	}
+	return withManifests(s.db, shifts, false)
+}
+
+func (s *DriverService) MyShift(userID, shiftID uint) (*ShiftWithManifest, error) {
+	driver, err := s.driverForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	var shift models.Shift
+	err = s.db.Where("driver_id = ? AND status IN ?", driver.ID, rosterShiftStatuses).
+		Preload("Staffs").
+		First(&shift, shiftID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShiftNotForDriver
+		}
+		return nil, err
+	}
+	items, err := withManifests(s.db, []models.Shift{shift}, false)
+	if err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// Respond 司机接受或拒绝已发布的班次；拒绝后由管理员改派，改派后状态重置为 pending。
+func (s *DriverService) Respond(userID, shiftID uint, accept bool, note string) (*models.Shift, error) {
+	note = strings.TrimSpace(note)
+	if len([]rune(note)) > 255 {
+		return nil, ErrDriverNoteTooLong
+	}
+	driver, err := s.driverForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	status, action := models.ShiftDriverStatusDeclined, "shift.driver_decline"
+	if accept {
+		status, action = models.ShiftDriverStatusAccepted, "shift.driver_accept"
+	}
+
+	var shift models.Shift
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Shift
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, shiftID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShiftNotFound
+			}
+			return err
+		}
+		if before.DriverID != driver.ID {
+			return ErrShiftNotForDriver
+		}
+		if before.Status != models.ShiftStatusPublished {
+			return ErrShiftNotRespondable
+		}
+		now := time.Now()
+		updates := map[string]any{"driver_status": status, "driver_responded_at": now, "driver_note": note, "version": bumpVersion}
+		if err := tx.Model(&models.Shift{}).Where("id = ?", shiftID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&shift, shiftID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, s.actor, action, auditEntityShift, shiftID,
+			map[string]any{"driver_status": before.DriverStatus},
+			map[string]any{"driver_status": status, "note": note})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// MyAvailability 司机查看自己的全部时间窗，包括管理员录入的。
+func (s *DriverService) MyAvailability(userID uint) ([]models.DriverAvailability, error) {
+	driver, err := s.driverForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	var items []models.DriverAvailability
+	err = s.db.Where("driver_id = ?", driver.ID).Order("start_time ASC").Find(&items).Error
+	return items, err
+}
+
+// CreateMyAvailability 司机自报时间窗，来源记为 self。
+func (s *DriverService) CreateMyAvailability(userID uint, input AvailabilityDTO) (*models.DriverAvailability, error) {
+	driver, err := s.driverForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return createDriverAvailability(s.db, s.actor, driver.ID, input, models.AvailabilitySourceSelf)
+}
+
+// DeleteMyAvailability 司机只能删除自报的时间窗，管理员录入的需由管理员修改。
+func (s *DriverService) DeleteMyAvailability(userID, windowID uint) error {
+	driver, err := s.driverForUser(userID)
+	if err != nil {
+		return err
+	}
+	return deleteDriverAvailability(s.db, s.actor, driver.ID, windowID, true)
+}
+
+// LinkDriverUser 将司机记录关联到登录账号，并把学生账号切换为 driver 角色。
+func (s *AdminService) LinkDriverUser(driverID, userID uint) (*models.Driver, error) {
+	var driver models.Driver
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&driver, driverID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDriverNotFound
+			}
+			return err
+		}
+		if driver.ArchivedAt != nil {
+			return ErrDriverArchived
+		}
+		if driver.UserID != nil && *driver.UserID != userID {
+			return ErrDriverHasUser
+		}
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		if user.Role != models.UserRoleStudent && user.Role != models.UserRoleDriver {
+			return ErrUserNotLinkable
+		}
+		var linked int64
+		if err := tx.Model(&models.Driver{}).Where("user_id = ? AND id <> ?", userID, driverID).Count(&linked).Error; err != nil {
+			return err
+		}
+		if linked > 0 {
+			return ErrDriverAlreadyLinked
+		}
+
+		before := driver.UserID
+		if err := tx.Model(&models.Driver{}).Where("id = ?", driverID).
+			Updates(map[string]any{"user_id": userID, "version": bumpVersion}).Error; err != nil {
+			return err
+		}
+		driver.UserID = &userID
+		driver.Version++
+		if user.Role != models.UserRoleDriver {
+			if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("role", models.UserRoleDriver).Error; err != nil {
+				return err
+			}
+			if err := recordAudit(tx, s.actor, "user.set_driver", auditEntityUser, userID,
+				map[string]any{"role": user.Role}, map[string]any{"role": models.UserRoleDriver}); err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, s.actor, "driver.link_user", auditEntityDriver, driverID,
+			map[string]any{"user_id": before}, map[string]any{"user_id": userID})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &driver, nil
+}
+
+// UnlinkDriverUser 解除关联，driver 角色的账号恢复为学生。
+func (s *AdminService) UnlinkDriverUser(driverID uint) (*models.Driver, error) {
+	var driver models.Driver
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&driver, driverID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDriverNotFound
+			}
+			return err
+		}
+		if driver.UserID == nil {
+			return nil
+		}
+		userID := *driver.UserID
+		if err := tx.Model(&models.Driver{}).Where("id = ?", driverID).
+			Updates(map[string]any{"user_id": nil, "version": bumpVersion}).Error; err != nil {
+			return err
+		}
+		driver.UserID = nil
+		driver.Version++
+		res := tx.Model(&models.User{}).Where("id = ? AND role = ?", userID, models.UserRoleDriver).Update("role", models.UserRoleStudent)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			if err := recordAudit(tx, s.actor, "user.unset_driver", auditEntityUser, userID,
+				map[string]any{"role": models.UserRoleDriver}, map[string]any{"role": models.UserRoleStudent}); err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, s.actor, "driver.unlink_user", auditEntityDriver, driverID,
+			map[string]any{"user_id": userID}, map[string]any{"user_id": nil})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &driver, nil
+}
+
+// ArchiveDriver 归档一次性司机：仍有未结束班次时拒绝，已完成或已取消的历史班次保留不变。
+func (s *AdminService) ArchiveDriver(driverID uint) (*models.Driver, error) {
+	var driver models.Driver
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&driver, driverID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDriverNotFound
+			}
+			return err
+		}
+		if driver.ArchivedAt != nil {
+			return nil
+		}
+		var active int64
+		if err := tx.Model(&models.Shift{}).
+			Where("driver_id = ? AND status IN ?", driverID, busyShiftStatuses).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return ErrDriverHasShifts
+		}
+		now := time.Now()
+		if err := tx.Model(&models.Driver{}).Where("id = ?", driverID).
+			Updates(map[string]any{"archived_at": now, "version": bumpVersion}).Error; err != nil {
+			return err
+		}
+		driver.ArchivedAt = &now
+		driver.Version++
+		return recordAudit(tx, s.actor, "driver.archive", auditEntityDriver, driverID,
+			map[string]any{"archived_at": nil}, map[string]any{"archived_at": now})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &driver, nil
+}
+
+// UnarchiveDriver 恢复已归档司机，使其重新出现在司机列表与自动排班中。
+func (s *AdminService) UnarchiveDriver(driverID uint) (*models.Driver, error) {
+	var driver models.Driver
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&driver, driverID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDriverNotFound
+			}
+			return err
+		}
+		if driver.ArchivedAt == nil {
+			return nil
+		}
+		before := *driver.ArchivedAt
+		if err := tx.Model(&models.Driver{}).Where("id = ?", driverID).
+			Updates(map[string]any{"archived_at": nil, "version": bumpVersion}).Error; err != nil {
+			return err
+		}
+		driver.ArchivedAt = nil
+		driver.Version++
+		return recordAudit(tx, s.actor, "driver.unarchive", auditEntityDriver, driverID,
+			map[string]any{"archived_at": before}, map[string]any{"archived_at": nil})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &driver, nil
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminService_LinkDriverUser(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC).WithActor(Actor{UserID: 1, Role: "admin"})
+
+	first, err := svc.CreateDriver(DriverDTO{Name: "d1", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4})
+	require.NoError(t, err)
+	second, err := svc.CreateDriver(DriverDTO{Name: "d2", CarModel: "Van", MaxSeats: 6, MaxChecked: 6, MaxCarryOn: 6})
+	require.NoError(t, err)
+	user := models.User{OpenID: "oid-driver", Name: "Wang", Role: models.UserRoleStudent}
+	require.NoError(t, db.Create(&user).Error)
+	staff := models.User{OpenID: "oid-staff", Name: "Lee", Role: models.UserRoleStaff}
+	require.NoError(t, db.Create(&staff).Error)
+
+	_, err = svc.LinkDriverUser(999, user.ID)
+	assert.ErrorIs(t, err, ErrDriverNotFound)
+	_, err = svc.LinkDriverUser(first.ID, 999)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	_, err = svc.LinkDriverUser(first.ID, staff.ID)
+	assert.ErrorIs(t, err, ErrUserNotLinkable)
+
+	linked, err := svc.LinkDriverUser(first.ID, user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, linked.UserID)
+	assert.Equal(t, user.ID, *linked.UserID)
+	require.NoError(t, db.First(&user, user.ID).Error)
+	assert.Equal(t, models.UserRoleDriver, user.Role)
+
+	// 重复关联同一账号是幂等的
+	_, err = svc.LinkDriverUser(first.ID, user.ID)
+	require.NoError(t, err)
+	_, err = svc.LinkDriverUser(second.ID, user.ID)
+	assert.ErrorIs(t, err, ErrDriverAlreadyLinked)
+	other := models.User{OpenID: "oid-other", Name: "Zhao", Role: models.UserRoleStudent}
+	require.NoError(t, db.Create(&other).Error)
+	_, err = svc.LinkDriverUser(first.ID, other.ID)
+	assert.ErrorIs(t, err, ErrDriverHasUser)
+
+	unlinked, err := svc.UnlinkDriverUser(first.ID)
+	require.NoError(t, err)
+	assert.Nil(t, unlinked.UserID)
+	require.NoError(t, db.First(&user, user.ID).Error)
+	assert.Equal(t, models.UserRoleStudent, user.Role)
+
+	var actions []string
+	require.NoError(t, db.Model(&models.AuditLog{}).Where("entity_type = ? AND entity_id = ?", auditEntityUser, user.ID).Order("id").Pluck("action", &actions).Error)
+	assert.Equal(t, []string{"user.set_driver", "user.unset_driver"}, actions)
+}
+
+func TestDriverService_ShiftsAndResponses(t *testing.T) {
+	db := newTestDB(t)
+	admin := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC).WithActor(Actor{UserID: 1, Role: "admin"})
+	drivers := NewDriverService(db)
+
+	driver, err := admin.CreateDriver(DriverDTO{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4})
+	require.NoError(t, err)
+	account := models.User{OpenID: "oid-driver", Name: "Wang", Role: models.UserRoleStudent}
+	require.NoError(t, db.Create(&account).Error)
+	passenger := models.User{OpenID: "oid-stu", Name: "Li", Phone: "13800000000", Role: models.UserRoleStudent}
+	require.NoError(t, db.Create(&passenger).Error)
+
+	_, err = drivers.MyShifts(account.ID)
+	assert.ErrorIs(t, err, ErrDriverNotLinked)
+	_, err = admin.LinkDriverUser(driver.ID, account.ID)
+	require.NoError(t, err)
+
+	base := time.Date(2026, 8, 20, 10, 0, 0, 0, time.UTC)
+	draft, err := admin.CreateShift(driver.ID, base, 0)
+	require.NoError(t, err)
+	published, err := admin.CreateShift(driver.ID, base.Add(4*time.Hour), 0)
+	require.NoError(t, err)
+	pickup := base.Add(4 * time.Hour)
+	req := models.Request{UserID: passenger.ID, FlightNo: "CA981", ArrivalDate: base, Airport: "JFK", Terminal: "T1",
+		Passengers: 2, CheckedBags: 3, CarryOnBags: 1, Status: models.RequestStatusPending, CalcPickupTime: &pickup}
+	require.NoError(t, db.Create(&req).Error)
+	_, err = admin.AssignStudent(published.ID, req.ID, "")
+	require.NoError(t, err)
+	require.NoError(t, admin.PublishShift(published.ID))
+
+	items, err := drivers.MyShifts(account.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, published.ID, items[0].ID)
+	require.Len(t, items[0].Manifest, 1)
+	entry := items[0].Manifest[0]
+	assert.Equal(t, "Li", entry.Name)
+	assert.Equal(t, "13800000000", entry.Phone)
+	assert.Equal(t, "CA981", entry.FlightNo)
+	assert.Equal(t, "T1", entry.Terminal)
+	assert.Equal(t, 2, entry.Passengers)
+	assert.Equal(t, 3, entry.CheckedBags)
+
+	_, err = drivers.MyShift(account.ID, draft.ID)
+	assert.ErrorIs(t, err, ErrShiftNotForDriver)
+	_, err = drivers.Respond(account.ID, draft.ID, true, "")
+	assert.ErrorIs(t, err, ErrShiftNotRespondable)
+	_, err = drivers.Respond(passenger.ID, published.ID, true, "")
+	assert.ErrorIs(t, err, ErrDriverNotLinked)
+
+	shift, err := drivers.WithActor(Actor{UserID: account.ID, Role: "driver"}).Respond(account.ID, published.ID, false, " flat tire ")
+	require.NoError(t, err)
+	assert.Equal(t, models.ShiftDriverStatusDeclined, shift.DriverStatus)
+	assert.Equal(t, "flat tire", shift.DriverNote)
+	require.NotNil(t, shift.DriverRespondedAt)
+	shift, err = drivers.Respond(account.ID, published.ID, true, "")
+	require.NoError(t, err)
+	assert.Equal(t, models.ShiftDriverStatusAccepted, shift.DriverStatus)
+
+	// 改期后需要重新确认
+	later := base.Add(5 * time.Hour)
+	updated, err := admin.UpdateShift(published.ID, ShiftUpdateDTO{DepartureTime: &later})
+	require.NoError(t, err)
+	assert.Equal(t, models.ShiftDriverStatusPending, updated.DriverStatus)
+	assert.Nil(t, updated.DriverRespondedAt)
+
+	var actions []string
+	require.NoError(t, db.Model(&models.AuditLog{}).Where("entity_type = ? AND entity_id = ?", auditEntityShift, published.ID).Order("id").Pluck("action", &actions).Error)
+	assert.Contains(t, actions, "shift.driver_decline")
+	assert.Contains(t, actions, "shift.driver_accept")
+}
+
+func TestAdminService_ArchiveDriver(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC).WithActor(Actor{UserID: 1, Role: "admin"})
+
+	driver, err := svc.CreateDriver(DriverDTO{Name: "once", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4})
+	require.NoError(t, err)
+	departure := time.Date(2026, 8, 20, 10, 0, 0, 0, time.UTC)
+	shift, err := svc.CreateShift(driver.ID, departure, 0)
+	require.NoError(t, err)
+
+	_, err = svc.ArchiveDriver(driver.ID)
+	assert.ErrorIs(t, err, ErrDriverHasShifts)
+	require.NoError(t, db.Model(&models.Shift{}).Where("id = ?", shift.ID).Update("status", models.ShiftStatusCompleted).Error)
+
+	archived, err := svc.ArchiveDriver(driver.ID)
+	require.NoError(t, err)
+	require.NotNil(t, archived.ArchivedAt)
+	_, err = svc.ArchiveDriver(driver.ID)
+	require.NoError(t, err)
+
+	list, err := svc.ListDrivers(false)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	list, err = svc.ListDrivers(true)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	available, err := svc.AvailableDrivers(departure.AddDate(0, 0, 1), departure.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	assert.Empty(t, available)
+
+	// 历史班次保留，但不能再给已归档司机排新班次
+	var kept models.Shift
+	require.NoError(t, db.First(&kept, shift.ID).Error)
+	assert.Equal(t, driver.ID, kept.DriverID)
+	_, err = svc.CreateShift(driver.ID, departure.AddDate(0, 0, 1), 0)
+	assert.ErrorIs(t, err, ErrDriverArchived)
+	other, err := svc.CreateDriver(DriverDTO{Name: "regular", CarModel: "Van", MaxSeats: 6, MaxChecked: 6, MaxCarryOn: 6})
+	require.NoError(t, err)
+	draft, err := svc.CreateShift(other.ID, departure.AddDate(0, 0, 1), 0)
+	require.NoError(t, err)
+	_, err = svc.UpdateShift(draft.ID, ShiftUpdateDTO{DriverID: &driver.ID})
+	assert.ErrorIs(t, err, ErrDriverArchived)
+	_, err = svc.LinkDriverUser(driver.ID, 1)
+	assert.ErrorIs(t, err, ErrDriverArchived)
+
+	restored, err := svc.UnarchiveDriver(driver.ID)
+	require.NoError(t, err)
+	assert.Nil(t, restored.ArchivedAt)
+	list, err = svc.ListDrivers(false)
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	_, err = svc.ArchiveDriver(999)
+	assert.ErrorIs(t, err, ErrDriverNotFound)
+
+	var audits int64
+	require.NoError(t, db.Model(&models.AuditLog{}).Where("action IN ?", []string{"driver.archive", "driver.unarchive"}).Count(&audits).Error)
+	assert.Equal(t, int64(2), audits)
+}
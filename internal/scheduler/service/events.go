@@ -0,0 +1,23 @@
+package service
+
+import "pickup/internal/scheduler/models"
+
+// requestEvent 需求变更事件的内容：只带标识与状态，客户端据此刷新对应数据。
+func requestEvent(req *models.Request) map[string]any {
+	return map[string]any{
+		"request_id": req.ID,
+		"user_id":    req.UserID,
+		"status":     req.Status,
+		"version":    req.Version,
+	}
+}
+
+// shiftChangeEvent 班次变更事件的内容。
+func shiftChangeEvent(shift *models.Shift) map[string]any {
+	return map[string]any{
+		"shift_id":  shift.ID,
+		"status":    shift.Status,
+		"version":   shift.Version,
+		"driver_id": shift.DriverID,
+	}
+}
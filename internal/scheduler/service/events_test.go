@@ -0,0 +1,160 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/events"
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServices_PublishEvents(t *testing.T) {
+	db := newTestDB(t)
+	broker := events.NewBroker()
+	sub := broker.Subscribe(0, false)
+	defer sub.Close()
+	admin := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, broker, time.UTC)
+	student := NewStudentService(db, broker, time.UTC)
+
+	next := func(typ string) map[string]any {
+		t.Helper()
+		select {
+		case ev := <-sub.Events:
+			require.Equal(t, typ, ev.Type)
+			var data map[string]any
+			require.NoError(t, json.Unmarshal(ev.Data, &data))
+			return data
+		default:
+			require.FailNow(t, "no event", typ)
+			return nil
+		}
+	}
+
+	req, err := student.CreateRequest(2, CreateRequestInput{FlightNo: "AA1", ArrivalDate: "2026-08-20", Terminal: "T1", ExpectedArrivalTime: "2026-08-20 09:00:00"})
+	require.NoError(t, err)
+	data := next(events.RequestCreated)
+	assert.EqualValues(t, req.ID, data["request_id"])
+	assert.Equal(t, "pending", data["status"])
+
+	terminal := "T2"
+	_, err = student.UpdatePendingRequest(2, req.ID, UpdateRequestInput{Terminal: &terminal})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, next(events.RequestUpdated)["version"])
+
+	driver, err := admin.CreateDriver(DriverDTO{Name: "d1", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4})
+	require.NoError(t, err)
+	shift, err := admin.CreateShift(driver.ID, time.Date(2026, 8, 20, 10, 0, 0, 0, time.UTC), 0)
+	require.NoError(t, err)
+	_, err = admin.AssignStudent(shift.ID, req.ID, "")
+	require.NoError(t, err)
+	data = next(events.StudentAssigned)
+	assert.EqualValues(t, shift.ID, data["shift_id"])
+	assert.EqualValues(t, req.ID, data["request_id"])
+
+	staff := models.User{OpenID: "oid-staff", Name: "Lee", Role: models.UserRoleStaff}
+	require.NoError(t, db.Create(&staff).Error)
+	_, err = admin.AssignStaff(shift.ID, staff.ID, "")
+	require.NoError(t, err)
+	assert.EqualValues(t, staff.ID, next(events.StaffAssigned)["staff_id"])
+
+	passengers := 2
+	_, err = admin.UpdateRequest(req.ID, RequestUpdateDTO{Passengers: &passengers})
+	require.NoError(t, err)
+	assert.Equal(t, "assigned", next(events.RequestUpdated)["status"])
+
+	require.NoError(t, admin.PublishShift(shift.ID))
+	assert.EqualValues(t, shift.ID, next(events.ShiftPublished)["shift_id"])
+
+	require.NoError(t, admin.RemoveStudent(shift.ID, req.ID))
+	assert.EqualValues(t, req.ID, next(events.StudentRemoved)["request_id"])
+
+	_, err = student.CancelRequest(2, req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "canceled", next(events.RequestUpdated)["status"])
+
+	// 失败的写入不广播。
+	_, err = student.CancelRequest(2, req.ID)
+	require.ErrorIs(t, err, ErrRequestAlreadyCanceled)
+	assert.Empty(t, sub.Events)
+
+	minutes := 120
+	_, err = admin.UpdateShift(shift.ID, ShiftUpdateDTO{EstimatedMinutes: &minutes})
+	require.NoError(t, err)
+	data = next(events.ShiftUpdated)
+	assert.EqualValues(t, shift.ID, data["shift_id"])
+	assert.Equal(t, "published", data["status"])
+
+	// 取消与删除班次时，释放回 pending 的需求逐条广播。
+	release := func(userID, shiftID uint) models.Request {
+		r, err := student.CreateRequest(userID, CreateRequestInput{FlightNo: "AA2", ArrivalDate: "2026-08-21", Terminal: "T1", ExpectedArrivalTime: "2026-08-21 09:00:00"})
+		require.NoError(t, err)
+		next(events.RequestCreated)
+		_, err = admin.AssignStudent(shiftID, r.ID, "")
+		require.NoError(t, err)
+		next(events.StudentAssigned)
+		return *r
+	}
+	released := release(3, shift.ID)
+	_, err = admin.CancelShift(shift.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "canceled", next(events.ShiftUpdated)["status"])
+	data = next(events.RequestUpdated)
+	assert.EqualValues(t, released.ID, data["request_id"])
+	assert.Equal(t, "pending", data["status"])
+
+	other, err := admin.CreateShift(driver.ID, time.Date(2026, 8, 21, 10, 0, 0, 0, time.UTC), 0)
+	require.NoError(t, err)
+	require.NoError(t, admin.DeleteShift(other.ID, false))
+	assert.EqualValues(t, other.ID, next(events.ShiftDeleted)["shift_id"])
+	assert.Empty(t, sub.Events)
+
+	other, err = admin.CreateShift(driver.ID, time.Date(2026, 8, 21, 10, 0, 0, 0, time.UTC), 0)
+	require.NoError(t, err)
+	released = release(4, other.ID)
+	require.NoError(t, admin.DeleteShift(other.ID, false))
+	assert.EqualValues(t, other.ID, next(events.ShiftDeleted)["shift_id"])
+	data = next(events.RequestUpdated)
+	assert.EqualValues(t, released.ID, data["request_id"])
+	assert.Equal(t, "pending", data["status"])
+
+	// 重复发布不改变状态与版本；出发与完成在提交后广播 shift.updated，管理员与志愿者路径一致。
+	staffSvc := NewStaffService(db, broker, time.UTC)
+	type step func(shiftID uint) (*models.Shift, error)
+	for i, path := range []struct{ start, complete step }{
+		{admin.StartShift, admin.CompleteShift},
+		{
+			func(id uint) (*models.Shift, error) { return staffSvc.StartShift(staff.ID, id) },
+			func(id uint) (*models.Shift, error) { return staffSvc.CompleteShift(staff.ID, id) },
+		},
+	} {
+		live, err := admin.CreateShift(driver.ID, time.Date(2026, 8, 22+i, 10, 0, 0, 0, time.UTC), 0)
+		require.NoError(t, err)
+		_, err = admin.AssignStaff(live.ID, staff.ID, "")
+		require.NoError(t, err)
+		next(events.StaffAssigned)
+		require.NoError(t, admin.PublishShift(live.ID))
+		next(events.ShiftPublished)
+		var published models.Shift
+		require.NoError(t, db.First(&published, live.ID).Error)
+		require.NoError(t, admin.PublishShift(live.ID))
+		next(events.ShiftPublished)
+		var republished models.Shift
+		require.NoError(t, db.First(&republished, live.ID).Error)
+		assert.Equal(t, models.ShiftStatusPublished, republished.Status)
+		assert.Equal(t, published.Version, republished.Version)
+
+		_, err = path.start(live.ID)
+		require.NoError(t, err)
+		data = next(events.ShiftUpdated)
+		assert.EqualValues(t, live.ID, data["shift_id"])
+		assert.Equal(t, "in_progress", data["status"])
+		_, err = path.complete(live.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "completed", next(events.ShiftUpdated)["status"])
+		assert.Empty(t, sub.Events)
+	}
+}
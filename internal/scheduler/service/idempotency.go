@@ -0,0 +1,96 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// IdempotencyTTL 幂等记录保留时长，过期后同一 key 视为新请求。
+	IdempotencyTTL = 24 * time.Hour
+	// idempotencyLease 首个请求未写回结果（进程崩溃等）时占位记录的最长保留时间，超时后允许重试接管。
+	idempotencyLease = 2 * time.Minute
+)
+
+var (
+	ErrIdempotencyKeyReused  = errors.New("Idempotency-Key was already used with a different request")
+	ErrIdempotencyInProgress = errors.New("a request with this Idempotency-Key is still being processed")
+)
+
+type IdempotencyService struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyService(db *gorm.DB) *IdempotencyService {
+	return &IdempotencyService{db: db}
+}
+
+// Begin 登记一次带 key 的请求。replay 为 true 时 record 是已完成的首次响应，应直接重放；
+// 否则 record 为新建的占位记录，处理结束后调用 Complete 保存响应或 Release 放弃。
+func (s *IdempotencyService) Begin(userID uint, key, route, fingerprint string, now time.Time) (*models.IdempotencyKey, bool, error) {
+	scope := s.db.Where("user_id = ? AND idempotency_key = ? AND route = ?", userID, key, route).Session(&gorm.Session{})
+	if err := scope.
+		Where("expires_at <= ? OR (status_code = 0 AND created_at <= ?)", now, now.Add(-idempotencyLease)).
+		Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return nil, false, err
+	}
+
+	record := models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Route:       route,
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(IdempotencyTTL),
+		CreatedAt:   now,
+	}
+	// 并发的重复请求只有一个能插入成功，其余读到占位记录后返回 ErrIdempotencyInProgress。
+	res := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return &record, false, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := scope.First(&existing).Error; err != nil {
+		return nil, false, err
+	}
+	switch {
+	case existing.Fingerprint != fingerprint:
+		return nil, false, ErrIdempotencyKeyReused
+	case existing.StatusCode == 0:
+		return nil, false, ErrIdempotencyInProgress
+	}
+	return &existing, true, nil
+}
+
+// Complete 保存首次响应；header 只需包含重放时要还原的响应头。
+func (s *IdempotencyService) Complete(id uint, status int, header map[string]string, body []byte) error {
+	raw, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	return s.db.Model(&models.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]any{
+		"status_code": status,
+		"headers":     raw,
+		"body":        body,
+	}).Error
+}
+
+// Release 删除占位记录，客户端可用同一 key 重试（用于 5xx 等未生效的请求）。
+func (s *IdempotencyService) Release(id uint) error {
+	return s.db.Delete(&models.IdempotencyKey{}, id).Error
+}
+
+// PurgeExpired 清理过期记录，返回删除条数。
+func (s *IdempotencyService) PurgeExpired(now time.Time) (int64, error) {
+	res := s.db.Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyService_BeginReplayAndExpiry(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewIdempotencyService(db)
+	now := time.Date(2026, 8, 20, 10, 0, 0, 0, time.UTC)
+	route := "POST /api/v1/admin/drivers"
+
+	first, replay, err := svc.Begin(1, "k1", route, "fp-a", now)
+	require.NoError(t, err)
+	assert.False(t, replay)
+	assert.Zero(t, first.StatusCode)
+
+	// 首个请求尚未完成时，重试被告知稍后再试。
+	_, _, err = svc.Begin(1, "k1", route, "fp-a", now)
+	assert.ErrorIs(t, err, ErrIdempotencyInProgress)
+
+	require.NoError(t, svc.Complete(first.ID, 201, map[string]string{"Content-Type": "application/json"}, []byte(`{"id":7}`)))
+	stored, replay, err := svc.Begin(1, "k1", route, "fp-a", now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, replay)
+	assert.Equal(t, first.ID, stored.ID)
+	assert.Equal(t, 201, stored.StatusCode)
+	assert.JSONEq(t, `{"Content-Type":"application/json"}`, string(stored.Headers))
+	assert.Equal(t, `{"id":7}`, string(stored.Body))
+
+	_, _, err = svc.Begin(1, "k1", route, "fp-b", now)
+	assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+
+	// key 按用户与路由区分。
+	_, replay, err = svc.Begin(2, "k1", route, "fp-b", now)
+	require.NoError(t, err)
+	assert.False(t, replay)
+	_, replay, err = svc.Begin(1, "k1", "POST /api/v1/admin/shifts", "fp-b", now)
+	require.NoError(t, err)
+	assert.False(t, replay)
+
+	// 过期后同一 key 视为新请求。
+	again, replay, err := svc.Begin(1, "k1", route, "fp-b", now.Add(IdempotencyTTL))
+	require.NoError(t, err)
+	assert.False(t, replay)
+	assert.NotEqual(t, first.ID, again.ID)
+}
+
+func TestIdempotencyService_ReleaseLeaseAndPurge(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewIdempotencyService(db)
+	now := time.Date(2026, 8, 20, 10, 0, 0, 0, time.UTC)
+	route := "POST /api/v1/student/requests"
+
+	record, _, err := svc.Begin(1, "k1", route, "fp", now)
+	require.NoError(t, err)
+	require.NoError(t, svc.Release(record.ID))
+	_, replay, err := svc.Begin(1, "k1", route, "fp", now)
+	require.NoError(t, err)
+	assert.False(t, replay)
+
+	// 占位记录超过租期仍未完成，视为首个请求已中断。
+	_, _, err = svc.Begin(1, "k1", route, "fp", now.Add(idempotencyLease-time.Second))
+	assert.ErrorIs(t, err, ErrIdempotencyInProgress)
+	_, replay, err = svc.Begin(1, "k1", route, "fp", now.Add(idempotencyLease))
+	require.NoError(t, err)
+	assert.False(t, replay)
+
+	other, _, err := svc.Begin(1, "k2", route, "fp", now.Add(time.Hour))
+	require.NoError(t, err)
+	purged, err := svc.PurgeExpired(now.Add(IdempotencyTTL + idempotencyLease))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	var left []models.IdempotencyKey
+	require.NoError(t, db.Find(&left).Error)
+	require.Len(t, left, 1)
+	assert.Equal(t, other.ID, left[0].ID)
+}
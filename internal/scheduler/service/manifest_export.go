@@ -0,0 +1,97 @@
+package service
+
+import (
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+)
+
+// ManifestRow 导出表中的一行：一名乘客及其所在班次与车辆。
+type ManifestRow struct {
+	ManifestEntry
+	DepartureTime time.Time
+	DriverName    string
+	CarModel      string
+}
+
+// MaskPhone 保留前 3 位与后 4 位，其余以 * 代替；过短的号码只保留后 2 位。
+func MaskPhone(phone string) string {
+	runes := []rune(phone)
+	switch n := len(runes); {
+	case n == 0:
+		return ""
+	case n >= 8:
+		for i := 3; i < n-4; i++ {
+			runes[i] = '*'
+		}
+	default:
+		for i := 0; i < n-2; i++ {
+			runes[i] = '*'
+		}
+	}
+	return string(runes)
+}
+
+// manifestRows 读取 query 选中的班次在 date 当天（按 loc 划分）的乘客名单，只包含已发布或进行中的班次；
+// 名单中的时间同样换算到 loc。
+func manifestRows(db, query *gorm.DB, date time.Time, loc *time.Location, maskPhones bool) ([]ManifestRow, error) {
+	dayStart := startOfDay(date, loc)
+	var shifts []models.Shift
+	err := query.
+		Where("shifts.status IN ? AND shifts.departure_time >= ? AND shifts.departure_time < ?",
+			rosterShiftStatuses, dayStart, dayStart.AddDate(0, 0, 1)).
+		Preload("Driver").
+		Order("shifts.departure_time ASC, shifts.id ASC").
+		Find(&shifts).Error
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(shifts))
+	for _, shift := range shifts {
+		ids = append(ids, shift.ID)
+	}
+	manifests, err := loadManifests(db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]ManifestRow, 0)
+	for _, shift := range shifts {
+		for _, entry := range manifests[shift.ID] {
+			if maskPhones {
+				entry.Phone = MaskPhone(entry.Phone)
+			}
+			if entry.CalcPickupTime != nil {
+				pickup := entry.CalcPickupTime.In(loc)
+				entry.CalcPickupTime = &pickup
+			}
+			row := ManifestRow{ManifestEntry: entry, DepartureTime: shift.DepartureTime.In(loc)}
+			if shift.Driver != nil {
+				row.DriverName = shift.Driver.Name
+				row.CarModel = shift.Driver.CarModel
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// ExportManifests 当前活动内 date 当天全部已发布班次的乘客名单。
+func (s *AdminService) ExportManifests(date time.Time, maskPhones bool) ([]ManifestRow, error) {
+	campaignID, err := s.campaignScope()
+	if err != nil {
+		return nil, err
+	}
+	query := s.db.Model(&models.Shift{}).Scopes(inCampaign("shifts.campaign_id", campaignID))
+	return manifestRows(s.db, query, date, s.loc, maskPhones)
+}
+
+// ExportManifests 志愿者只能导出自己值班的班次。
+func (s *StaffService) ExportManifests(staffID uint, date time.Time, maskPhones bool) ([]ManifestRow, error) {
+	query := s.db.Model(&models.Shift{}).
+		Joins("JOIN shift_staffs ss ON ss.shift_id = shifts.id").
+		Where("ss.staff_id = ?", staffID)
+	return manifestRows(s.db, query, date, s.loc, maskPhones)
+}
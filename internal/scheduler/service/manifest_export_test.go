@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/clause"
+)
+
+func TestMaskPhone(t *testing.T) {
+	assert.Equal(t, "138****0000", MaskPhone("13800000000"))
+	assert.Equal(t, "+12*****0100", MaskPhone("+12175550100"))
+	assert.Equal(t, "****56", MaskPhone("123456"))
+	assert.Equal(t, "", MaskPhone(""))
+}
+
+func TestExportManifests(t *testing.T) {
+	db := newTestDB(t)
+	adminSvc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+	staffSvc := NewStaffService(db, nil, time.UTC)
+
+	staff := models.User{OpenID: "staff", Name: "s", Role: models.UserRoleStaff}
+	li := models.User{OpenID: "li", Name: "Li", Phone: "13800000000", Role: models.UserRoleStudent}
+	wang := models.User{OpenID: "wang", Name: "Wang", Phone: "13900000000", Role: models.UserRoleStudent}
+	for _, u := range []*models.User{&staff, &li, &wang} {
+		require.NoError(t, db.Create(u).Error)
+	}
+	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	day := time.Date(2026, 8, 20, 0, 0, 0, 0, time.Local)
+	shifts := []models.Shift{
+		{DriverID: driver.ID, DepartureTime: day.Add(14 * time.Hour), Status: models.ShiftStatusPublished},
+		{DriverID: driver.ID, DepartureTime: day.Add(10 * time.Hour), Status: models.ShiftStatusInProgress},
+		{DriverID: driver.ID, DepartureTime: day.Add(18 * time.Hour), Status: models.ShiftStatusDraft},
+		{DriverID: driver.ID, DepartureTime: day.AddDate(0, 0, 1), Status: models.ShiftStatusPublished},
+	}
+	for i := range shifts {
+		require.NoError(t, db.Create(&shifts[i]).Error)
+	}
+	for i, userID := range []uint{li.ID, wang.ID, li.ID, wang.ID} {
+		req := models.Request{UserID: userID, FlightNo: "AA1", ArrivalDate: day, Terminal: "T1", CheckedBags: 2, Status: models.RequestStatusPublished}
+		require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+		require.NoError(t, db.Table("shift_requests").Create(map[string]any{"shift_id": shifts[i].ID, "request_id": req.ID}).Error)
+	}
+	require.NoError(t, db.Table("shift_staffs").Create(map[string]any{"shift_id": shifts[0].ID, "staff_id": staff.ID}).Error)
+
+	// 草稿与次日班次不导出，按出发时间排序
+	rows, err := adminSvc.ExportManifests(day, false)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, shifts[1].ID, rows[0].ShiftID)
+	assert.Equal(t, "Wang", rows[0].Name)
+	assert.Equal(t, "13900000000", rows[0].Phone)
+	assert.Equal(t, "d", rows[0].DriverName)
+	assert.Equal(t, "SUV", rows[0].CarModel)
+	assert.Equal(t, 2, rows[0].CheckedBags)
+	assert.Equal(t, shifts[0].ID, rows[1].ShiftID)
+
+	rows, err = staffSvc.ExportManifests(staff.ID, day, true)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "Li", rows[0].Name)
+	assert.Equal(t, "138****0000", rows[0].Phone)
+
+	rows, err = staffSvc.ExportManifests(staff.ID, day.AddDate(0, 0, 1), true)
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+}
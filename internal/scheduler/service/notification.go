@@ -0,0 +1,164 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"pickup/internal/config"
+	"pickup/internal/scheduler/models"
+	"pickup/internal/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const notificationEventShiftPublished = "shift_published"
+
+// 订阅消息模板字段，模板需按此关键词顺序配置：接机时间、航站楼、车型、志愿者联系方式。
+const (
+	publishFieldPickupTime   = "time1"
+	publishFieldTerminal     = "thing2"
+	publishFieldCarModel     = "thing3"
+	publishFieldStaffContact = "thing4"
+)
+
+// thing 类字段微信限制 20 个字符。
+const subscribeThingMaxRunes = 20
+
+// NotificationService 班次发布后通过小程序订阅消息通知学生，并持久化每次发送结果。
+type NotificationService struct {
+	db         *gorm.DB
+	wechat     *utils.WechatClient
+	templateID string
+	logger     *zap.Logger
+}
+
+func NewNotificationService(db *gorm.DB, wechatCfg *config.WechatConfig, logger *zap.Logger) *NotificationService {
+	return &NotificationService{
+		db:         db,
+		wechat:     utils.NewWechatClient(wechatCfg.AppID, wechatCfg.AppSecret),
+		templateID: wechatCfg.PublishTemplateID,
+		logger:     logger,
+	}
+}
+
+// NotifyShiftPublished 向班次内已发布需求的学生发送通知。
+// 未配置模板时直接跳过；已成功通知过的需求不会重复发送，便于重复发布时只通知新加入的学生。
+func (s *NotificationService) NotifyShiftPublished(shiftID uint) ([]models.Notification, error) {
+	if s.templateID == "" {
+		return nil, nil
+	}
+
+	var shift models.Shift
+	if err := s.db.
+		Preload("Driver").
+		Preload("Staffs").
+		Preload("Requests", "status = ?", models.RequestStatusPublished).
+		Preload("Requests.User").
+		First(&shift, shiftID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShiftNotFound
+		}
+		return nil, err
+	}
+
+	var sent []uint
+	if err := s.db.Model(&models.Notification{}).
+		Where("shift_id = ? AND event = ? AND status = ?", shiftID, notificationEventShiftPublished, models.NotificationStatusSent).
+		Pluck("request_id", &sent).Error; err != nil {
+		return nil, err
+	}
+	done := make(map[uint]bool, len(sent))
+	for _, id := range sent {
+		done[id] = true
+	}
+
+	pending := make([]models.Request, 0, len(shift.Requests))
+	for _, req := range shift.Requests {
+		if !done[req.ID] {
+			pending = append(pending, req)
+		}
+	}
+	if len(pending) == 0 {
+		return []models.Notification{}, nil
+	}
+
+	carModel := "待定"
+	if shift.Driver != nil && shift.Driver.CarModel != "" {
+		carModel = shift.Driver.CarModel
+	}
+	contact := "待定"
+	if len(shift.Staffs) > 0 {
+		contact = strings.TrimSpace(shift.Staffs[0].Name + " " + shift.Staffs[0].Phone)
+	}
+
+	token, tokenErr := s.wechat.GetAccessToken()
+
+	records := make([]models.Notification, 0, len(pending))
+	for _, req := range pending {
+		record := models.Notification{
+			UserID:     req.UserID,
+			RequestID:  req.ID,
+			ShiftID:    shift.ID,
+			Event:      notificationEventShiftPublished,
+			TemplateID: s.templateID,
+		}
+
+		switch {
+		case req.User == nil || req.User.OpenID == "":
+			record.Status = models.NotificationStatusSkipped
+			record.ErrMsg = "missing open_id"
+		case tokenErr != nil:
+			record.Status = models.NotificationStatusFailed
+			record.ErrMsg = truncateRunes(tokenErr.Error(), 255)
+		default:
+			resp, err := s.wechat.SendSubscribeMessage(token, utils.SubscribeMessage{
+				ToUser:     req.User.OpenID,
+				TemplateID: s.templateID,
+				Data: map[string]utils.SubscribeMessageValue{
+					publishFieldPickupTime:   {Value: shift.DepartureTime.Format("2006-01-02 15:04")},
+					publishFieldTerminal:     {Value: truncateRunes(req.Terminal, subscribeThingMaxRunes)},
+					publishFieldCarModel:     {Value: truncateRunes(carModel, subscribeThingMaxRunes)},
+					publishFieldStaffContact: {Value: truncateRunes(contact, subscribeThingMaxRunes)},
+				},
+			})
+			record.Status = models.NotificationStatusSent
+			if resp != nil {
+				record.ErrCode = resp.ErrCode
+			}
+			if err != nil {
+				record.Status = models.NotificationStatusFailed
+				record.ErrMsg = truncateRunes(err.Error(), 255)
+			}
+		}
+
+		if err := s.db.Create(&record).Error; err != nil {
+			return records, fmt.Errorf("save notification for request %d: %w", req.ID, err)
+		}
+		if record.Status == models.NotificationStatusFailed {
+			s.logger.Warn("shift publish notification failed",
+				zap.Uint("shift_id", shift.ID),
+				zap.Uint("request_id", req.ID),
+				zap.String("error", record.ErrMsg))
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// ListShiftNotifications 返回班次的通知发送记录，按时间倒序。
+func (s *AdminService) ListShiftNotifications(shiftID uint) ([]models.Notification, error) {
+	var items []models.Notification
+	err := s.db.Where("shift_id = ?", shiftID).Order("id DESC").Find(&items).Error
+	return items, err
+}
+
+func truncateRunes(v string, limit int) string {
+	runes := []rune(v)
+	if len(runes) <= limit {
+		return v
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pickup/internal/config"
+	"pickup/internal/scheduler/models"
+	"pickup/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+func TestNotificationService_NotifyOnPublish(t *testing.T) {
+	db := newTestDB(t)
+
+	var sends int32
+	var last utils.SubscribeMessage
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cgi-bin/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "tok", "expires_in": 7200})
+	})
+	mux.HandleFunc("/cgi-bin/message/subscribe/send", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&sends, 1)
+		var msg utils.SubscribeMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		if msg.ToUser == "oid-refused" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"errcode": 43101, "errmsg": "user refuse to accept the msg"})
+			return
+		}
+		last = msg
+		_ = json.NewEncoder(w).Encode(map[string]any{"errcode": 0, "errmsg": "ok"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	notifier := NewNotificationService(db, &config.WechatConfig{AppID: "a", AppSecret: "b", PublishTemplateID: "tpl"}, zap.NewNop())
+	notifier.wechat.SetBaseURL(server.URL)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), notifier, nil, time.UTC)
+
+	driver := models.Driver{Name: "d", CarModel: "Toyota Sienna", MaxSeats: 6, MaxChecked: 6, MaxCarryOn: 6}
+	require.NoError(t, db.Create(&driver).Error)
+	departure := time.Date(2026, 9, 1, 15, 30, 0, 0, time.UTC)
+	shift := models.Shift{DriverID: driver.ID, DepartureTime: departure, Status: models.ShiftStatusDraft}
+	require.NoError(t, db.Create(&shift).Error)
+	staff := models.User{OpenID: "oid-staff", Name: "Lee", Phone: "13800000000", Role: models.UserRoleStaff}
+	require.NoError(t, db.Create(&staff).Error)
+	require.NoError(t, db.Table("shift_staffs").Create(map[string]any{"shift_id": shift.ID, "staff_id": staff.ID}).Error)
+
+	users := []models.User{
+		{OpenID: "oid-ok", Name: "a", Role: models.UserRoleStudent},
+		{OpenID: "oid-refused", Name: "b", Role: models.UserRoleStudent},
+	}
+	for i := range users {
+		require.NoError(t, db.Create(&users[i]).Error)
+		req := models.Request{UserID: users[i].ID, FlightNo: "CA1", ArrivalDate: departure, Terminal: "T3", Status: models.RequestStatusPending}
+		require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+		_, err := svc.AssignStudent(shift.ID, req.ID, "")
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, svc.PublishShift(shift.ID))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&sends))
+	assert.Equal(t, "tpl", last.TemplateID)
+	assert.Equal(t, "2026-09-01 15:30", last.Data[publishFieldPickupTime].Value)
+	assert.Equal(t, "T3", last.Data[publishFieldTerminal].Value)
+	assert.Equal(t, "Toyota Sienna", last.Data[publishFieldCarModel].Value)
+	assert.Equal(t, "Lee 13800000000", last.Data[publishFieldStaffContact].Value)
+
+	items, err := svc.ListShiftNotifications(shift.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	byUser := map[uint]models.Notification{}
+	for _, item := range items {
+		byUser[item.UserID] = item
+	}
+	assert.Equal(t, models.NotificationStatusSent, byUser[users[0].ID].Status)
+	assert.Equal(t, models.NotificationStatusFailed, byUser[users[1].ID].Status)
+	assert.Equal(t, 43101, byUser[users[1].ID].ErrCode)
+
+	// 重复发布只重试未成功的学生
+	require.NoError(t, svc.PublishShift(shift.ID))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&sends))
+	items, err = svc.ListShiftNotifications(shift.ID)
+	require.NoError(t, err)
+	assert.Len(t, items, 3)
+
+	// 加入已发布班次的学生直接为 published 并收到通知；仍未成功的学生一并重试。
+	late := models.User{OpenID: "oid-late", Name: "c", Role: models.UserRoleStudent}
+	require.NoError(t, db.Create(&late).Error)
+	req := models.Request{UserID: late.ID, FlightNo: "CA1", ArrivalDate: departure, Terminal: "T3", Status: models.RequestStatusPending}
+	require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+	res, err := svc.AssignStudent(shift.ID, req.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, models.RequestStatusPublished, res.Status)
+	require.NoError(t, db.First(&req, req.ID).Error)
+	assert.Equal(t, models.RequestStatusPublished, req.Status)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&sends))
+	assert.Equal(t, "oid-late", last.ToUser)
+}
+
+func TestNotificationService_SkipBranches(t *testing.T) {
+	db := newTestDB(t)
+
+	disabled := NewNotificationService(db, &config.WechatConfig{}, zap.NewNop())
+	records, err := disabled.NotifyShiftPublished(1)
+	require.NoError(t, err)
+	assert.Nil(t, records)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cgi-bin/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"errcode": 40164, "errmsg": "ip not in whitelist"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	svc := NewNotificationService(db, &config.WechatConfig{AppID: "a", AppSecret: "b", PublishTemplateID: "tpl"}, zap.NewNop())
+	svc.wechat.SetBaseURL(server.URL)
+
+	_, err = svc.NotifyShiftPublished(999)
+	assert.ErrorIs(t, err, ErrShiftNotFound)
+
+	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	shift := models.Shift{DriverID: driver.ID, DepartureTime: time.Now(), Status: models.ShiftStatusPublished}
+	require.NoError(t, db.Create(&shift).Error)
+	withOpenID := models.User{OpenID: "oid-1", Name: "a", Role: models.UserRoleStudent}
+	require.NoError(t, db.Create(&withOpenID).Error)
+	for _, userID := range []uint{withOpenID.ID, 999} {
+		req := models.Request{UserID: userID, FlightNo: "CA1", ArrivalDate: time.Now(), Terminal: "T3", Status: models.RequestStatusPublished}
+		require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+		require.NoError(t, db.Table("shift_requests").Create(map[string]any{"shift_id": shift.ID, "request_id": req.ID}).Error)
+	}
+
+	records, err = svc.NotifyShiftPublished(shift.ID)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	statuses := map[uint]models.NotificationStatus{}
+	for _, r := range records {
+		statuses[r.UserID] = r.Status
+	}
+	assert.Equal(t, models.NotificationStatusFailed, statuses[withOpenID.ID])
+	assert.Equal(t, models.NotificationStatusSkipped, statuses[999])
+}
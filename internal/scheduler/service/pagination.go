@@ -0,0 +1,154 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidLimit  = fmt.Errorf("limit must be between 1 and %d", MaxPageLimit)
+)
+
+// Page 游标分页结果；Total 为不分页时的总数，NextCursor 为空表示没有下一页。
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// PageQuery 排序与分页参数。Sort 为排序字段，前缀 "-" 表示倒序；Cursor 取自上一页的 NextCursor。
+type PageQuery struct {
+	Sort   string
+	Cursor string
+	Limit  int
+}
+
+// sortOrder 按时间列排序，相同时间再按 id 排序保证游标稳定；column 为空时只按 id 排序。
+type sortOrder struct {
+	name   string
+	table  string
+	column string
+	desc   bool
+}
+
+// parseSort columns 为允许的排序字段到时间列的映射，"id" 总是允许。
+func parseSort(raw, fallback, table string, columns map[string]string) (sortOrder, error) {
+	if raw == "" {
+		raw = fallback
+	}
+	order := sortOrder{name: raw, table: table}
+	field := raw
+	if strings.HasPrefix(field, "-") {
+		order.desc = true
+		field = field[1:]
+	}
+	if field == "id" {
+		return order, nil
+	}
+	column, ok := columns[field]
+	if !ok {
+		return sortOrder{}, ErrInvalidSort
+	}
+	order.column = table + "." + column
+	return order, nil
+}
+
+// pageCursor 上一页最后一行的排序值；Sort 用于拒绝换了排序方式后沿用的旧游标。
+type pageCursor struct {
+	Sort string     `json:"s"`
+	Key  *time.Time `json:"k,omitempty"`
+	ID   uint       `json:"id"`
+}
+
+func (o sortOrder) encodeCursor(key time.Time, id uint) string {
+	c := pageCursor{Sort: o.name, ID: id}
+	if o.column != "" {
+		c.Key = &key
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func (o sortOrder) decodeCursor(raw string) (*pageCursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != o.name || (o.column != "" && c.Key == nil) {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// apply 追加游标条件与排序。
+func (o sortOrder) apply(query *gorm.DB, after *pageCursor) *gorm.DB {
+	op, dir := ">", "ASC"
+	if o.desc {
+		op, dir = "<", "DESC"
+	}
+	id := o.table + ".id"
+	if o.column == "" {
+		if after != nil {
+			query = query.Where(id+" "+op+" ?", after.ID)
+		}
+		return query.Order(id + " " + dir)
+	}
+	if after != nil {
+		query = query.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", o.column, op, o.column, id, op),
+			*after.Key, *after.Key, after.ID)
+	}
+	return query.Order(o.column + " " + dir).Order(id + " " + dir)
+}
+
+func pageLimit(limit int) (int, error) {
+	if limit == 0 {
+		return DefaultPageLimit, nil
+	}
+	if limit < 0 || limit > MaxPageLimit {
+		return 0, ErrInvalidLimit
+	}
+	return limit, nil
+}
+
+// paginate 统计 base 的总数并读取一页；keyOf 返回一行的排序时间与 id，load 只作用于读取（如 Preload）。
+func paginate[T any](base *gorm.DB, order sortOrder, q PageQuery, keyOf func(T) (time.Time, uint), load ...func(*gorm.DB) *gorm.DB) (*Page[T], error) {
+	limit, err := pageLimit(q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	after, err := order.decodeCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	page := &Page[T]{Items: make([]T, 0)}
+	if err := base.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	query := order.apply(base.Session(&gorm.Session{}), after).Scopes(load...).Limit(limit + 1)
+	if err := query.Find(&page.Items).Error; err != nil {
+		return nil, err
+	}
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		key, id := keyOf(page.Items[limit-1])
+		page.NextCursor = order.encodeCursor(key, id)
+	}
+	return page, nil
+}
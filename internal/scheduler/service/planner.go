@@ -0,0 +1,320 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"pickup/internal/scheduler/events"
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPlanWindowMinutes     = 60
+	defaultPlanTurnaroundMinutes = 180
+)
+
+var ErrEmptyPlan = errors.New("plan has no shifts")
+
+// PlanPreviewInput 自动排班预览参数。
+type PlanPreviewInput struct {
+	From              time.Time
+	To                time.Time
+	WindowMinutes     int
+	TurnaroundMinutes int
+	ReserveSeats      int
+}
+
+// PlannedShift 预览中的草稿班次；EstimatedMinutes 为 0 时提交按默认时长创建。
+type PlannedShift struct {
+	DriverID         uint      `json:"driver_id"`
+	DepartureTime    time.Time `json:"departure_time"`
+	EstimatedMinutes int       `json:"estimated_minutes"`
+	Terminal         string    `json:"terminal"`
+	RequestIDs       []uint    `json:"request_ids"`
+	Seats            int       `json:"seats"`
+	CheckedBags      int       `json:"checked_bags"`
+	CarryOnBags      int       `json:"carry_on_bags"`
+}
+
+// UnplannedRequest 未能排入班次的需求及原因。
+type UnplannedRequest struct {
+	RequestID uint   `json:"request_id"`
+	Reason    string `json:"reason"`
+}
+
+// ShiftPlan 自动排班预览结果，不落库。
+type ShiftPlan struct {
+	Shifts     []PlannedShift     `json:"shifts"`
+	Unassigned []UnplannedRequest `json:"unassigned"`
+}
+
+type planBin struct {
+	driver models.Driver
+	shift  PlannedShift
+}
+
+func (b *planBin) fits(req models.Request, reserveSeats int) bool {
+	return b.shift.Seats+req.Passengers+reserveSeats <= b.driver.MaxSeats &&
+		b.shift.CheckedBags+req.CheckedBags <= b.driver.MaxChecked &&
+		b.shift.CarryOnBags+req.CarryOnBags <= b.driver.MaxCarryOn
+}
+
+// departureWith 加入 req 后的发车时间：取组内最晚的接机时间。
+func (b *planBin) departureWith(req models.Request) time.Time {
+	if req.CalcPickupTime.After(b.shift.DepartureTime) {
+		return *req.CalcPickupTime
+	}
+	return b.shift.DepartureTime
+}
+
+func (b *planBin) add(req models.Request) {
+	b.shift.RequestIDs = append(b.shift.RequestIDs, req.ID)
+	b.shift.Seats += req.Passengers
+	b.shift.CheckedBags += req.CheckedBags
+	b.shift.CarryOnBags += req.CarryOnBags
+	b.shift.DepartureTime = b.departureWith(req)
+}
+
+// planSlot 司机被占用的一段时间：已有班次或本次预览中的班次。
+type planSlot struct {
+	departure time.Time
+	minutes   int
+	bin       *planBin
+}
+
+// PreviewPlan 按接机时间窗口与航站楼分组，将日期范围内的 pending 需求装箱到可用司机。
+// 分组内按行李量降序做 First-Fit；班次按最终发车时间与预计用时占用司机，且前后至少间隔 turnaround，
+// 登记了时间窗的司机只排入时间窗覆盖的班次。
+func (s *AdminService) PreviewPlan(input PlanPreviewInput) (*ShiftPlan, error) {
+	window := time.Duration(input.WindowMinutes) * time.Minute
+	if window <= 0 {
+		window = defaultPlanWindowMinutes * time.Minute
+	}
+	turnaround := time.Duration(input.TurnaroundMinutes) * time.Minute
+	if turnaround <= 0 {
+		turnaround = defaultPlanTurnaroundMinutes * time.Minute
+	}
+	if input.To.Before(input.From) {
+		return nil, errors.New("invalid date range")
+	}
+
+	campaignID, err := s.campaignScope()
+	if err != nil {
+		return nil, err
+	}
+	scoped := s.db.Scopes(inCampaign("campaign_id", campaignID))
+
+	var reqs []models.Request
+	if err := scoped.
+		Where("status = ? AND arrival_date >= ? AND arrival_date < ?",
+			models.RequestStatusPending, input.From.Format("2006-01-02"), input.To.AddDate(0, 0, 1).Format("2006-01-02")).
+		Order("calc_pickup_time ASC, id ASC").
+		Find(&reqs).Error; err != nil {
+		return nil, err
+	}
+
+	var drivers []models.Driver
+	if err := s.db.Scopes(inCampaign("campaign_id", campaignID)).Where("archived_at IS NULL").Order("max_seats DESC, id ASC").Find(&drivers).Error; err != nil {
+		return nil, err
+	}
+
+	var existing []models.Shift
+	if err := s.db.Scopes(inCampaign("campaign_id", campaignID)).
+		Where("status IN ? AND departure_time BETWEEN ? AND ?",
+			[]models.ShiftStatus{models.ShiftStatusDraft, models.ShiftStatusPublished, models.ShiftStatusInProgress},
+			input.From.Add(-turnaround), input.To.AddDate(0, 0, 1).Add(turnaround)).
+		Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	busy := make(map[uint][]*planSlot, len(drivers))
+	for _, shift := range existing {
+		busy[shift.DriverID] = append(busy[shift.DriverID], &planSlot{departure: shift.DepartureTime, minutes: shift.EstimatedMinutes})
+	}
+	driverIDs := make([]uint, 0, len(drivers))
+	for _, d := range drivers {
+		driverIDs = append(driverIDs, d.ID)
+	}
+	var rows []models.DriverAvailability
+	if err := s.db.Where("driver_id IN ?", driverIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	windows := make(map[uint][]models.DriverAvailability, len(drivers))
+	for _, w := range rows {
+		windows[w.DriverID] = append(windows[w.DriverID], w)
+	}
+	// span 班次占用司机的时长，不短于 turnaround。
+	span := func(minutes int) time.Duration {
+		if d := time.Duration(minutes) * time.Minute; d > turnaround {
+			return d
+		}
+		return turnaround
+	}
+	// driverFree 检查司机在 [departure, departure+minutes) 是否空闲；self 为正在调整的班次本身。
+	driverFree := func(driverID uint, departure time.Time, minutes int, self *planBin) bool {
+		if !coveredByAvailability(windows[driverID], departure, shiftEnd(departure, minutes)) {
+			return false
+		}
+		for _, slot := range busy[driverID] {
+			if slot.bin != nil && slot.bin == self {
+				continue
+			}
+			start, length := slot.departure, slot.minutes
+			if slot.bin != nil {
+				start, length = slot.bin.shift.DepartureTime, slot.bin.shift.EstimatedMinutes
+			}
+			if departure.Before(start.Add(span(length))) && start.Before(departure.Add(span(minutes))) {
+				return false
+			}
+		}
+		return true
+	}
+
+	plan := &ShiftPlan{Shifts: []PlannedShift{}, Unassigned: []UnplannedRequest{}}
+
+	groups := make(map[string][][]models.Request)
+	terminals := make([]string, 0)
+	for _, req := range reqs {
+		if req.CalcPickupTime == nil {
+			plan.Unassigned = append(plan.Unassigned, UnplannedRequest{RequestID: req.ID, Reason: "missing_pickup_time"})
+			continue
+		}
+		windows, ok := groups[req.Terminal]
+		if !ok {
+			terminals = append(terminals, req.Terminal)
+		}
+		if n := len(windows); n > 0 && req.CalcPickupTime.Sub(*windows[n-1][0].CalcPickupTime) < window {
+			windows[n-1] = append(windows[n-1], req)
+		} else {
+			windows = append(windows, []models.Request{req})
+		}
+		groups[req.Terminal] = windows
+	}
+
+	for _, terminal := range terminals {
+		for _, group := range groups[terminal] {
+			sort.SliceStable(group, func(i, j int) bool {
+				return group[i].CheckedBags+group[i].CarryOnBags > group[j].CheckedBags+group[j].CarryOnBags
+			})
+
+			var bins []*planBin
+			for _, req := range group {
+				placed := false
+				for _, bin := range bins {
+					// 加入后发车时间可能推迟，需按新的时间重新确认司机空闲。
+					if bin.fits(req, input.ReserveSeats) && driverFree(bin.driver.ID, bin.departureWith(req), bin.shift.EstimatedMinutes, bin) {
+						bin.add(req)
+						placed = true
+						break
+					}
+				}
+				if placed {
+					continue
+				}
+
+				reason := "exceeds_vehicle_capacity"
+				for _, driver := range drivers {
+					candidate := &planBin{driver: driver, shift: PlannedShift{
+						DriverID:         driver.ID,
+						DepartureTime:    *req.CalcPickupTime,
+						EstimatedMinutes: DefaultShiftMinutes,
+						Terminal:         terminal,
+						RequestIDs:       []uint{},
+					}}
+					if !candidate.fits(req, input.ReserveSeats) {
+						continue
+					}
+					if !driverFree(driver.ID, candidate.shift.DepartureTime, candidate.shift.EstimatedMinutes, nil) {
+						reason = "no_available_driver"
+						continue
+					}
+					candidate.add(req)
+					bins = append(bins, candidate)
+					busy[driver.ID] = append(busy[driver.ID], &planSlot{bin: candidate})
+					placed = true
+					break
+				}
+				if !placed {
+					plan.Unassigned = append(plan.Unassigned, UnplannedRequest{RequestID: req.ID, Reason: reason})
+				}
+			}
+
+			for _, bin := range bins {
+				plan.Shifts = append(plan.Shifts, bin.shift)
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// RequestWarning 提交排班时单个需求的非阻断提示，如 capacity_overload。
+type RequestWarning struct {
+	RequestID uint   `json:"request_id"`
+	Warning   string `json:"warning"`
+}
+
+// CommittedShift 提交后创建的班次，附带各需求分配时的容量提示。
+type CommittedShift struct {
+	models.Shift
+	RequestWarnings []RequestWarning `json:"request_warnings,omitempty"`
+}
+
+// CommitPlan 在单个事务内创建草稿班次并绑定需求；任一需求状态变化或司机时段重叠则整体回滚。
+// 预览后被手工调整导致超载时按容量策略处理，warn 策略下的提示随班次返回。
+func (s *AdminService) CommitPlan(planned []PlannedShift) ([]CommittedShift, error) {
+	if len(planned) == 0 {
+		return nil, ErrEmptyPlan
+	}
+
+	scope, err := s.campaignScope()
+	if err != nil {
+		return nil, err
+	}
+	created := make([]CommittedShift, 0, len(planned))
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range planned {
+			minutes, err := shiftMinutes(item.EstimatedMinutes)
+			if err != nil {
+				return err
+			}
+			campaignID, err := shiftCampaignInTx(tx, item.DriverID, scope)
+			if err != nil {
+				return err
+			}
+			shift := models.Shift{DriverID: item.DriverID, DepartureTime: item.DepartureTime, EstimatedMinutes: minutes, Status: models.ShiftStatusDraft, CampaignID: campaignID}
+			if shift.Warning, err = checkDriverScheduleInTx(tx, item.DriverID, item.DepartureTime, minutes, 0); err != nil {
+				return err
+			}
+			if err := tx.Create(&shift).Error; err != nil {
+				return err
+			}
+			committed := CommittedShift{Shift: shift}
+			for _, requestID := range item.RequestIDs {
+				res, err := s.assigner.assignInTx(tx, shift.ID, requestID, "")
+				if err != nil {
+					return err
+				}
+				if res.Warning != "" {
+					committed.RequestWarnings = append(committed.RequestWarnings, RequestWarning{RequestID: requestID, Warning: res.Warning})
+				}
+			}
+			if err := recordAudit(tx, s.actor, "shift.create_from_plan", auditEntityShift, shift.ID, nil, item); err != nil {
+				return err
+			}
+			created = append(created, committed)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range planned {
+		for _, requestID := range item.RequestIDs {
+			s.broker.Publish(events.StudentAssigned, map[string]any{"shift_id": created[i].ID, "request_id": requestID})
+		}
+	}
+	return created, nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func createPlanRequest(t *testing.T, db *gorm.DB, terminal string, pickup time.Time, checked int) models.Request {
+	t.Helper()
+	arrival := pickup.Add(-45 * time.Minute)
+	req := models.Request{
+		UserID:         1,
+		FlightNo:       "AA1",
+		ArrivalDate:    time.Date(pickup.Year(), pickup.Month(), pickup.Day(), 0, 0, 0, 0, time.UTC),
+		Terminal:       terminal,
+		CheckedBags:    checked,
+		Status:         models.RequestStatusPending,
+		ArrivalTimeAPI: &arrival,
+		PickupBuffer:   45,
+		CalcPickupTime: &pickup,
+	}
+	require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+	return req
+}
+
+func TestAdminService_PreviewPlan_GroupsAndPacks(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+
+	van := models.Driver{Name: "van", CarModel: "Van", MaxSeats: 3, MaxChecked: 4, MaxCarryOn: 4}
+	sedan := models.Driver{Name: "sedan", CarModel: "Sedan", MaxSeats: 2, MaxChecked: 2, MaxCarryOn: 2}
+	require.NoError(t, db.Create(&van).Error)
+	require.NoError(t, db.Create(&sedan).Error)
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	r1 := createPlanRequest(t, db, "T5", base, 2)
+	r2 := createPlanRequest(t, db, "T5", base.Add(20*time.Minute), 1)
+	r3 := createPlanRequest(t, db, "T5", base.Add(40*time.Minute), 1)
+	r4 := createPlanRequest(t, db, "T1", base.Add(10*time.Minute), 0)
+	r5 := createPlanRequest(t, db, "T5", base.Add(50*time.Minute), 5)
+
+	plan, err := svc.PreviewPlan(PlanPreviewInput{
+		From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, plan.Shifts, 2)
+	assert.Equal(t, "T5", plan.Shifts[0].Terminal)
+	assert.Equal(t, van.ID, plan.Shifts[0].DriverID)
+	assert.ElementsMatch(t, []uint{r1.ID, r2.ID, r3.ID}, plan.Shifts[0].RequestIDs)
+	assert.Equal(t, base.Add(40*time.Minute), plan.Shifts[0].DepartureTime.UTC())
+	assert.Equal(t, "T1", plan.Shifts[1].Terminal)
+	assert.Equal(t, sedan.ID, plan.Shifts[1].DriverID)
+	assert.Equal(t, []uint{r4.ID}, plan.Shifts[1].RequestIDs)
+
+	require.Len(t, plan.Unassigned, 1)
+	assert.Equal(t, r5.ID, plan.Unassigned[0].RequestID)
+	assert.Equal(t, "exceeds_vehicle_capacity", plan.Unassigned[0].Reason)
+}
+
+func TestAdminService_PreviewPlan_SkipsBusyDrivers(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+
+	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, db.Create(&models.Shift{DriverID: driver.ID, DepartureTime: base.Add(time.Hour), Status: models.ShiftStatusDraft}).Error)
+	req := createPlanRequest(t, db, "T1", base, 1)
+
+	plan, err := svc.PreviewPlan(PlanPreviewInput{From: base.Truncate(24 * time.Hour), To: base.Truncate(24 * time.Hour)})
+	require.NoError(t, err)
+	assert.Empty(t, plan.Shifts)
+	require.Len(t, plan.Unassigned, 1)
+	assert.Equal(t, req.ID, plan.Unassigned[0].RequestID)
+	assert.Equal(t, "no_available_driver", plan.Unassigned[0].Reason)
+
+	_, err = svc.PreviewPlan(PlanPreviewInput{From: base, To: base.Add(-48 * time.Hour)})
+	assert.ErrorContains(t, err, "invalid date range")
+}
+
+func TestAdminService_PreviewPlan_UsesShiftSpanAndAvailability(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	day := base.Truncate(24 * time.Hour)
+
+	// 长班次：出发在 4 小时前，但 12 小时后才结束。
+	long := models.Driver{Name: "long", CarModel: "Van", MaxSeats: 6, MaxChecked: 6, MaxCarryOn: 6}
+	require.NoError(t, db.Create(&long).Error)
+	require.NoError(t, db.Create(&models.Shift{DriverID: long.ID, DepartureTime: base.Add(-4 * time.Hour), EstimatedMinutes: 720, Status: models.ShiftStatusPublished}).Error)
+	// 时间窗只覆盖下午。
+	windowed := models.Driver{Name: "windowed", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&windowed).Error)
+	require.NoError(t, db.Create(&models.DriverAvailability{DriverID: windowed.ID, StartTime: base.Add(4 * time.Hour), EndTime: base.Add(10 * time.Hour), Source: models.AvailabilitySourceAdmin}).Error)
+
+	morning := createPlanRequest(t, db, "T1", base, 1)
+	plan, err := svc.PreviewPlan(PlanPreviewInput{From: day, To: day})
+	require.NoError(t, err)
+	assert.Empty(t, plan.Shifts)
+	require.Len(t, plan.Unassigned, 1)
+	assert.Equal(t, morning.ID, plan.Unassigned[0].RequestID)
+	assert.Equal(t, "no_available_driver", plan.Unassigned[0].Reason)
+
+	afternoon := createPlanRequest(t, db, "T1", base.Add(5*time.Hour), 1)
+	plan, err = svc.PreviewPlan(PlanPreviewInput{From: day, To: day})
+	require.NoError(t, err)
+	require.Len(t, plan.Shifts, 1)
+	assert.Equal(t, windowed.ID, plan.Shifts[0].DriverID)
+	assert.Equal(t, []uint{afternoon.ID}, plan.Shifts[0].RequestIDs)
+	assert.Equal(t, DefaultShiftMinutes, plan.Shifts[0].EstimatedMinutes)
+}
+
+func TestAdminService_PreviewPlan_RechecksDelayedDeparture(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	driver := models.Driver{Name: "d", CarModel: "Van", MaxSeats: 6, MaxChecked: 6, MaxCarryOn: 6}
+	require.NoError(t, db.Create(&driver).Error)
+	require.NoError(t, db.Create(&models.Shift{DriverID: driver.ID, DepartureTime: base.Add(210 * time.Minute), EstimatedMinutes: 60, Status: models.ShiftStatusDraft}).Error)
+	// 行李多的先装箱，班次在 10:00 出发时司机空闲；再加入 10:50 的需求会把发车推迟到与已有班次冲突。
+	early := createPlanRequest(t, db, "T1", base, 2)
+	late := createPlanRequest(t, db, "T1", base.Add(50*time.Minute), 0)
+
+	plan, err := svc.PreviewPlan(PlanPreviewInput{From: base.Truncate(24 * time.Hour), To: base.Truncate(24 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, plan.Shifts, 1)
+	assert.Equal(t, []uint{early.ID}, plan.Shifts[0].RequestIDs)
+	assert.Equal(t, base, plan.Shifts[0].DepartureTime.UTC())
+	require.Len(t, plan.Unassigned, 1)
+	assert.Equal(t, late.ID, plan.Unassigned[0].RequestID)
+	assert.Equal(t, "no_available_driver", plan.Unassigned[0].Reason)
+}
+
+func TestAdminService_CommitPlan(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+
+	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	r1 := createPlanRequest(t, db, "T1", base, 1)
+	r2 := createPlanRequest(t, db, "T1", base.Add(10*time.Minute), 1)
+
+	_, err := svc.CommitPlan(nil)
+	assert.ErrorIs(t, err, ErrEmptyPlan)
+
+	shifts, err := svc.CommitPlan([]PlannedShift{{DriverID: driver.ID, DepartureTime: base.Add(10 * time.Minute), RequestIDs: []uint{r1.ID, r2.ID}}})
+	require.NoError(t, err)
+	require.Len(t, shifts, 1)
+	assert.Equal(t, models.ShiftStatusDraft, shifts[0].Status)
+
+	var bound int64
+	require.NoError(t, db.Model(&models.ShiftRequest{}).Where("shift_id = ?", shifts[0].ID).Count(&bound).Error)
+	assert.Equal(t, int64(2), bound)
+
+	r3 := createPlanRequest(t, db, "T1", base.Add(20*time.Minute), 0)
+	_, err = svc.CommitPlan([]PlannedShift{{DriverID: driver.ID, DepartureTime: base, RequestIDs: []uint{r3.ID}}})
+	var overlap *DriverOverlapError
+	assert.ErrorAs(t, err, &overlap)
+
+	_, err = svc.CommitPlan([]PlannedShift{{DriverID: driver.ID, DepartureTime: base.Add(4 * time.Hour), RequestIDs: []uint{r3.ID, r1.ID}}})
+	assert.ErrorIs(t, err, ErrRequestNotPending)
+
+	var reloaded models.Request
+	require.NoError(t, db.First(&reloaded, r3.ID).Error)
+	assert.Equal(t, models.RequestStatusPending, reloaded.Status)
+	var shiftCount int64
+	require.NoError(t, db.Model(&models.Shift{}).Count(&shiftCount).Error)
+	assert.Equal(t, int64(1), shiftCount)
+}
+
+func TestAdminService_CommitPlanCapacityWarnings(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+
+	driver := models.Driver{Name: "d", CarModel: "Sedan", MaxSeats: 1, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	r1 := createPlanRequest(t, db, "T1", base, 1)
+	r2 := createPlanRequest(t, db, "T1", base.Add(10*time.Minute), 1)
+
+	// 预览后手工把两人塞进一座车，warn 策略下提交成功并返回提示。
+	shifts, err := svc.CommitPlan([]PlannedShift{{DriverID: driver.ID, DepartureTime: base.Add(10 * time.Minute), EstimatedMinutes: 90, RequestIDs: []uint{r1.ID, r2.ID}}})
+	require.NoError(t, err)
+	require.Len(t, shifts, 1)
+	assert.Equal(t, 90, shifts[0].EstimatedMinutes)
+	assert.Equal(t, []RequestWarning{{RequestID: r2.ID, Warning: "capacity_overload"}}, shifts[0].RequestWarnings)
+
+	_, err = svc.CommitPlan([]PlannedShift{{DriverID: driver.ID, DepartureTime: base.Add(6 * time.Hour), EstimatedMinutes: MaxShiftMinutes + 1}})
+	assert.ErrorIs(t, err, ErrInvalidShiftMinutes)
+}
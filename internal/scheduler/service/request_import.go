@@ -0,0 +1,462 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"pickup/internal/scheduler/events"
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxImportRows 单次导入的数据行上限。
+const MaxImportRows = 2000
+
+// importOpenIDPrefix 导入时按手机号创建的占位用户的 open_id 前缀，学生绑定同一手机号后由 BindPhone 认领。
+const importOpenIDPrefix = "import:"
+
+const (
+	ImportRowCreated = "created"
+	ImportRowExists  = "exists"
+	ImportRowInvalid = "invalid"
+)
+
+var (
+	ErrImportEmpty       = errors.New("csv has no header row")
+	ErrImportTooManyRows = fmt.Errorf("csv has more than %d rows", MaxImportRows)
+	ErrImportHasErrors   = errors.New("import has invalid rows, nothing was written")
+
+	errImportDryRun = errors.New("dry run")
+
+	flightNoPattern = regexp.MustCompile(`^([A-Z][A-Z0-9]|[0-9][A-Z])[A-Z]?[0-9]{1,4}[A-Z]?$`)
+)
+
+// importFields 可导入的字段，required 为 true 的字段必须出现在表头中。
+var importFields = []struct {
+	name     string
+	required bool
+}{
+	{"name", true},
+	{"phone", true},
+	{"flight_no", true},
+	{"arrival_date", true},
+	{"terminal", true},
+	{"expected_arrival_time", false},
+	{"airport", false},
+	{"passengers", false},
+	{"checked_bags", false},
+	{"carry_on_bags", false},
+}
+
+// ImportOptions Mapping 为 字段 → CSV 表头，未指定的字段按同名表头（忽略大小写）匹配。
+type ImportOptions struct {
+	Mapping map[string]string
+	DryRun  bool
+}
+
+// ImportRowResult Line 为 CSV 中的行号（表头为第 1 行）。
+type ImportRowResult struct {
+	Line      int      `json:"line"`
+	Status    string   `json:"status"`
+	RequestID uint     `json:"request_id,omitempty"`
+	UserID    uint     `json:"user_id,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
+
+	newUser bool
+}
+
+type ImportReport struct {
+	DryRun   bool              `json:"dry_run"`
+	Total    int               `json:"total"`
+	Created  int               `json:"created"`
+	Existing int               `json:"existing"`
+	Invalid  int               `json:"invalid"`
+	Rows     []ImportRowResult `json:"rows"`
+}
+
+// importRow 一行校验通过后的数据。
+type importRow struct {
+	name        string
+	phone       string
+	flightNo    string
+	arrivalDate time.Time
+	arrivalTime *time.Time
+	airport     string
+	terminal    string
+	passengers  int
+	checkedBags int
+	carryOnBags int
+}
+
+// ImportRequests 从 CSV 批量导入接机需求。每行在同一事务内处理：按手机号找到或创建占位学生，
+// 同一活动内已有相同航班与到达日期的需求视为已导入，因此重复导入同一文件不会产生新记录。
+// 任一行无效时整批回滚；DryRun 时执行同样的校验与写入后回滚，报告与真实导入一致。
+func (s *AdminService) ImportRequests(r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrImportEmpty
+	}
+	if len(records)-1 > MaxImportRows {
+		return nil, ErrImportTooManyRows
+	}
+	columns, err := importColumns(records[0], opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	campaign, err := s.importCampaign()
+	if err != nil {
+		return nil, err
+	}
+	var campaignID *uint
+	if campaign != nil {
+		campaignID = &campaign.ID
+	}
+	buffers, err := LoadTerminalBuffers(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{DryRun: opts.DryRun, Rows: make([]ImportRowResult, 0, len(records)-1)}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i, record := range records[1:] {
+			result := ImportRowResult{Line: i + 2}
+			row, errs := parseImportRow(record, columns, campaign, s.loc)
+			if len(errs) == 0 {
+				errs = s.importRow(tx, row, campaignID, buffers, &result)
+			}
+			if len(errs) > 0 {
+				result.Status = ImportRowInvalid
+				result.Errors = errs
+				report.Invalid++
+			} else if result.Status == ImportRowCreated {
+				report.Created++
+			} else {
+				report.Existing++
+			}
+			report.Rows = append(report.Rows, result)
+		}
+		report.Total = len(report.Rows)
+		if report.Invalid > 0 {
+			return ErrImportHasErrors
+		}
+		if opts.DryRun {
+			return errImportDryRun
+		}
+		return nil
+	})
+	if err == nil {
+		for _, row := range report.Rows {
+			if row.Status == ImportRowCreated {
+				s.broker.Publish(events.RequestCreated, map[string]any{"request_id": row.RequestID, "user_id": row.UserID})
+			}
+		}
+		return report, nil
+	}
+	if !errors.Is(err, errImportDryRun) && !errors.Is(err, ErrImportHasErrors) {
+		return nil, err
+	}
+	report.clearRolledBackIDs()
+	if opts.DryRun {
+		return report, nil
+	}
+	return report, err
+}
+
+// clearRolledBackIDs 事务回滚后，本次新建的需求与占位用户并未写入，去掉报告中对应的 ID。
+func (r *ImportReport) clearRolledBackIDs() {
+	requests := make(map[uint]bool)
+	users := make(map[uint]bool)
+	for _, row := range r.Rows {
+		if row.Status == ImportRowCreated {
+			requests[row.RequestID] = true
+		}
+		if row.newUser {
+			users[row.UserID] = true
+		}
+	}
+	for i := range r.Rows {
+		if requests[r.Rows[i].RequestID] {
+			r.Rows[i].RequestID = 0
+		}
+		if users[r.Rows[i].UserID] {
+			r.Rows[i].UserID = 0
+		}
+	}
+}
+
+// importCampaign 导入目标活动：显式指定或当前活动；已关闭的活动不接受导入。
+func (s *AdminService) importCampaign() (*models.Campaign, error) {
+	campaignID, err := s.campaignScope()
+	if err != nil || campaignID == nil {
+		return nil, err
+	}
+	var campaign models.Campaign
+	if err := s.db.First(&campaign, *campaignID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCampaignNotFound
+		}
+		return nil, err
+	}
+	if campaign.Status == models.CampaignStatusClosed {
+		return nil, ErrCampaignClosed
+	}
+	return &campaign, nil
+}
+
+// importColumns 返回 字段 → 列下标。
+func importColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if _, dup := index[h]; !dup {
+			index[h] = i
+		}
+	}
+	known := make(map[string]bool, len(importFields))
+	for _, f := range importFields {
+		known[f.name] = true
+	}
+	for field := range mapping {
+		if !known[field] {
+			return nil, fmt.Errorf("unknown import field %q", field)
+		}
+	}
+
+	columns := make(map[string]int, len(importFields))
+	for _, f := range importFields {
+		source := f.name
+		if mapped, ok := mapping[f.name]; ok {
+			source = mapped
+		}
+		col, ok := index[strings.ToLower(strings.TrimSpace(source))]
+		if !ok {
+			if f.required {
+				return nil, fmt.Errorf("column %q for %s not found", source, f.name)
+			}
+			continue
+		}
+		columns[f.name] = col
+	}
+	return columns, nil
+}
+
+// parseImportRow 校验一行并返回全部错误，便于一次改完。
+func parseImportRow(record []string, columns map[string]int, campaign *models.Campaign, loc *time.Location) (importRow, []string) {
+	value := func(field string) string {
+		col, ok := columns[field]
+		if !ok || col >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[col])
+	}
+	var row importRow
+	var errs []string
+
+	row.name = value("name")
+	if row.name == "" {
+		errs = append(errs, "name is required")
+	} else if len([]rune(row.name)) > 64 {
+		errs = append(errs, "name is longer than 64 characters")
+	}
+
+	var ok bool
+	if row.phone, ok = normalizeImportPhone(value("phone")); !ok {
+		errs = append(errs, "phone must be 6-20 digits")
+	}
+	if row.flightNo, ok = normalizeFlightNo(value("flight_no")); !ok {
+		errs = append(errs, fmt.Sprintf("invalid flight_no %q", value("flight_no")))
+	}
+
+	arrivalDate, err := parseImportDate(value("arrival_date"))
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("invalid arrival_date %q, want YYYY-MM-DD", value("arrival_date")))
+	} else {
+		row.arrivalDate = arrivalDate
+		if campaign != nil && (arrivalDate.Before(dateOnly(campaign.StartDate)) || arrivalDate.After(dateOnly(campaign.EndDate))) {
+			errs = append(errs, ErrArrivalOutsideCampaign.Error())
+		}
+		if raw := value("expected_arrival_time"); raw != "" {
+			if at, err := parseImportTime(raw, arrivalDate, loc); err != nil {
+				errs = append(errs, fmt.Sprintf("invalid expected_arrival_time %q", raw))
+			} else {
+				row.arrivalTime = &at
+			}
+		}
+	}
+
+	row.airport = strings.ToUpper(value("airport"))
+	row.terminal = value("terminal")
+	if row.terminal == "" {
+		errs = append(errs, "terminal is required")
+	}
+
+	row.passengers = 1
+	if raw := value("passengers"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || !validPassengers(n) {
+			errs = append(errs, ErrInvalidPassengers.Error())
+		} else {
+			row.passengers = n
+		}
+	}
+	for _, bag := range []struct {
+		field string
+		dst   *int
+	}{{"checked_bags", &row.checkedBags}, {"carry_on_bags", &row.carryOnBags}} {
+		raw := value(bag.field)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			errs = append(errs, bag.field+" must be a non-negative integer")
+			continue
+		}
+		*bag.dst = n
+	}
+	return row, errs
+}
+
+// importRow 写入一行：找到或创建占位学生，同一活动内已有相同航班与日期的需求时视为已导入。
+func (s *AdminService) importRow(tx *gorm.DB, row importRow, campaignID *uint, buffers *TerminalBuffers, result *ImportRowResult) []string {
+	user, created, err := s.importUser(tx, row)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	result.UserID = user.ID
+	result.newUser = created
+
+	var existing models.Request
+	err = tx.Scopes(inCampaign("campaign_id", campaignID)).
+		Where("user_id = ? AND status <> ?", user.ID, models.RequestStatusCanceled).
+		Order("id ASC").Limit(1).Find(&existing).Error
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if existing.ID != 0 {
+		if existing.FlightNo != row.flightNo || !dateOnly(existing.ArrivalDate).Equal(row.arrivalDate) {
+			return []string{fmt.Sprintf("phone already has request %d with flight %s", existing.ID, existing.FlightNo)}
+		}
+		result.Status = ImportRowExists
+		result.RequestID = existing.ID
+		return nil
+	}
+
+	airport, buffer := buffers.Lookup(row.airport, row.terminal)
+	req := models.Request{
+		UserID:         user.ID,
+		FlightNo:       row.flightNo,
+		ArrivalDate:    row.arrivalDate,
+		Airport:        airport,
+		Terminal:       row.terminal,
+		Passengers:     row.passengers,
+		CheckedBags:    row.checkedBags,
+		CarryOnBags:    row.carryOnBags,
+		Status:         models.RequestStatusPending,
+		ArrivalTimeAPI: row.arrivalTime,
+		PickupBuffer:   buffer,
+		CampaignID:     campaignID,
+	}
+	if row.arrivalTime != nil {
+		pickup := row.arrivalTime.Add(time.Duration(buffer) * time.Minute)
+		req.CalcPickupTime = &pickup
+	}
+	if err := tx.Omit(clause.Associations).Create(&req).Error; err != nil {
+		return []string{err.Error()}
+	}
+	if err := recordAudit(tx, s.actor, "request.import", auditEntityRequest, req.ID, nil, req); err != nil {
+		return []string{err.Error()}
+	}
+	result.Status = ImportRowCreated
+	result.RequestID = req.ID
+	return nil
+}
+
+// importUser 按手机号查找用户，没有时创建占位学生；手机号属于非学生账号时拒绝。
+func (s *AdminService) importUser(tx *gorm.DB, row importRow) (*models.User, bool, error) {
+	var user models.User
+	if err := tx.Where("phone = ?", row.phone).Order("id ASC").Limit(1).Find(&user).Error; err != nil {
+		return nil, false, err
+	}
+	if user.ID != 0 {
+		if user.Role != models.UserRoleStudent {
+			return nil, false, fmt.Errorf("phone belongs to a %s account", user.Role)
+		}
+		return &user, false, nil
+	}
+	user = models.User{
+		OpenID: importOpenIDPrefix + row.phone,
+		Name:   row.name,
+		Phone:  row.phone,
+		Role:   models.UserRoleStudent,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return nil, false, err
+	}
+	if err := recordAudit(tx, s.actor, "user.import", auditEntityUser, user.ID, nil, user); err != nil {
+		return nil, false, err
+	}
+	return &user, true, nil
+}
+
+// normalizeImportPhone 去掉空格、横线、括号与 +86 前缀，与微信返回的 purePhoneNumber 对齐。
+func normalizeImportPhone(raw string) (string, bool) {
+	phone := normalizePhone(strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')':
+			return -1
+		}
+		return r
+	}, raw))
+	digits := strings.TrimPrefix(phone, "+")
+	if len(digits) < 6 || len(digits) > 20 {
+		return "", false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	return phone, true
+}
+
+// normalizeFlightNo 转大写并去掉空格，如 "ua 881" → "UA881"。
+func normalizeFlightNo(raw string) (string, bool) {
+	flightNo := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(raw), " ", ""))
+	return flightNo, flightNoPattern.MatchString(flightNo)
+}
+
+func parseImportDate(raw string) (time.Time, error) {
+	return time.Parse("2006-01-02", strings.ReplaceAll(raw, "/", "-"))
+}
+
+// parseImportTime 接受完整日期时间，或只有时分（与 arrival_date 组合），均按 loc 解释。
+func parseImportTime(raw string, arrivalDate time.Time, loc *time.Location) (time.Time, error) {
+	raw = strings.ReplaceAll(raw, "/", "-")
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, raw, loc); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return time.Date(arrivalDate.Year(), arrivalDate.Month(), arrivalDate.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", raw)
+}
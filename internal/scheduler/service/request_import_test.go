@@ -0,0 +1,194 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const importCSV = "\ufeff姓名,手机,Flight_No,arrival_date,terminal,expected_arrival_time,checked_bags\n" +
+	"Li,+86 138-0000-0000,ua 881,2026/08/20,T5,14:30,2\n" +
+	"Wang,13900000000,AA100,2026-08-21,T3,2026-08-21 09:05,1\n"
+
+var importMapping = map[string]string{"name": "姓名", "phone": "手机"}
+
+func TestAdminService_ImportRequests(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC).WithActor(Actor{UserID: 1, Role: "admin"})
+
+	// 预演：报告与真实导入一致，但不写入
+	report, err := svc.ImportRequests(strings.NewReader(importCSV), ImportOptions{Mapping: importMapping, DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 2, report.Created)
+	assert.Zero(t, report.Rows[0].RequestID)
+	var count int64
+	require.NoError(t, db.Model(&models.Request{}).Count(&count).Error)
+	assert.Zero(t, count)
+
+	report, err = svc.ImportRequests(strings.NewReader(importCSV), ImportOptions{Mapping: importMapping})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Total)
+	assert.Equal(t, 2, report.Created)
+	require.NotZero(t, report.Rows[0].RequestID)
+
+	var req models.Request
+	require.NoError(t, db.Preload("User").First(&req, report.Rows[0].RequestID).Error)
+	assert.Equal(t, "UA881", req.FlightNo)
+	assert.Equal(t, 2, req.CheckedBags)
+	assert.Equal(t, 1, req.Passengers)
+	require.NotNil(t, req.ArrivalTimeAPI)
+	assert.Equal(t, time.Date(2026, 8, 20, 14, 30, 0, 0, time.UTC), req.ArrivalTimeAPI.UTC())
+	require.NotNil(t, req.User)
+	assert.Equal(t, "13800000000", req.User.Phone)
+	assert.Equal(t, "import:13800000000", req.User.OpenID)
+	assert.Equal(t, "Li", req.User.Name)
+
+	// 重复导入同一文件不产生新记录
+	again, err := svc.ImportRequests(strings.NewReader(importCSV), ImportOptions{Mapping: importMapping})
+	require.NoError(t, err)
+	assert.Equal(t, 0, again.Created)
+	assert.Equal(t, 2, again.Existing)
+	assert.Equal(t, report.Rows[0].RequestID, again.Rows[0].RequestID)
+	require.NoError(t, db.Model(&models.Request{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+	require.NoError(t, db.Model(&models.User{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+
+	var audits int64
+	require.NoError(t, db.Model(&models.AuditLog{}).Where("action IN ?", []string{"request.import", "user.import"}).Count(&audits).Error)
+	assert.Equal(t, int64(4), audits)
+}
+
+func TestAdminService_ImportRequestsRejectsInvalidRows(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+	require.NoError(t, db.Create(&models.User{OpenID: "staff", Name: "s", Phone: "13700000000", Role: models.UserRoleStaff}).Error)
+
+	csv := "name,phone,flight_no,arrival_date,terminal,passengers,checked_bags\n" +
+		"Li,13800000000,UA881,2026-08-20,T5,1,0\n" +
+		",12ab,??,2026-13-01,,9,-1\n" +
+		"Staff,13700000000,UA881,2026-08-20,T5,1,0\n" +
+		"Li,13800000000,DL7,2026-08-20,T5,1,0\n"
+	report, err := svc.ImportRequests(strings.NewReader(csv), ImportOptions{})
+	assert.ErrorIs(t, err, ErrImportHasErrors)
+	require.NotNil(t, report)
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 3, report.Invalid)
+	assert.Equal(t, ImportRowCreated, report.Rows[0].Status)
+	assert.Zero(t, report.Rows[0].RequestID)
+	assert.Zero(t, report.Rows[0].UserID)
+	assert.Equal(t, 3, report.Rows[1].Line)
+	assert.Len(t, report.Rows[1].Errors, 7)
+	assert.Contains(t, report.Rows[2].Errors[0], "staff account")
+	assert.Contains(t, report.Rows[3].Errors[0], "already has request")
+
+	var count int64
+	require.NoError(t, db.Model(&models.Request{}).Count(&count).Error)
+	assert.Zero(t, count)
+	require.NoError(t, db.Model(&models.User{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	_, err = svc.ImportRequests(strings.NewReader("name,phone\n"), ImportOptions{})
+	assert.ErrorContains(t, err, `column "flight_no"`)
+	_, err = svc.ImportRequests(strings.NewReader(importCSV), ImportOptions{Mapping: map[string]string{"email": "x"}})
+	assert.ErrorContains(t, err, "unknown import field")
+	_, err = svc.ImportRequests(strings.NewReader(""), ImportOptions{})
+	assert.ErrorIs(t, err, ErrImportEmpty)
+}
+
+func TestAdminService_ImportRequestsCampaign(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+	campaign := models.Campaign{Name: "fall", StartDate: time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2026, 8, 20, 0, 0, 0, 0, time.UTC), Status: models.CampaignStatusOpen, Active: true}
+	require.NoError(t, db.Create(&campaign).Error)
+
+	report, err := svc.ImportRequests(strings.NewReader(importCSV), ImportOptions{Mapping: importMapping, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, []string{ErrArrivalOutsideCampaign.Error()}, report.Rows[1].Errors)
+
+	require.NoError(t, db.Model(&campaign).Update("status", models.CampaignStatusClosed).Error)
+	_, err = svc.ImportRequests(strings.NewReader(importCSV), ImportOptions{Mapping: importMapping})
+	assert.ErrorIs(t, err, ErrCampaignClosed)
+	_, err = svc.WithCampaign(999).ImportRequests(strings.NewReader(importCSV), ImportOptions{Mapping: importMapping})
+	assert.ErrorIs(t, err, ErrCampaignNotFound)
+}
+
+func TestClaimImportedUser(t *testing.T) {
+	db := newTestDB(t)
+	placeholder := models.User{OpenID: "import:13800000000", Name: "Li", Phone: "13800000000", Role: models.UserRoleStudent}
+	real := models.User{OpenID: "wx-1", Name: "wx_user", Phone: "13800000000", Role: models.UserRoleStudent}
+	require.NoError(t, db.Create(&placeholder).Error)
+	require.NoError(t, db.Create(&real).Error)
+	req := models.Request{UserID: placeholder.ID, FlightNo: "UA881", ArrivalDate: time.Now(), Terminal: "T5"}
+	require.NoError(t, db.Omit("User").Create(&req).Error)
+
+	require.NoError(t, claimImportedUser(db, zap.NewNop(), real.ID, "13800000000"))
+	require.NoError(t, db.First(&req, req.ID).Error)
+	assert.Equal(t, real.ID, req.UserID)
+	require.NoError(t, db.First(&real, real.ID).Error)
+	assert.Equal(t, "Li", real.Name)
+	assert.ErrorIs(t, db.First(&models.User{}, placeholder.ID).Error, gorm.ErrRecordNotFound)
+}
+
+func TestClaimImportedUserDuplicates(t *testing.T) {
+	db := newTestDB(t)
+	campaign := uint(1)
+	claim := func(phone string, importedStatus, ownStatus models.RequestStatus) (models.User, models.Request, models.Request) {
+		t.Helper()
+		placeholder := models.User{OpenID: "import:" + phone, Name: "Li", Phone: phone, Role: models.UserRoleStudent}
+		real := models.User{OpenID: "wx-" + phone, Name: "wx_user", Phone: phone, Role: models.UserRoleStudent}
+		require.NoError(t, db.Create(&placeholder).Error)
+		require.NoError(t, db.Create(&real).Error)
+		imported := models.Request{UserID: placeholder.ID, FlightNo: "UA881", ArrivalDate: time.Now(), Terminal: "T5", Status: importedStatus, CampaignID: &campaign}
+		own := models.Request{UserID: real.ID, FlightNo: "UA881", ArrivalDate: time.Now(), Terminal: "T5", Status: ownStatus, CampaignID: &campaign}
+		require.NoError(t, db.Omit("User").Create(&imported).Error)
+		require.NoError(t, db.Omit("User").Create(&own).Error)
+		require.NoError(t, claimImportedUser(db, zap.NewNop(), real.ID, phone))
+		require.NoError(t, db.First(&imported, imported.ID).Error)
+		require.NoError(t, db.First(&own, own.ID).Error)
+		return placeholder, imported, own
+	}
+
+	// 都为 pending：取消导入的一条，其余照常认领。
+	placeholder, imported, own := claim("13800000001", models.RequestStatusPending, models.RequestStatusPending)
+	assert.Equal(t, models.RequestStatusCanceled, imported.Status)
+	assert.Equal(t, own.UserID, imported.UserID)
+	assert.Equal(t, models.RequestStatusPending, own.Status)
+	assert.ErrorIs(t, db.First(&models.User{}, placeholder.ID).Error, gorm.ErrRecordNotFound)
+
+	// 导入的一条已排班：保留它，取消本人 pending 的一条。
+	_, imported, own = claim("13800000002", models.RequestStatusAssigned, models.RequestStatusPending)
+	assert.Equal(t, models.RequestStatusAssigned, imported.Status)
+	assert.Equal(t, own.UserID, imported.UserID)
+	assert.Equal(t, models.RequestStatusCanceled, own.Status)
+
+	// 两条都已排班：放弃认领，占位用户与需求保持不变。
+	placeholder, imported, own = claim("13800000003", models.RequestStatusPublished, models.RequestStatusAssigned)
+	assert.Equal(t, placeholder.ID, imported.UserID)
+	assert.Equal(t, models.RequestStatusPublished, imported.Status)
+	assert.Equal(t, models.RequestStatusAssigned, own.Status)
+	require.NoError(t, db.First(&models.User{}, placeholder.ID).Error)
+
+	// 不同活动的需求互不影响。
+	other := uint(2)
+	placeholder = models.User{OpenID: "import:13800000004", Name: "Li", Phone: "13800000004", Role: models.UserRoleStudent}
+	real := models.User{OpenID: "wx-13800000004", Name: "wx_user", Phone: "13800000004", Role: models.UserRoleStudent}
+	require.NoError(t, db.Create(&placeholder).Error)
+	require.NoError(t, db.Create(&real).Error)
+	prev := models.Request{UserID: placeholder.ID, FlightNo: "UA881", ArrivalDate: time.Now(), Terminal: "T5", Status: models.RequestStatusPending, CampaignID: &other}
+	require.NoError(t, db.Omit("User").Create(&prev).Error)
+	require.NoError(t, db.Omit("User").Create(&models.Request{UserID: real.ID, FlightNo: "UA882", ArrivalDate: time.Now(), Terminal: "T5", Status: models.RequestStatusPending, CampaignID: &campaign}).Error)
+	require.NoError(t, claimImportedUser(db, zap.NewNop(), real.ID, "13800000004"))
+	require.NoError(t, db.First(&prev, prev.ID).Error)
+	assert.Equal(t, real.ID, prev.UserID)
+	assert.Equal(t, models.RequestStatusPending, prev.Status)
+}
@@ -20,7 +20,7 @@ import (
 func TestAdminService_ErrorBranchesAndAssignStudent(t *testing.T) {
 	t.Run("assign student delegate", func(t *testing.T) {
 		db := newTestDB(t)
-		svc := NewAdminService(db, NewShiftAssignmentService(db))
+		svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
 
 		driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 5, MaxChecked: 5, MaxCarryOn: 5}
 		require.NoError(t, db.Create(&driver).Error)
@@ -29,14 +29,14 @@ func TestAdminService_ErrorBranchesAndAssignStudent(t *testing.T) {
 		req := models.Request{UserID: 1, FlightNo: "AA1", ArrivalDate: time.Now(), Terminal: "T1", Status: models.RequestStatusPending}
 		require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
 
-		res, err := svc.AssignStudent(shift.ID, req.ID)
+		res, err := svc.AssignStudent(shift.ID, req.ID, "")
 		require.NoError(t, err)
 		assert.Empty(t, res.Warning)
 	})
 
 	t.Run("create driver error", func(t *testing.T) {
 		db := newTestDB(t)
-		svc := NewAdminService(db, NewShiftAssignmentService(db))
+		svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
 		require.NoError(t, db.Exec("DROP TABLE drivers").Error)
 		_, err := svc.CreateDriver(DriverDTO{Name: "d", CarModel: "SUV", MaxSeats: 1, MaxChecked: 1, MaxCarryOn: 1})
 		assert.Error(t, err)
@@ -44,15 +44,15 @@ func TestAdminService_ErrorBranchesAndAssignStudent(t *testing.T) {
 
 	t.Run("create shift error", func(t *testing.T) {
 		db := newTestDB(t)
-		svc := NewAdminService(db, NewShiftAssignmentService(db))
+		svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
 		require.NoError(t, db.Exec("DROP TABLE shifts").Error)
-		_, err := svc.CreateShift(1, time.Now())
+		_, err := svc.CreateShift(1, time.Now(), 0)
 		assert.Error(t, err)
 	})
 
 	t.Run("remove student error", func(t *testing.T) {
 		db := newTestDB(t)
-		svc := NewAdminService(db, NewShiftAssignmentService(db))
+		svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
 		require.NoError(t, db.Exec("DROP TABLE shift_requests").Error)
 		err := svc.RemoveStudent(1, 1)
 		assert.Error(t, err)
@@ -60,7 +60,7 @@ func TestAdminService_ErrorBranchesAndAssignStudent(t *testing.T) {
 
 	t.Run("publish shift error", func(t *testing.T) {
 		db := newTestDB(t)
-		svc := NewAdminService(db, NewShiftAssignmentService(db))
+		svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
 		require.NoError(t, db.Exec("DROP TABLE shifts").Error)
 		err := svc.PublishShift(1)
 		assert.Error(t, err)
@@ -68,8 +68,8 @@ func TestAdminService_ErrorBranchesAndAssignStudent(t *testing.T) {
 
 	t.Run("assign staff not found", func(t *testing.T) {
 		db := newTestDB(t)
-		svc := NewAdminService(db, NewShiftAssignmentService(db))
-		err := svc.AssignStaff(1, 999)
+		svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+		_, err := svc.AssignStaff(1, 999, "")
 		assert.Error(t, err)
 	})
 }
@@ -155,7 +155,7 @@ func TestAuthService_ErrorBranches(t *testing.T) {
 func TestShiftAssignmentService_ExtraBranches(t *testing.T) {
 	t.Run("non-overload branch", func(t *testing.T) {
 		db := newTestDB(t)
-		svc := NewShiftAssignmentService(db)
+		svc := NewShiftAssignmentService(db, nil)
 
 		driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 5, MaxChecked: 5, MaxCarryOn: 5}
 		require.NoError(t, db.Create(&driver).Error)
@@ -164,14 +164,14 @@ func TestShiftAssignmentService_ExtraBranches(t *testing.T) {
 		req := models.Request{UserID: 1, FlightNo: "A1", ArrivalDate: time.Now(), Terminal: "T1", Status: models.RequestStatusPending}
 		require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
 
-		res, err := svc.AssignStudentToShift(context.Background(), shift.ID, req.ID)
+		res, err := svc.AssignStudentToShift(context.Background(), shift.ID, req.ID, "")
 		require.NoError(t, err)
 		assert.Empty(t, res.Warning)
 	})
 
 	t.Run("count query error branch", func(t *testing.T) {
 		db := newTestDB(t)
-		svc := NewShiftAssignmentService(db)
+		svc := NewShiftAssignmentService(db, nil)
 
 		driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 5, MaxChecked: 5, MaxCarryOn: 5}
 		require.NoError(t, db.Create(&driver).Error)
@@ -181,7 +181,7 @@ func TestShiftAssignmentService_ExtraBranches(t *testing.T) {
 		require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
 		require.NoError(t, db.Exec("DROP TABLE shift_requests").Error)
 
-		_, err := svc.AssignStudentToShift(context.Background(), shift.ID, req.ID)
+		_, err := svc.AssignStudentToShift(context.Background(), shift.ID, req.ID, "")
 		assert.Error(t, err)
 	})
 }
@@ -189,7 +189,7 @@ func TestShiftAssignmentService_ExtraBranches(t *testing.T) {
 func TestStudentService_ExtraBranches(t *testing.T) {
 	t.Run("list requests db error", func(t *testing.T) {
 		db := newTestDB(t)
-		svc := NewStudentService(db)
+		svc := NewStudentService(db, nil, time.UTC)
 		require.NoError(t, db.Exec("DROP TABLE requests").Error)
 		_, err := svc.ListMyRequests(1)
 		assert.Error(t, err)
@@ -197,7 +197,7 @@ func TestStudentService_ExtraBranches(t *testing.T) {
 
 	t.Run("update parse and not found errors", func(t *testing.T) {
 		db := newTestDB(t)
-		svc := NewStudentService(db)
+		svc := NewStudentService(db, nil, time.UTC)
 
 		_, err := svc.UpdatePendingRequest(1, 999, UpdateRequestInput{})
 		assert.Error(t, err)
@@ -3,7 +3,10 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 
+	"pickup/internal/config"
 	"pickup/internal/scheduler/models"
 
 	"gorm.io/gorm"
@@ -11,109 +14,166 @@ import (
 )
 
 var (
-	ErrRequestNotPending = errors.New("request status is not pending")
-	ErrShiftNotFound     = errors.New("shift not found")
-	ErrRequestNotFound   = errors.New("request not found")
+	ErrRequestNotPending  = errors.New("request status is not pending")
+	ErrShiftNotFound      = errors.New("shift not found")
+	ErrRequestNotFound    = errors.New("request not found")
+	ErrShiftNotAssignable = errors.New("shift is not open for assignment")
+	ErrReasonRequired     = errors.New("overload reason is required by capacity policy")
 )
 
+// CapacityExceededError capacity_policy=reject 时超载分配被拒绝。
+type CapacityExceededError struct {
+	Seats, MaxSeats     int
+	Checked, MaxChecked int
+	CarryOn, MaxCarryOn int
+}
+
+func (e *CapacityExceededError) Error() string {
+	return fmt.Sprintf("shift capacity exceeded: seats %d/%d, checked %d/%d, carry-on %d/%d",
+		e.Seats, e.MaxSeats, e.Checked, e.MaxChecked, e.CarryOn, e.MaxCarryOn)
+}
+
 type AssignStudentResult struct {
-	Warning string `json:"warning,omitempty"`
+	// Status 分配后的需求状态：已发布班次直接为 published，否则为 assigned。
+	Status  models.RequestStatus `json:"status"`
+	Warning string               `json:"warning,omitempty"`
+	Reason  string               `json:"reason,omitempty"`
 }
 
 type ShiftAssignmentService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	policy models.CapacityPolicy
+}
+
+// NewShiftAssignmentService cfg 为 nil 或策略无效时按 warn 处理。
+func NewShiftAssignmentService(db *gorm.DB, cfg *config.SchedulerConfig) *ShiftAssignmentService {
+	policy := models.CapacityPolicyWarn
+	if cfg != nil && validCapacityPolicy(models.CapacityPolicy(cfg.CapacityPolicy)) {
+		policy = models.CapacityPolicy(cfg.CapacityPolicy)
+	}
+	return &ShiftAssignmentService{db: db, policy: policy}
 }
 
-func NewShiftAssignmentService(db *gorm.DB) *ShiftAssignmentService {
-	return &ShiftAssignmentService{db: db}
+// policyFor 班次级策略优先于全局策略。
+func (s *ShiftAssignmentService) policyFor(shift models.Shift) models.CapacityPolicy {
+	if shift.CapacityPolicy != nil {
+		return *shift.CapacityPolicy
+	}
+	return s.policy
+}
+
+func validCapacityPolicy(p models.CapacityPolicy) bool {
+	switch p {
+	case models.CapacityPolicyWarn, models.CapacityPolicyReject, models.CapacityPolicyAllowWithReason:
+		return true
+	}
+	return false
 }
 
 // AssignStudentToShift 将学生需求加入班次。
 // 核心保障：
 // 1. 锁定 Shift + Request 行（FOR UPDATE）
 // 2. 校验 Request 为 pending
-// 3. 原子写入 shift_requests + Request.status=assigned
-// 4. 若软超载返回 warning=capacity_overload，但仍提交事务
-func (s *ShiftAssignmentService) AssignStudentToShift(ctx context.Context, shiftID, requestID uint) (AssignStudentResult, error) {
-	result := AssignStudentResult{}
-
+// 3. 原子写入 shift_requests + Request.status=assigned（已发布班次为 published）
+// 4. 超载时按容量策略处理：warn 返回 warning=capacity_overload 并提交；
+// reject 返回 *CapacityExceededError；allow_with_reason 需提供 reason 并随绑定记录保存
+func (s *ShiftAssignmentService) AssignStudentToShift(ctx context.Context, shiftID, requestID uint, reason string) (AssignStudentResult, error) {
+	var result AssignStudentResult
 	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var shift models.Shift
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-			Preload("Driver").
-			First(&shift, shiftID).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return ErrShiftNotFound
-			}
-			return err
-		}
-
-		var req models.Request
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-			First(&req, requestID).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return ErrRequestNotFound
-			}
+		res, err := s.assignInTx(tx, shiftID, requestID, reason)
+		if err != nil {
 			return err
 		}
+		result = res
+		return nil
+	})
+	if err != nil {
+		return AssignStudentResult{}, err
+	}
+	return result, nil
+}
 
-		if req.Status != models.RequestStatusPending {
-			return ErrRequestNotPending
-		}
+// assignInTx 在调用方事务内完成分配，供单次分配与批量排班提交复用。
+func (s *ShiftAssignmentService) assignInTx(tx *gorm.DB, shiftID, requestID uint, reason string) (AssignStudentResult, error) {
+	result := AssignStudentResult{}
 
-		var boundRequestCount int64
-		if err := tx.Model(&models.ShiftRequest{}).
-			Where("shift_id = ?", shiftID).
-			Count(&boundRequestCount).Error; err != nil {
-			return err
+	var shift models.Shift
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Driver").
+		First(&shift, shiftID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return result, ErrShiftNotFound
 		}
+		return result, err
+	}
 
-		var staffCount int64
-		if err := tx.Model(&models.ShiftStaff{}).
-			Where("shift_id = ?", shiftID).
-			Count(&staffCount).Error; err != nil {
-			return err
-		}
+	if shift.Status != models.ShiftStatusDraft && shift.Status != models.ShiftStatusPublished {
+		return result, ErrShiftNotAssignable
+	}
 
-		type baggageAggregate struct {
-			Checked int64
-			CarryOn int64
-		}
-		var aggregate baggageAggregate
-		if err := tx.Table("shift_requests sr").
-			Select("COALESCE(SUM(r.checked_bags), 0) AS checked, COALESCE(SUM(r.carry_on_bags), 0) AS carry_on").
-			Joins("JOIN requests r ON r.id = sr.request_id").
-			Where("sr.shift_id = ?", shiftID).
-			Scan(&aggregate).Error; err != nil {
-			return err
+	var req models.Request
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&req, requestID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return result, ErrRequestNotFound
 		}
+		return result, err
+	}
 
-		totalSeats := int(boundRequestCount+1) + int(staffCount)
-		totalChecked := int(aggregate.Checked) + req.CheckedBags
-		totalCarryOn := int(aggregate.CarryOn) + req.CarryOnBags
+	if req.Status != models.RequestStatusPending {
+		return result, ErrRequestNotPending
+	}
+	if !sameCampaign(req.CampaignID, shift.CampaignID) {
+		return result, ErrCampaignMismatch
+	}
 
-		if totalSeats > shift.Driver.MaxSeats || totalChecked > shift.Driver.MaxChecked || totalCarryOn > shift.Driver.MaxCarryOn {
-			result.Warning = "capacity_overload"
-		}
+	usages, err := loadShiftUsage(tx, []uint{shiftID})
+	if err != nil {
+		return result, err
+	}
+	usage := usages[shiftID]
 
-		if err := tx.Table("shift_requests").Create(map[string]any{
-			"shift_id":   shiftID,
-			"request_id": requestID,
-		}).Error; err != nil {
-			return err
-		}
+	totalSeats := usage.seats() + req.Passengers
+	totalChecked := usage.Checked + req.CheckedBags
+	totalCarryOn := usage.CarryOn + req.CarryOnBags
 
-		if err := tx.Table("requests").
-			Where("id = ?", requestID).
-			Update("status", models.RequestStatusAssigned).Error; err != nil {
-			return err
+	binding := map[string]any{
+		"shift_id":   shiftID,
+		"request_id": requestID,
+	}
+	if totalSeats > shift.Driver.MaxSeats || totalChecked > shift.Driver.MaxChecked || totalCarryOn > shift.Driver.MaxCarryOn {
+		switch s.policyFor(shift) {
+		case models.CapacityPolicyReject:
+			return result, &CapacityExceededError{
+				Seats: totalSeats, MaxSeats: shift.Driver.MaxSeats,
+				Checked: totalChecked, MaxChecked: shift.Driver.MaxChecked,
+				CarryOn: totalCarryOn, MaxCarryOn: shift.Driver.MaxCarryOn,
+			}
+		case models.CapacityPolicyAllowWithReason:
+			reason = strings.TrimSpace(reason)
+			if reason == "" {
+				return result, ErrReasonRequired
+			}
+			binding["overload_reason"] = reason
+			result.Reason = reason
 		}
+		result.Warning = "capacity_overload"
+	}
 
-		return nil
-	})
+	if err := tx.Table("shift_requests").Create(binding).Error; err != nil {
+		return result, err
+	}
 
-	if err != nil {
-		return AssignStudentResult{}, err
+	// 已发布班次上的学生与发布时绑定的学生一致，直接进入 published。
+	result.Status = models.RequestStatusAssigned
+	if shift.Status == models.ShiftStatusPublished {
+		result.Status = models.RequestStatusPublished
+	}
+	if err := tx.Table("requests").
+		Where("id = ?", requestID).
+		Updates(map[string]any{"status": result.Status, "version": bumpVersion}).Error; err != nil {
+		return result, err
 	}
+
 	return result, nil
 }
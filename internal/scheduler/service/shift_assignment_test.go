@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"pickup/internal/config"
 	"pickup/internal/scheduler/models"
 
 	"github.com/stretchr/testify/assert"
@@ -14,9 +15,9 @@ import (
 
 func TestShiftAssignmentService_AssignStudentToShift_Errors(t *testing.T) {
 	db := newTestDB(t)
-	svc := NewShiftAssignmentService(db)
+	svc := NewShiftAssignmentService(db, nil)
 
-	_, err := svc.AssignStudentToShift(context.Background(), 999, 1)
+	_, err := svc.AssignStudentToShift(context.Background(), 999, 1, "")
 	assert.ErrorIs(t, err, ErrShiftNotFound)
 
 	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
@@ -24,18 +25,18 @@ func TestShiftAssignmentService_AssignStudentToShift_Errors(t *testing.T) {
 	shift := models.Shift{DriverID: driver.ID, DepartureTime: time.Now(), Status: models.ShiftStatusDraft}
 	require.NoError(t, db.Create(&shift).Error)
 
-	_, err = svc.AssignStudentToShift(context.Background(), shift.ID, 999)
+	_, err = svc.AssignStudentToShift(context.Background(), shift.ID, 999, "")
 	assert.ErrorIs(t, err, ErrRequestNotFound)
 
 	req := models.Request{UserID: 1, FlightNo: "AA1", ArrivalDate: time.Now(), Terminal: "T1", Status: models.RequestStatusAssigned}
 	require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
-	_, err = svc.AssignStudentToShift(context.Background(), shift.ID, req.ID)
+	_, err = svc.AssignStudentToShift(context.Background(), shift.ID, req.ID, "")
 	assert.ErrorIs(t, err, ErrRequestNotPending)
 }
 
 func TestShiftAssignmentService_AssignStudentToShift_SuccessAndOverload(t *testing.T) {
 	db := newTestDB(t)
-	svc := NewShiftAssignmentService(db)
+	svc := NewShiftAssignmentService(db, nil)
 
 	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 1, MaxChecked: 1, MaxCarryOn: 1}
 	require.NoError(t, db.Create(&driver).Error)
@@ -49,7 +50,7 @@ func TestShiftAssignmentService_AssignStudentToShift_SuccessAndOverload(t *testi
 	req := models.Request{UserID: 1, FlightNo: "AA1", ArrivalDate: time.Now(), Terminal: "T1", Status: models.RequestStatusPending, CheckedBags: 1, CarryOnBags: 1}
 	require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
 
-	res, err := svc.AssignStudentToShift(context.Background(), shift.ID, req.ID)
+	res, err := svc.AssignStudentToShift(context.Background(), shift.ID, req.ID, "")
 	require.NoError(t, err)
 	assert.Equal(t, "capacity_overload", res.Warning)
 
@@ -59,3 +60,55 @@ func TestShiftAssignmentService_AssignStudentToShift_SuccessAndOverload(t *testi
 	require.NoError(t, db.First(&updated, req.ID).Error)
 	assert.Equal(t, models.RequestStatusAssigned, updated.Status)
 }
+
+func TestShiftAssignmentService_CapacityPolicies(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewShiftAssignmentService(db, &config.SchedulerConfig{CapacityPolicy: "reject"})
+
+	driver := models.Driver{Name: "d", CarModel: "Sedan", MaxSeats: 1, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	shift := models.Shift{DriverID: driver.ID, DepartureTime: time.Now(), Status: models.ShiftStatusDraft}
+	require.NoError(t, db.Create(&shift).Error)
+
+	reqs := make([]models.Request, 3)
+	for i := range reqs {
+		reqs[i] = models.Request{UserID: uint(i + 1), FlightNo: "AA1", ArrivalDate: time.Now(), Terminal: "T1", Status: models.RequestStatusPending}
+		require.NoError(t, db.Omit(clause.Associations).Create(&reqs[i]).Error)
+	}
+
+	res, err := svc.AssignStudentToShift(context.Background(), shift.ID, reqs[0].ID, "")
+	require.NoError(t, err)
+	assert.Empty(t, res.Warning)
+
+	// 全局 reject：超载返回类型化错误且不落库
+	_, err = svc.AssignStudentToShift(context.Background(), shift.ID, reqs[1].ID, "")
+	var capErr *CapacityExceededError
+	require.ErrorAs(t, err, &capErr)
+	assert.Equal(t, 2, capErr.Seats)
+	assert.Equal(t, 1, capErr.MaxSeats)
+	var pending models.Request
+	require.NoError(t, db.First(&pending, reqs[1].ID).Error)
+	assert.Equal(t, models.RequestStatusPending, pending.Status)
+
+	// 班次覆盖为 allow_with_reason：缺少理由拒绝，提供理由后保存
+	require.NoError(t, db.Model(&shift).Update("capacity_policy", models.CapacityPolicyAllowWithReason).Error)
+	_, err = svc.AssignStudentToShift(context.Background(), shift.ID, reqs[1].ID, "  ")
+	assert.ErrorIs(t, err, ErrReasonRequired)
+	res, err = svc.AssignStudentToShift(context.Background(), shift.ID, reqs[1].ID, "siblings share a seat")
+	require.NoError(t, err)
+	assert.Equal(t, "capacity_overload", res.Warning)
+	assert.Equal(t, "siblings share a seat", res.Reason)
+	var saved models.ShiftRequest
+	require.NoError(t, db.Where("request_id = ?", reqs[1].ID).First(&saved).Error)
+	require.NotNil(t, saved.OverloadReason)
+	assert.Equal(t, "siblings share a seat", *saved.OverloadReason)
+
+	// 班次覆盖为 warn
+	require.NoError(t, db.Model(&shift).Update("capacity_policy", models.CapacityPolicyWarn).Error)
+	res, err = svc.AssignStudentToShift(context.Background(), shift.ID, reqs[2].ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, "capacity_overload", res.Warning)
+	assert.Empty(t, res.Reason)
+
+	assert.Equal(t, models.CapacityPolicyWarn, NewShiftAssignmentService(db, &config.SchedulerConfig{CapacityPolicy: "bogus"}).policy)
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+)
+
+// conflictEarlyThreshold 学生接机时间早于发车超过该阈值时视为冲突（学生需长时间等待）。
+const conflictEarlyThreshold = 2 * time.Hour
+
+type ShiftConflictService struct {
+	db *gorm.DB
+}
+
+func NewShiftConflictService(db *gorm.DB) *ShiftConflictService {
+	return &ShiftConflictService{db: db}
+}
+
+type boundPickup struct {
+	ShiftID        uint
+	RequestID      uint
+	DepartureTime  time.Time
+	CalcPickupTime time.Time
+}
+
+// classifyConflict 判断单个绑定需求与班次发车时间是否冲突。
+func classifyConflict(departure, pickup time.Time) (models.ShiftConflictKind, bool) {
+	if pickup.After(departure) {
+		return models.ShiftConflictLatePickup, true
+	}
+	if departure.Sub(pickup) > conflictEarlyThreshold {
+		return models.ShiftConflictEarlyPickup, true
+	}
+	return "", false
+}
+
+// Detect 检查涉及指定航班（为空时检查全部）的未出发班次，记录新冲突并关闭已消除的冲突。
+func (s *ShiftConflictService) Detect(ctx context.Context, flightNos []string) ([]models.ShiftConflict, error) {
+	var detected []models.ShiftConflict
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		detected, err = detectConflictsInTx(tx, func(db *gorm.DB) *gorm.DB {
+			if len(flightNos) == 0 {
+				return db
+			}
+			return db.Where("r.flight_no IN ?", flightNos)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return detected, nil
+}
+
+// detectConflictsInTx 按 scope 限定的绑定（可引用 sr/s/r 别名）重新判定冲突。
+func detectConflictsInTx(tx *gorm.DB, scope func(*gorm.DB) *gorm.DB) ([]models.ShiftConflict, error) {
+	var pickups []boundPickup
+	if err := tx.Table("shift_requests sr").
+		Select("sr.shift_id, sr.request_id, s.departure_time, r.calc_pickup_time").
+		Joins("JOIN shifts s ON s.id = sr.shift_id").
+		Joins("JOIN requests r ON r.id = sr.request_id").
+		Where("s.status IN ? AND r.calc_pickup_time IS NOT NULL",
+			[]models.ShiftStatus{models.ShiftStatusDraft, models.ShiftStatusPublished}).
+		Scopes(scope).
+		Scan(&pickups).Error; err != nil {
+		return nil, err
+	}
+	if len(pickups) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	detected := make([]models.ShiftConflict, 0)
+	for _, p := range pickups {
+		var open models.ShiftConflict
+		findErr := tx.Where("shift_id = ? AND request_id = ? AND resolved_at IS NULL", p.ShiftID, p.RequestID).
+			First(&open).Error
+		if findErr != nil && !errors.Is(findErr, gorm.ErrRecordNotFound) {
+			return nil, findErr
+		}
+		hasOpen := findErr == nil
+
+		kind, conflicting := classifyConflict(p.DepartureTime, p.CalcPickupTime)
+		if !conflicting {
+			if hasOpen {
+				if err := tx.Model(&models.ShiftConflict{}).Where("id = ?", open.ID).Update("resolved_at", now).Error; err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		conflict := models.ShiftConflict{
+			ShiftID:        p.ShiftID,
+			RequestID:      p.RequestID,
+			Kind:           kind,
+			CalcPickupTime: p.CalcPickupTime,
+			DepartureTime:  p.DepartureTime,
+			DeltaMinutes:   int(p.CalcPickupTime.Sub(p.DepartureTime) / time.Minute),
+			DetectedAt:     now,
+		}
+		if hasOpen {
+			conflict.ID = open.ID
+			if err := tx.Model(&models.ShiftConflict{}).Where("id = ?", open.ID).Updates(map[string]any{
+				"kind":             conflict.Kind,
+				"calc_pickup_time": conflict.CalcPickupTime,
+				"departure_time":   conflict.DepartureTime,
+				"delta_minutes":    conflict.DeltaMinutes,
+				"detected_at":      conflict.DetectedAt,
+			}).Error; err != nil {
+				return nil, err
+			}
+		} else if err := tx.Omit("Shift", "Request").Create(&conflict).Error; err != nil {
+			return nil, err
+		}
+		detected = append(detected, conflict)
+	}
+	return detected, nil
+}
+
+// ListShiftConflicts 列出仍绑定在班次上的冲突；includeResolved 为 true 时包含已消除记录。
+func (s *AdminService) ListShiftConflicts(includeResolved bool) ([]models.ShiftConflict, error) {
+	campaignID, err := s.campaignScope()
+	if err != nil {
+		return nil, err
+	}
+	query := s.db.
+		Scopes(func(db *gorm.DB) *gorm.DB {
+			if campaignID == nil {
+				return db
+			}
+			return db.Where("shift_id IN (SELECT id FROM shifts WHERE campaign_id = ?)", *campaignID)
+		}).
+		Where("EXISTS (SELECT 1 FROM shift_requests sr WHERE sr.shift_id = shift_conflicts.shift_id AND sr.request_id = shift_conflicts.request_id)")
+	if !includeResolved {
+		query = query.Where("resolved_at IS NULL")
+	}
+	var conflicts []models.ShiftConflict
+	err = query.
+		Preload("Shift.Driver").
+		Preload("Request").
+		Order("departure_time ASC, id ASC").
+		Find(&conflicts).Error
+	return conflicts, err
+}
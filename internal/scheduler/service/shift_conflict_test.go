@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/clause"
+)
+
+func TestShiftConflictService_DetectAndResolve(t *testing.T) {
+	db := newTestDB(t)
+	conflictSvc := NewShiftConflictService(db)
+	adminSvc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+
+	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	departure := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	shift := models.Shift{DriverID: driver.ID, DepartureTime: departure, Status: models.ShiftStatusPublished}
+	require.NoError(t, db.Create(&shift).Error)
+
+	late := departure.Add(40 * time.Minute)
+	early := departure.Add(-3 * time.Hour)
+	fine := departure.Add(-30 * time.Minute)
+	reqLate := models.Request{UserID: 1, FlightNo: "AA1", ArrivalDate: departure, Terminal: "T1", Status: models.RequestStatusPublished, CalcPickupTime: &late}
+	reqEarly := models.Request{UserID: 2, FlightNo: "AA2", ArrivalDate: departure, Terminal: "T1", Status: models.RequestStatusPublished, CalcPickupTime: &early}
+	reqFine := models.Request{UserID: 3, FlightNo: "AA3", ArrivalDate: departure, Terminal: "T1", Status: models.RequestStatusPublished, CalcPickupTime: &fine}
+	for _, r := range []*models.Request{&reqLate, &reqEarly, &reqFine} {
+		require.NoError(t, db.Omit(clause.Associations).Create(r).Error)
+		require.NoError(t, db.Table("shift_requests").Create(map[string]any{"shift_id": shift.ID, "request_id": r.ID}).Error)
+	}
+
+	detected, err := conflictSvc.Detect(context.Background(), nil)
+	require.NoError(t, err)
+	require.Len(t, detected, 2)
+
+	list, err := adminSvc.ListShiftConflicts(false)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	kinds := map[uint]models.ShiftConflictKind{}
+	for _, c := range list {
+		kinds[c.RequestID] = c.Kind
+		require.NotNil(t, c.Shift)
+		require.NotNil(t, c.Shift.Driver)
+	}
+	assert.Equal(t, models.ShiftConflictLatePickup, kinds[reqLate.ID])
+	assert.Equal(t, models.ShiftConflictEarlyPickup, kinds[reqEarly.ID])
+
+	// 再次检测不会重复记录
+	_, err = conflictSvc.Detect(context.Background(), []string{"AA1"})
+	require.NoError(t, err)
+	var total int64
+	require.NoError(t, db.Model(&models.ShiftConflict{}).Count(&total).Error)
+	assert.Equal(t, int64(2), total)
+
+	// 航班恢复正常后冲突被关闭
+	require.NoError(t, db.Model(&models.Request{}).Where("id = ?", reqLate.ID).Update("calc_pickup_time", fine).Error)
+	_, err = conflictSvc.Detect(context.Background(), []string{"AA1"})
+	require.NoError(t, err)
+	list, err = adminSvc.ListShiftConflicts(false)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, reqEarly.ID, list[0].RequestID)
+
+	all, err := adminSvc.ListShiftConflicts(true)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	// 学生被移出班次后冲突不再展示
+	require.NoError(t, adminSvc.RemoveStudent(shift.ID, reqEarly.ID))
+	list, err = adminSvc.ListShiftConflicts(false)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestAdminService_UpdateShiftRedetectsConflicts(t *testing.T) {
+	db := newTestDB(t)
+	adminSvc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+
+	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	departure := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	shift := models.Shift{DriverID: driver.ID, DepartureTime: departure, EstimatedMinutes: 90, Status: models.ShiftStatusPublished}
+	require.NoError(t, db.Create(&shift).Error)
+	pickup := departure.Add(-30 * time.Minute)
+	req := models.Request{UserID: 1, FlightNo: "AA1", ArrivalDate: departure, Terminal: "T1", Status: models.RequestStatusPublished, CalcPickupTime: &pickup}
+	require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+	require.NoError(t, db.Table("shift_requests").Create(map[string]any{"shift_id": shift.ID, "request_id": req.ID}).Error)
+
+	// 发车提前到接机时间之前，学生赶不上。
+	earlier := departure.Add(-time.Hour)
+	_, err := adminSvc.UpdateShift(shift.ID, ShiftUpdateDTO{DepartureTime: &earlier})
+	require.NoError(t, err)
+	list, err := adminSvc.ListShiftConflicts(false)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, models.ShiftConflictLatePickup, list[0].Kind)
+	assert.Equal(t, 30, list[0].DeltaMinutes)
+
+	// 改回原时间后冲突关闭。
+	_, err = adminSvc.UpdateShift(shift.ID, ShiftUpdateDTO{DepartureTime: &departure})
+	require.NoError(t, err)
+	list, err = adminSvc.ListShiftConflicts(false)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
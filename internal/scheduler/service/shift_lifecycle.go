@@ -0,0 +1,209 @@
+package service
+
+import (
+	"errors"
+	"maps"
+	"time"
+
+	"pickup/internal/scheduler/events"
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidShiftTransition = errors.New("invalid shift status transition")
+	ErrShiftPublished         = errors.New("shift is published; pass force=true to delete it")
+	ErrShiftNotDeletable      = errors.New("shift has started and cannot be deleted")
+)
+
+// shiftTransitions 班次状态机：draft → published → in_progress → completed，
+// 出发前（draft/published）可取消。重复发布不是状态迁移，由 PublishShift 单独处理。
+var shiftTransitions = map[models.ShiftStatus][]models.ShiftStatus{
+	models.ShiftStatusDraft:      {models.ShiftStatusPublished, models.ShiftStatusCanceled},
+	models.ShiftStatusPublished:  {models.ShiftStatusInProgress, models.ShiftStatusCanceled},
+	models.ShiftStatusInProgress: {models.ShiftStatusCompleted},
+}
+
+func canTransitionShift(from, to models.ShiftStatus) bool {
+	for _, next := range shiftTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// transitionShiftInTx 锁定班次并校验状态迁移，extra 为随状态一并写入的列（如时间戳），
+// 状态变化以 action 记入审计日志。
+func transitionShiftInTx(tx *gorm.DB, actor Actor, action string, shiftID uint, to models.ShiftStatus, extra map[string]any) (*models.Shift, error) {
+	var shift models.Shift
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shift, shiftID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShiftNotFound
+		}
+		return nil, err
+	}
+	if !canTransitionShift(shift.Status, to) {
+		return nil, ErrInvalidShiftTransition
+	}
+
+	updates := map[string]any{"status": to}
+	for k, v := range extra {
+		updates[k] = v
+	}
+	write := maps.Clone(updates)
+	write["version"] = bumpVersion
+	if err := tx.Model(&models.Shift{}).Where("id = ?", shiftID).Updates(write).Error; err != nil {
+		return nil, err
+	}
+	if err := recordAudit(tx, actor, action, auditEntityShift, shiftID,
+		map[string]any{"status": shift.Status}, updates); err != nil {
+		return nil, err
+	}
+	shift.Status = to
+	shift.Version++
+	return &shift, nil
+}
+
+// StartShift 志愿者出发时将已发布班次标记为进行中。
+func (s *AdminService) StartShift(shiftID uint) (*models.Shift, error) {
+	var shift *models.Shift
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		updated, err := transitionShiftInTx(tx, s.actor, "shift.start", shiftID, models.ShiftStatusInProgress, map[string]any{"started_at": now})
+		if err != nil {
+			return err
+		}
+		updated.StartedAt = &now
+		shift = updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.broker.Publish(events.ShiftUpdated, shiftChangeEvent(shift))
+	return shift, nil
+}
+
+// CompleteShift 接机完成后关闭班次。
+func (s *AdminService) CompleteShift(shiftID uint) (*models.Shift, error) {
+	var shift *models.Shift
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		updated, err := transitionShiftInTx(tx, s.actor, "shift.complete", shiftID, models.ShiftStatusCompleted, map[string]any{"completed_at": now})
+		if err != nil {
+			return err
+		}
+		updated.CompletedAt = &now
+		shift = updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.broker.Publish(events.ShiftUpdated, shiftChangeEvent(shift))
+	return shift, nil
+}
+
+// CancelShift 取消班次，并在同一事务内将已绑定需求释放回 pending（与 RemoveStudent 一致）。
+func (s *AdminService) CancelShift(shiftID uint) (*models.Shift, error) {
+	var shift *models.Shift
+	var released []models.Request
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		updated, err := transitionShiftInTx(tx, s.actor, "shift.cancel", shiftID, models.ShiftStatusCanceled, nil)
+		if err != nil {
+			return err
+		}
+		if released, err = releaseShiftRequestsInTx(tx, shiftID); err != nil {
+			return err
+		}
+		shift = updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.broker.Publish(events.ShiftUpdated, shiftChangeEvent(shift))
+	s.publishReleased(released)
+	return shift, nil
+}
+
+// releaseShiftRequestsInTx 解除班次上所有需求绑定并将其恢复为 pending，返回释放后的需求供提交后广播。
+func releaseShiftRequestsInTx(tx *gorm.DB, shiftID uint) ([]models.Request, error) {
+	var ids []uint
+	if err := tx.Model(&models.ShiftRequest{}).Where("shift_id = ?", shiftID).Order("request_id ASC").Pluck("request_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if err := tx.Table("requests").
+		Where("id IN ?", ids).
+		Updates(map[string]any{"status": models.RequestStatusPending, "version": bumpVersion}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("shift_id = ?", shiftID).Delete(&models.ShiftRequest{}).Error; err != nil {
+		return nil, err
+	}
+	var released []models.Request
+	if err := tx.Select("id", "user_id", "status", "version").Order("id ASC").Find(&released, ids).Error; err != nil {
+		return nil, err
+	}
+	return released, nil
+}
+
+func (s *AdminService) publishReleased(released []models.Request) {
+	for i := range released {
+		s.broker.Publish(events.RequestUpdated, requestEvent(&released[i]))
+	}
+}
+
+// DeleteShift 删除误建的班次，并在同一事务内把已绑定需求释放回 pending。
+// 已发布班次学生可能已收到通知，需 force 才能删除；已出发或已完成的班次保留为历史记录。
+func (s *AdminService) DeleteShift(shiftID uint, force bool) error {
+	var released []models.Request
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var shift models.Shift
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shift, shiftID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShiftNotFound
+			}
+			return err
+		}
+		switch shift.Status {
+		case models.ShiftStatusInProgress, models.ShiftStatusCompleted:
+			return ErrShiftNotDeletable
+		case models.ShiftStatusPublished:
+			if !force {
+				return ErrShiftPublished
+			}
+		}
+		var err error
+		if released, err = releaseShiftRequestsInTx(tx, shiftID); err != nil {
+			return err
+		}
+		releasedIDs := make([]uint, 0, len(released))
+		for _, req := range released {
+			releasedIDs = append(releasedIDs, req.ID)
+		}
+		if err := tx.Where("shift_id = ?", shiftID).Delete(&models.ShiftStaff{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("shift_id = ?", shiftID).Delete(&models.ShiftConflict{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Shift{}, shiftID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, s.actor, "shift.delete", auditEntityShift, shiftID,
+			map[string]any{"shift": shift, "request_ids": releasedIDs}, nil)
+	})
+	if err != nil {
+		return err
+	}
+	s.broker.Publish(events.ShiftDeleted, map[string]any{"shift_id": shiftID})
+	s.publishReleased(released)
+	return nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/clause"
+)
+
+func TestAdminService_ShiftLifecycle(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+
+	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 6, MaxChecked: 6, MaxCarryOn: 6}
+	require.NoError(t, db.Create(&driver).Error)
+	departure := time.Date(2026, 8, 20, 10, 0, 0, 0, time.UTC)
+	newShift := func(offset time.Duration) models.Shift {
+		shift := models.Shift{DriverID: driver.ID, DepartureTime: departure.Add(offset), EstimatedMinutes: 60, Status: models.ShiftStatusDraft}
+		require.NoError(t, db.Create(&shift).Error)
+		return shift
+	}
+	newRequest := func(shiftID uint) models.Request {
+		req := models.Request{UserID: 2, FlightNo: "AA1", ArrivalDate: departure, Terminal: "T1", Passengers: 1, Status: models.RequestStatusPending}
+		require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+		_, err := svc.AssignStudent(shiftID, req.ID, "")
+		require.NoError(t, err)
+		return req
+	}
+	assertReleased := func(shiftID uint, reqs ...models.Request) {
+		t.Helper()
+		var bound int64
+		require.NoError(t, db.Model(&models.ShiftRequest{}).Where("shift_id = ?", shiftID).Count(&bound).Error)
+		assert.Zero(t, bound)
+		for _, req := range reqs {
+			var got models.Request
+			require.NoError(t, db.First(&got, req.ID).Error)
+			assert.Equal(t, models.RequestStatusPending, got.Status)
+		}
+	}
+
+	// 草稿取消：已分配的需求回到 pending，绑定删除。
+	draft := newShift(0)
+	draftReqs := []models.Request{newRequest(draft.ID), newRequest(draft.ID)}
+	canceled, err := svc.CancelShift(draft.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ShiftStatusCanceled, canceled.Status)
+	assertReleased(draft.ID, draftReqs...)
+
+	// 已取消的班次不能再发布或取消。
+	assert.ErrorIs(t, svc.PublishShift(draft.ID), ErrInvalidShiftTransition)
+	_, err = svc.CancelShift(draft.ID)
+	assert.ErrorIs(t, err, ErrInvalidShiftTransition)
+
+	// 已发布取消：published 需求同样释放。
+	published := newShift(3 * time.Hour)
+	pubReq := newRequest(published.ID)
+	require.NoError(t, svc.PublishShift(published.ID))
+	require.NoError(t, db.First(&pubReq, pubReq.ID).Error)
+	require.Equal(t, models.RequestStatusPublished, pubReq.Status)
+	_, err = svc.CancelShift(published.ID)
+	require.NoError(t, err)
+	assertReleased(published.ID, pubReq)
+
+	// 草稿不能直接开始；出发后不能取消，完成后也不能再开始或取消。
+	started := newShift(6 * time.Hour)
+	startedReq := newRequest(started.ID)
+	_, err = svc.StartShift(started.ID)
+	assert.ErrorIs(t, err, ErrInvalidShiftTransition)
+	require.NoError(t, svc.PublishShift(started.ID))
+	_, err = svc.CompleteShift(started.ID)
+	assert.ErrorIs(t, err, ErrInvalidShiftTransition)
+	_, err = svc.StartShift(started.ID)
+	require.NoError(t, err)
+	_, err = svc.CancelShift(started.ID)
+	assert.ErrorIs(t, err, ErrInvalidShiftTransition)
+	done, err := svc.CompleteShift(started.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ShiftStatusCompleted, done.Status)
+	_, err = svc.CancelShift(started.ID)
+	assert.ErrorIs(t, err, ErrInvalidShiftTransition)
+	_, err = svc.StartShift(started.ID)
+	assert.ErrorIs(t, err, ErrInvalidShiftTransition)
+
+	// 被拒绝的取消不改动绑定。
+	var bound int64
+	require.NoError(t, db.Model(&models.ShiftRequest{}).Where("shift_id = ? AND request_id = ?", started.ID, startedReq.ID).Count(&bound).Error)
+	assert.Equal(t, int64(1), bound)
+	require.NoError(t, db.First(&startedReq, startedReq.ID).Error)
+	assert.Equal(t, models.RequestStatusPublished, startedReq.Status)
+
+	_, err = svc.CancelShift(9999)
+	assert.ErrorIs(t, err, ErrShiftNotFound)
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"pickup/internal/scheduler/events"
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrShiftNotBoarding      = errors.New("shift is not open for boarding")
+	ErrStaffNotOnShift       = errors.New("staff is not assigned to this shift")
+	ErrRequestNotOnShift     = errors.New("request is not on this shift")
+	ErrInvalidBoardingStatus = errors.New("invalid boarding status")
+)
+
+// CheckInInput 志愿者登记单个乘客的登车结果；status 为 waiting 时撤销登记。
+type CheckInInput struct {
+	RequestID uint                  `json:"request_id" binding:"required"`
+	Status    models.BoardingStatus `json:"status" binding:"required"`
+}
+
+type StaffService struct {
+	db     *gorm.DB
+	broker *events.Broker
+	loc    *time.Location
+	actor  Actor
+}
+
+// NewStaffService broker 为 nil 时不广播变更；loc 为调度时区，按天导出名单时据此划分日期。
+func NewStaffService(db *gorm.DB, broker *events.Broker, loc *time.Location) *StaffService {
+	return &StaffService{db: db, broker: broker, loc: loc}
+}
+
+// WithActor 返回绑定操作者的服务副本，用于审计。
+func (s *StaffService) WithActor(actor Actor) *StaffService {
+	clone := *s
+	clone.actor = actor
+	return &clone
+}
+
+// CheckIn 记录乘客登车/未到。仅限该班次的志愿者或管理员，班次须为 published 或 in_progress。
+func (s *StaffService) CheckIn(staffID, shiftID uint, input CheckInInput) (*models.ShiftRequest, error) {
+	switch input.Status {
+	case models.BoardingStatusWaiting, models.BoardingStatusBoarded, models.BoardingStatusNoShow:
+	default:
+		return nil, ErrInvalidBoardingStatus
+	}
+
+	var record models.ShiftRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var shift models.Shift
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shift, shiftID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShiftNotFound
+			}
+			return err
+		}
+		if shift.Status != models.ShiftStatusPublished && shift.Status != models.ShiftStatusInProgress {
+			return ErrShiftNotBoarding
+		}
+
+		if err := requireStaffOnShift(tx, staffID, shiftID); err != nil {
+			return err
+		}
+
+		if err := tx.Where("shift_id = ? AND request_id = ?", shiftID, input.RequestID).First(&record).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRequestNotOnShift
+			}
+			return err
+		}
+
+		before := record
+		updates := map[string]any{"boarding_status": input.Status, "checked_in_at": nil, "checked_in_by": nil}
+		record.BoardingStatus = input.Status
+		record.CheckedInAt = nil
+		record.CheckedInBy = nil
+		if input.Status != models.BoardingStatusWaiting {
+			now := time.Now()
+			updates["checked_in_at"] = now
+			updates["checked_in_by"] = staffID
+			record.CheckedInAt = &now
+			record.CheckedInBy = &staffID
+		}
+		if err := tx.Model(&models.ShiftRequest{}).
+			Where("shift_id = ? AND request_id = ?", shiftID, input.RequestID).
+			Updates(updates).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, s.actor, "request.check_in", auditEntityRequest, input.RequestID, before, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// requireStaffOnShift 管理员不受限制，其他人须为该班次的志愿者。
+func requireStaffOnShift(tx *gorm.DB, staffID, shiftID uint) error {
+	var staff models.User
+	if err := tx.First(&staff, staffID).Error; err != nil {
+		return err
+	}
+	if staff.Role == models.UserRoleAdmin {
+		return nil
+	}
+	var onShift int64
+	if err := tx.Model(&models.ShiftStaff{}).
+		Where("shift_id = ? AND staff_id = ?", shiftID, staffID).
+		Count(&onShift).Error; err != nil {
+		return err
+	}
+	if onShift == 0 {
+		return ErrStaffNotOnShift
+	}
+	return nil
+}
+
+// MyShifts 返回志愿者值班的已发布或进行中班次，附司机与乘客名单；
+// maskPhones 与名单导出的规则一致，除管理员外手机号脱敏。
+func (s *StaffService) MyShifts(staffID uint, maskPhones bool) ([]ShiftWithManifest, error) {
+	var shifts []models.Shift
+	err := s.db.Model(&models.Shift{}).
+		Joins("JOIN shift_staffs ss ON ss.shift_id = shifts.id").
+		Where("ss.staff_id = ? AND shifts.status IN ?", staffID, rosterShiftStatuses).
+		Preload("Driver").
+		Preload("Staffs").
+		Order("shifts.departure_time ASC, shifts.id ASC").
+		Find(&shifts).Error
+	if err != nil {
+		return nil, err
+	}
+	return withManifests(s.db, shifts, maskPhones)
+}
+
+func (s *StaffService) MyShift(staffID, shiftID uint, maskPhones bool) (*ShiftWithManifest, error) {
+	if err := requireStaffOnShift(s.db, staffID, shiftID); err != nil {
+		return nil, err
+	}
+	var shift models.Shift
+	err := s.db.Where("status IN ?", rosterShiftStatuses).
+		Preload("Driver").
+		Preload("Staffs").
+		First(&shift, shiftID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShiftNotFound
+		}
+		return nil, err
+	}
+	items, err := withManifests(s.db, []models.Shift{shift}, maskPhones)
+	if err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// StartShift 值班志愿者出发时将自己的班次标记为进行中。
+func (s *StaffService) StartShift(staffID, shiftID uint) (*models.Shift, error) {
+	return s.transition(staffID, shiftID, "shift.start", models.ShiftStatusInProgress, "started_at")
+}
+
+// CompleteShift 值班志愿者接机完成后关闭自己的班次。
+func (s *StaffService) CompleteShift(staffID, shiftID uint) (*models.Shift, error) {
+	return s.transition(staffID, shiftID, "shift.complete", models.ShiftStatusCompleted, "completed_at")
+}
+
+// MyAvailability 志愿者查看自己的全部时间窗，包括管理员录入的。
+func (s *StaffService) MyAvailability(staffID uint) ([]models.StaffAvailability, error) {
+	var items []models.StaffAvailability
+	err := s.db.Where("staff_id = ?", staffID).Order("start_time ASC").Find(&items).Error
+	return items, err
+}
+
+// CreateMyAvailability 志愿者自报时间窗，来源记为 self。
+func (s *StaffService) CreateMyAvailability(staffID uint, input AvailabilityDTO) (*models.StaffAvailability, error) {
+	return createStaffAvailability(s.db, s.actor, staffID, input, models.AvailabilitySourceSelf)
+}
+
+// DeleteMyAvailability 志愿者只能删除自报的时间窗。
+func (s *StaffService) DeleteMyAvailability(staffID, windowID uint) error {
+	return deleteStaffAvailability(s.db, s.actor, staffID, windowID, true)
+}
+
+func (s *StaffService) transition(staffID, shiftID uint, action string, to models.ShiftStatus, stampColumn string) (*models.Shift, error) {
+	var shift *models.Shift
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := requireStaffOnShift(tx, staffID, shiftID); err != nil {
+			return err
+		}
+		now := time.Now()
+		updated, err := transitionShiftInTx(tx, s.actor, action, shiftID, to, map[string]any{stampColumn: now})
+		if err != nil {
+			return err
+		}
+		if to == models.ShiftStatusInProgress {
+			updated.StartedAt = &now
+		} else {
+			updated.CompletedAt = &now
+		}
+		shift = updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.broker.Publish(events.ShiftUpdated, shiftChangeEvent(shift))
+	return shift, nil
+}
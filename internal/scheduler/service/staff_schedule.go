@@ -0,0 +1,290 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pickup/internal/scheduler/events"
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrUserNotStaff  = errors.New("user is not staff")
+	ErrStaffNotFound = errors.New("staff not found")
+)
+
+// StaffOverlapError 志愿者在同一时段已在其他班次值班。
+type StaffOverlapError struct {
+	StaffID       uint
+	ShiftID       uint
+	DepartureTime time.Time
+}
+
+func (e *StaffOverlapError) Error() string {
+	return fmt.Sprintf("staff %d is already on shift %d departing at %s",
+		e.StaffID, e.ShiftID, e.DepartureTime.Format("2006-01-02 15:04"))
+}
+
+// AssignStaffResult Warning 为 outside_availability 时表示班次不在志愿者登记的时间窗内，
+// 为 capacity_overload 时表示志愿者占座后超载（allow_with_reason 策略下 Reason 为填写的理由）。
+type AssignStaffResult struct {
+	Warning string `json:"warning,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// ShiftOverlap 同一志愿者两个时间重叠的班次。
+type ShiftOverlap struct {
+	ShiftID      uint `json:"shift_id"`
+	OtherShiftID uint `json:"other_shift_id"`
+}
+
+// StaffSchedule 志愿者单日值班表。
+type StaffSchedule struct {
+	Staff        models.User                `json:"staff"`
+	Date         string                     `json:"date"`
+	Shifts       []models.Shift             `json:"shifts"`
+	Availability []models.StaffAvailability `json:"availability"`
+	Overlaps     []ShiftOverlap             `json:"overlaps"`
+}
+
+func isStaffRole(role models.UserRole) bool {
+	return role == models.UserRoleStaff || role == models.UserRoleAdmin
+}
+
+// loadStaffShifts 读取志愿者可能与 [from, to) 重叠的未结束班次。
+func loadStaffShifts(db *gorm.DB, staffID uint, from, to time.Time, excludeShiftID uint) ([]models.Shift, error) {
+	query := db.Model(&models.Shift{}).
+		Joins("JOIN shift_staffs ss ON ss.shift_id = shifts.id").
+		Where("ss.staff_id = ? AND shifts.status IN ? AND shifts.departure_time < ? AND shifts.departure_time > ?",
+			staffID, busyShiftStatuses, to, from.Add(-MaxShiftMinutes*time.Minute))
+	if excludeShiftID != 0 {
+		query = query.Where("shifts.id <> ?", excludeShiftID)
+	}
+	var shifts []models.Shift
+	if err := query.Order("shifts.departure_time ASC").Find(&shifts).Error; err != nil {
+		return nil, err
+	}
+	busy := shifts[:0]
+	for _, shift := range shifts {
+		if shiftEnd(shift.DepartureTime, shift.EstimatedMinutes).After(from) {
+			busy = append(busy, shift)
+		}
+	}
+	return busy, nil
+}
+
+// lockStaffInTx 锁住志愿者的 users 行，同一志愿者的并发指派与改期因此串行做撞班检查。
+// 调用方先锁班次再锁志愿者，保持加锁顺序一致。
+func lockStaffInTx(tx *gorm.DB, staffID uint) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, staffID).Error
+}
+
+// checkStaffScheduleInTx 与志愿者其他班次重叠时返回 *StaffOverlapError；超出时间窗只返回警告。
+func checkStaffScheduleInTx(tx *gorm.DB, staffID uint, shift models.Shift) (string, error) {
+	end := shiftEnd(shift.DepartureTime, shift.EstimatedMinutes)
+	busy, err := loadStaffShifts(tx, staffID, shift.DepartureTime, end, shift.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(busy) > 0 {
+		return "", &StaffOverlapError{StaffID: staffID, ShiftID: busy[0].ID, DepartureTime: busy[0].DepartureTime}
+	}
+	var windows []models.StaffAvailability
+	if err := tx.Where("staff_id = ?", staffID).Find(&windows).Error; err != nil {
+		return "", err
+	}
+	if !coveredByAvailability(windows, shift.DepartureTime, end) {
+		return warningOutsideAvailability, nil
+	}
+	return "", nil
+}
+
+// AssignStaff 志愿者时段重叠时返回 *StaffOverlapError，超出其登记时间窗时仍分配并返回警告。
+// 志愿者占一个座位，超载时与学生分配一样按容量策略处理；reason 记入审计日志。
+func (s *AdminService) AssignStaff(shiftID, staffID uint, reason string) (AssignStaffResult, error) {
+	var result AssignStaffResult
+	var user models.User
+	if err := s.db.First(&user, staffID).Error; err != nil {
+		return result, err
+	}
+	if !isStaffRole(user.Role) {
+		return result, ErrUserNotStaff
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var shift models.Shift
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Driver").First(&shift, shiftID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShiftNotFound
+			}
+			return err
+		}
+		if shift.Status != models.ShiftStatusDraft && shift.Status != models.ShiftStatusPublished {
+			return ErrShiftNotAssignable
+		}
+		if err := lockStaffInTx(tx, staffID); err != nil {
+			return err
+		}
+		warning, err := checkStaffScheduleInTx(tx, staffID, shift)
+		if err != nil {
+			return err
+		}
+		result.Warning = warning
+
+		usages, err := loadShiftUsage(tx, []uint{shiftID})
+		if err != nil {
+			return err
+		}
+		if seats := usages[shiftID].seats() + 1; seats > shift.Driver.MaxSeats {
+			usage := usages[shiftID]
+			switch s.assigner.policyFor(shift) {
+			case models.CapacityPolicyReject:
+				return &CapacityExceededError{
+					Seats: seats, MaxSeats: shift.Driver.MaxSeats,
+					Checked: usage.Checked, MaxChecked: shift.Driver.MaxChecked,
+					CarryOn: usage.CarryOn, MaxCarryOn: shift.Driver.MaxCarryOn,
+				}
+			case models.CapacityPolicyAllowWithReason:
+				reason = strings.TrimSpace(reason)
+				if reason == "" {
+					return ErrReasonRequired
+				}
+				result.Reason = reason
+			}
+			result.Warning = "capacity_overload"
+		}
+
+		if err := tx.Table("shift_staffs").Create(map[string]any{"shift_id": shiftID, "staff_id": staffID}).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, s.actor, "shift.assign_staff", auditEntityShift, shiftID, nil,
+			map[string]any{"staff_id": staffID, "warning": result.Warning, "reason": result.Reason})
+	})
+	if err != nil {
+		return AssignStaffResult{}, err
+	}
+	s.broker.Publish(events.StaffAssigned, map[string]any{"shift_id": shiftID, "staff_id": staffID, "warning": result.Warning})
+	return result, nil
+}
+
+// StaffSchedule 返回志愿者在 date 当天出发的全部班次、当天相关时间窗以及其中相互重叠的班次。
+func (s *AdminService) StaffSchedule(staffID uint, date time.Time) (*StaffSchedule, error) {
+	var staff models.User
+	if err := s.db.First(&staff, staffID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStaffNotFound
+		}
+		return nil, err
+	}
+	if !isStaffRole(staff.Role) {
+		return nil, ErrUserNotStaff
+	}
+	dayStart := startOfDay(date, s.loc)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	schedule := &StaffSchedule{Staff: staff, Date: dayStart.Format("2006-01-02")}
+	err := s.db.Model(&models.Shift{}).
+		Joins("JOIN shift_staffs ss ON ss.shift_id = shifts.id").
+		Where("ss.staff_id = ? AND shifts.departure_time >= ? AND shifts.departure_time < ?", staffID, dayStart, dayEnd).
+		Preload("Driver").
+		Order("shifts.departure_time ASC, shifts.id ASC").
+		Find(&schedule.Shifts).Error
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.Where("staff_id = ? AND start_time < ? AND end_time > ?", staffID, dayEnd, dayStart).
+		Order("start_time ASC").
+		Find(&schedule.Availability).Error
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.Overlaps = make([]ShiftOverlap, 0)
+	for i, a := range schedule.Shifts {
+		if a.Status == models.ShiftStatusCanceled || a.Status == models.ShiftStatusCompleted {
+			continue
+		}
+		aEnd := shiftEnd(a.DepartureTime, a.EstimatedMinutes)
+		for _, b := range schedule.Shifts[i+1:] {
+			if b.Status == models.ShiftStatusCanceled || b.Status == models.ShiftStatusCompleted {
+				continue
+			}
+			if b.DepartureTime.Before(aEnd) {
+				schedule.Overlaps = append(schedule.Overlaps, ShiftOverlap{ShiftID: a.ID, OtherShiftID: b.ID})
+			}
+		}
+	}
+	return schedule, nil
+}
+
+func (s *AdminService) ListStaffAvailability(staffID uint) ([]models.StaffAvailability, error) {
+	var items []models.StaffAvailability
+	err := s.db.Where("staff_id = ?", staffID).Order("start_time ASC").Find(&items).Error
+	return items, err
+}
+
+func (s *AdminService) CreateStaffAvailability(staffID uint, input AvailabilityDTO) (*models.StaffAvailability, error) {
+	return createStaffAvailability(s.db, s.actor, staffID, input, models.AvailabilitySourceAdmin)
+}
+
+func (s *AdminService) DeleteStaffAvailability(staffID, windowID uint) error {
+	return deleteStaffAvailability(s.db, s.actor, staffID, windowID, false)
+}
+
+// createStaffAvailability 管理员录入与志愿者自报共用，source 记录来源。
+func createStaffAvailability(db *gorm.DB, actor Actor, staffID uint, input AvailabilityDTO, source models.AvailabilitySource) (*models.StaffAvailability, error) {
+	if !input.EndTime.After(input.StartTime) {
+		return nil, ErrInvalidAvailability
+	}
+	window := models.StaffAvailability{
+		StaffID:   staffID,
+		StartTime: input.StartTime,
+		EndTime:   input.EndTime,
+		Source:    source,
+		Note:      strings.TrimSpace(input.Note),
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var staff models.User
+		if err := tx.Select("id", "role").First(&staff, staffID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrStaffNotFound
+			}
+			return err
+		}
+		if !isStaffRole(staff.Role) {
+			return ErrUserNotStaff
+		}
+		if err := tx.Create(&window).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, "user.availability.create", auditEntityUser, staffID, nil, window)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+// deleteStaffAvailability selfOnly 为 true 时只能删除本人自报的时间窗。
+func deleteStaffAvailability(db *gorm.DB, actor Actor, staffID, windowID uint, selfOnly bool) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var before models.StaffAvailability
+		if err := tx.Where("staff_id = ?", staffID).First(&before, windowID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAvailabilityNotFound
+			}
+			return err
+		}
+		if selfOnly && before.Source != models.AvailabilitySourceSelf {
+			return ErrAvailabilityNotSelf
+		}
+		if err := tx.Delete(&models.StaffAvailability{}, windowID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, "user.availability.delete", auditEntityUser, staffID, before, nil)
+	})
+}
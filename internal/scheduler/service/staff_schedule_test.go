@@ -0,0 +1,215 @@
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminService_StaffConflicts(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC).WithActor(Actor{UserID: 1, Role: "admin"})
+
+	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	day := time.Date(2026, 8, 20, 0, 0, 0, 0, time.UTC)
+	shifts := []models.Shift{
+		{DriverID: driver.ID, DepartureTime: day.Add(10 * time.Hour), EstimatedMinutes: 90, Status: models.ShiftStatusDraft},
+		{DriverID: driver.ID, DepartureTime: day.Add(10*time.Hour + 10*time.Minute), EstimatedMinutes: 90, Status: models.ShiftStatusDraft},
+		{DriverID: driver.ID, DepartureTime: day.Add(16 * time.Hour), EstimatedMinutes: 60, Status: models.ShiftStatusDraft},
+	}
+	for i := range shifts {
+		require.NoError(t, db.Create(&shifts[i]).Error)
+	}
+	staff := models.User{OpenID: "oid-staff", Name: "Lee", Role: models.UserRoleStaff}
+	require.NoError(t, db.Create(&staff).Error)
+
+	_, err := svc.CreateStaffAvailability(staff.ID, AvailabilityDTO{StartTime: day.Add(8 * time.Hour), EndTime: day.Add(14 * time.Hour)})
+	require.NoError(t, err)
+
+	res, err := svc.AssignStaff(shifts[0].ID, staff.ID, "")
+	require.NoError(t, err)
+	assert.Empty(t, res.Warning)
+
+	_, err = svc.AssignStaff(shifts[1].ID, staff.ID, "")
+	var overlap *StaffOverlapError
+	require.ErrorAs(t, err, &overlap)
+	assert.Equal(t, shifts[0].ID, overlap.ShiftID)
+
+	res, err = svc.AssignStaff(shifts[2].ID, staff.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, "outside_availability", res.Warning)
+
+	// 迁移前已存在的重复值班在日程中标出
+	require.NoError(t, db.Table("shift_staffs").Create(map[string]any{"shift_id": shifts[1].ID, "staff_id": staff.ID}).Error)
+	schedule, err := svc.StaffSchedule(staff.ID, day.Add(15*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "2026-08-20", schedule.Date)
+	require.Len(t, schedule.Shifts, 3)
+	require.NotNil(t, schedule.Shifts[0].Driver)
+	assert.Len(t, schedule.Availability, 1)
+	assert.Equal(t, []ShiftOverlap{{ShiftID: shifts[0].ID, OtherShiftID: shifts[1].ID}}, schedule.Overlaps)
+
+	next, err := svc.StaffSchedule(staff.ID, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Empty(t, next.Shifts)
+	assert.Empty(t, next.Availability)
+
+	_, err = svc.StaffSchedule(999, day)
+	assert.ErrorIs(t, err, ErrStaffNotFound)
+	student := models.User{OpenID: "oid-stu", Name: "s", Role: models.UserRoleStudent}
+	require.NoError(t, db.Create(&student).Error)
+	_, err = svc.StaffSchedule(student.ID, day)
+	assert.ErrorIs(t, err, ErrUserNotStaff)
+	_, err = svc.CreateStaffAvailability(student.ID, AvailabilityDTO{StartTime: day, EndTime: day.Add(time.Hour)})
+	assert.ErrorIs(t, err, ErrUserNotStaff)
+	_, err = svc.CreateStaffAvailability(staff.ID, AvailabilityDTO{StartTime: day, EndTime: day})
+	assert.ErrorIs(t, err, ErrInvalidAvailability)
+
+	windows, err := svc.ListStaffAvailability(staff.ID)
+	require.NoError(t, err)
+	require.Len(t, windows, 1)
+	assert.ErrorIs(t, svc.DeleteStaffAvailability(student.ID, windows[0].ID), ErrAvailabilityNotFound)
+	require.NoError(t, svc.DeleteStaffAvailability(staff.ID, windows[0].ID))
+}
+
+func TestAdminService_ConcurrentAssignStaff(t *testing.T) {
+	db := newTestDB(t)
+	// sqlite 不支持行锁，单连接让事务像 MySQL 中被志愿者行锁阻塞时一样依次执行。
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC).WithActor(Actor{UserID: 1, Role: "admin"})
+
+	day := time.Date(2026, 8, 20, 0, 0, 0, 0, time.UTC)
+	shifts := make([]models.Shift, 4)
+	for i := range shifts {
+		driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+		require.NoError(t, db.Create(&driver).Error)
+		shifts[i] = models.Shift{DriverID: driver.ID, DepartureTime: day.Add(10*time.Hour + time.Duration(i)*10*time.Minute), EstimatedMinutes: 90, Status: models.ShiftStatusDraft}
+		require.NoError(t, db.Create(&shifts[i]).Error)
+	}
+	staff := models.User{OpenID: "oid-staff", Name: "Lee", Role: models.UserRoleStaff}
+	require.NoError(t, db.Create(&staff).Error)
+
+	errs := make([]error, len(shifts))
+	var wg sync.WaitGroup
+	for i := range shifts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = svc.AssignStaff(shifts[i].ID, staff.ID, "")
+		}()
+	}
+	wg.Wait()
+
+	assigned := 0
+	for _, err := range errs {
+		if err == nil {
+			assigned++
+			continue
+		}
+		var overlap *StaffOverlapError
+		assert.ErrorAs(t, err, &overlap)
+	}
+	assert.Equal(t, 1, assigned)
+	var stored int64
+	require.NoError(t, db.Table("shift_staffs").Where("staff_id = ?", staff.ID).Count(&stored).Error)
+	assert.Equal(t, int64(1), stored)
+}
+
+func TestAdminService_UpdateShiftChecksStaff(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+
+	day := time.Date(2026, 8, 20, 0, 0, 0, 0, time.UTC)
+	var shifts []*models.Shift
+	for _, hour := range []int{10, 14} {
+		driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+		require.NoError(t, db.Create(&driver).Error)
+		shift, err := svc.CreateShift(driver.ID, day.Add(time.Duration(hour)*time.Hour), 90)
+		require.NoError(t, err)
+		shifts = append(shifts, shift)
+	}
+	staff := models.User{OpenID: "oid-staff", Name: "Lee", Role: models.UserRoleStaff}
+	require.NoError(t, db.Create(&staff).Error)
+	_, err := svc.CreateStaffAvailability(staff.ID, AvailabilityDTO{StartTime: day.Add(8 * time.Hour), EndTime: day.Add(18 * time.Hour)})
+	require.NoError(t, err)
+	for _, shift := range shifts {
+		res, err := svc.AssignStaff(shift.ID, staff.ID, "")
+		require.NoError(t, err)
+		assert.Empty(t, res.Warning)
+	}
+
+	// 改期后与志愿者的另一班重叠：整体拒绝，班次不变。
+	overlapping := day.Add(13 * time.Hour)
+	_, err = svc.UpdateShift(shifts[0].ID, ShiftUpdateDTO{DepartureTime: &overlapping})
+	var overlap *StaffOverlapError
+	require.ErrorAs(t, err, &overlap)
+	assert.Equal(t, shifts[1].ID, overlap.ShiftID)
+	var stored models.Shift
+	require.NoError(t, db.First(&stored, shifts[0].ID).Error)
+	assert.True(t, stored.DepartureTime.Equal(shifts[0].DepartureTime))
+
+	// 延长时长同样检查。
+	minutes := 300
+	_, err = svc.UpdateShift(shifts[0].ID, ShiftUpdateDTO{EstimatedMinutes: &minutes})
+	require.ErrorAs(t, err, &overlap)
+
+	// 移出志愿者时间窗只给出警告。
+	evening := day.Add(19 * time.Hour)
+	updated, err := svc.UpdateShift(shifts[1].ID, ShiftUpdateDTO{DepartureTime: &evening})
+	require.NoError(t, err)
+	assert.Equal(t, "outside_availability", updated.Warning)
+}
+
+func TestAdminService_AssignStaffCapacityAndStatus(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+
+	driver := models.Driver{Name: "d", CarModel: "Sedan", MaxSeats: 2, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	reject := models.CapacityPolicyReject
+	shift := models.Shift{DriverID: driver.ID, DepartureTime: time.Date(2026, 8, 20, 10, 0, 0, 0, time.UTC), EstimatedMinutes: 90,
+		Status: models.ShiftStatusDraft, CapacityPolicy: &reject}
+	require.NoError(t, db.Create(&shift).Error)
+	var staff []models.User
+	for _, oid := range []string{"s1", "s2", "s3"} {
+		u := models.User{OpenID: oid, Name: oid, Role: models.UserRoleStaff}
+		require.NoError(t, db.Create(&u).Error)
+		staff = append(staff, u)
+	}
+
+	for _, u := range staff[:2] {
+		_, err := svc.AssignStaff(shift.ID, u.ID, "")
+		require.NoError(t, err)
+	}
+	// 两个座位已被志愿者占满，reject 策略下第三人被拒。
+	_, err := svc.AssignStaff(shift.ID, staff[2].ID, "")
+	var capErr *CapacityExceededError
+	require.ErrorAs(t, err, &capErr)
+	assert.Equal(t, 3, capErr.Seats)
+	var count int64
+	require.NoError(t, db.Table("shift_staffs").Where("shift_id = ?", shift.ID).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+
+	allow := models.CapacityPolicyAllowWithReason
+	require.NoError(t, db.Model(&shift).Update("capacity_policy", allow).Error)
+	_, err = svc.AssignStaff(shift.ID, staff[2].ID, " ")
+	assert.ErrorIs(t, err, ErrReasonRequired)
+	res, err := svc.AssignStaff(shift.ID, staff[2].ID, "trainee")
+	require.NoError(t, err)
+	assert.Equal(t, "capacity_overload", res.Warning)
+	assert.Equal(t, "trainee", res.Reason)
+
+	for _, status := range []models.ShiftStatus{models.ShiftStatusInProgress, models.ShiftStatusCompleted, models.ShiftStatusCanceled} {
+		require.NoError(t, db.Model(&shift).Update("status", status).Error)
+		_, err = svc.AssignStaff(shift.ID, staff[0].ID, "")
+		assert.ErrorIs(t, err, ErrShiftNotAssignable, status)
+	}
+}
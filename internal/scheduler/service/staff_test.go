@@ -0,0 +1,151 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/clause"
+)
+
+func TestStaffService_CheckInAndNoShowReport(t *testing.T) {
+	db := newTestDB(t)
+	staffSvc := NewStaffService(db, nil, time.UTC)
+	adminSvc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+	studentSvc := NewStudentService(db, nil, time.UTC)
+
+	staff := models.User{OpenID: "staff", Name: "s", Role: models.UserRoleStaff}
+	other := models.User{OpenID: "other", Name: "o", Role: models.UserRoleStaff}
+	student := models.User{OpenID: "stu", Name: "stu", Phone: "13800000000", Role: models.UserRoleStudent}
+	for _, u := range []*models.User{&staff, &other, &student} {
+		require.NoError(t, db.Create(u).Error)
+	}
+	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	departure := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	shift := models.Shift{DriverID: driver.ID, DepartureTime: departure, Status: models.ShiftStatusDraft}
+	require.NoError(t, db.Create(&shift).Error)
+	req := models.Request{UserID: student.ID, FlightNo: "AA1", ArrivalDate: departure, Terminal: "T1", Status: models.RequestStatusPublished}
+	require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+	require.NoError(t, db.Table("shift_requests").Create(map[string]any{"shift_id": shift.ID, "request_id": req.ID}).Error)
+	require.NoError(t, db.Table("shift_staffs").Create(map[string]any{"shift_id": shift.ID, "staff_id": staff.ID}).Error)
+
+	_, err := staffSvc.CheckIn(staff.ID, shift.ID, CheckInInput{RequestID: req.ID, Status: models.BoardingStatusBoarded})
+	assert.ErrorIs(t, err, ErrShiftNotBoarding)
+	require.NoError(t, db.Model(&models.Shift{}).Where("id = ?", shift.ID).Update("status", models.ShiftStatusPublished).Error)
+
+	_, err = staffSvc.CheckIn(staff.ID, shift.ID, CheckInInput{RequestID: req.ID, Status: "late"})
+	assert.ErrorIs(t, err, ErrInvalidBoardingStatus)
+	_, err = staffSvc.CheckIn(staff.ID, 999, CheckInInput{RequestID: req.ID, Status: models.BoardingStatusBoarded})
+	assert.ErrorIs(t, err, ErrShiftNotFound)
+	_, err = staffSvc.CheckIn(other.ID, shift.ID, CheckInInput{RequestID: req.ID, Status: models.BoardingStatusBoarded})
+	assert.ErrorIs(t, err, ErrStaffNotOnShift)
+	_, err = staffSvc.CheckIn(staff.ID, shift.ID, CheckInInput{RequestID: 999, Status: models.BoardingStatusBoarded})
+	assert.ErrorIs(t, err, ErrRequestNotOnShift)
+
+	record, err := staffSvc.CheckIn(staff.ID, shift.ID, CheckInInput{RequestID: req.ID, Status: models.BoardingStatusNoShow})
+	require.NoError(t, err)
+	assert.Equal(t, models.BoardingStatusNoShow, record.BoardingStatus)
+	require.NotNil(t, record.CheckedInBy)
+	assert.Equal(t, staff.ID, *record.CheckedInBy)
+
+	dashboard, err := adminSvc.DashboardShifts(ShiftFilter{})
+	require.NoError(t, err)
+	require.Len(t, dashboard.Items, 1)
+	require.Len(t, dashboard.Items[0].Requests, 1)
+	require.NotNil(t, dashboard.Items[0].Requests[0].Boarding)
+	assert.Equal(t, models.BoardingStatusNoShow, dashboard.Items[0].Requests[0].Boarding.BoardingStatus)
+
+	mine, err := studentSvc.ListMyRequests(student.ID)
+	require.NoError(t, err)
+	require.Len(t, mine, 1)
+	require.NotNil(t, mine[0].Boarding)
+	assert.Equal(t, models.BoardingStatusNoShow, mine[0].Boarding.BoardingStatus)
+
+	report, err := adminSvc.NoShowReport(departure, departure)
+	require.NoError(t, err)
+	require.Len(t, report, 1)
+	assert.Equal(t, req.ID, report[0].RequestID)
+	assert.Equal(t, "13800000000", report[0].Phone)
+	_, err = adminSvc.NoShowReport(departure, departure.AddDate(0, 0, -1))
+	assert.Error(t, err)
+
+	// 管理员可代为登记，waiting 撤销登记信息
+	admin := models.User{OpenID: "admin", Name: "a", Role: models.UserRoleAdmin}
+	require.NoError(t, db.Create(&admin).Error)
+	record, err = staffSvc.CheckIn(admin.ID, shift.ID, CheckInInput{RequestID: req.ID, Status: models.BoardingStatusWaiting})
+	require.NoError(t, err)
+	assert.Nil(t, record.CheckedInAt)
+	report, err = adminSvc.NoShowReport(departure, departure)
+	require.NoError(t, err)
+	assert.Empty(t, report)
+}
+
+func TestStaffService_MyShiftsAndLifecycle(t *testing.T) {
+	db := newTestDB(t)
+	staffSvc := NewStaffService(db, nil, time.UTC).WithActor(Actor{UserID: 1, Role: "staff"})
+
+	staff := models.User{OpenID: "staff", Name: "s", Role: models.UserRoleStaff}
+	other := models.User{OpenID: "other", Name: "o", Role: models.UserRoleStaff}
+	student := models.User{OpenID: "stu", Name: "Li", Phone: "13800000000", Role: models.UserRoleStudent}
+	for _, u := range []*models.User{&staff, &other, &student} {
+		require.NoError(t, db.Create(u).Error)
+	}
+	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	departure := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	shifts := []models.Shift{
+		{DriverID: driver.ID, DepartureTime: departure, Status: models.ShiftStatusPublished},
+		{DriverID: driver.ID, DepartureTime: departure.Add(4 * time.Hour), Status: models.ShiftStatusDraft},
+		{DriverID: driver.ID, DepartureTime: departure.Add(8 * time.Hour), Status: models.ShiftStatusPublished},
+	}
+	for i := range shifts {
+		require.NoError(t, db.Create(&shifts[i]).Error)
+		require.NoError(t, db.Table("shift_staffs").Create(map[string]any{"shift_id": shifts[i].ID, "staff_id": staff.ID}).Error)
+	}
+	require.NoError(t, db.Model(&models.ShiftStaff{}).Where("shift_id = ?", shifts[2].ID).Update("staff_id", other.ID).Error)
+	req := models.Request{UserID: student.ID, FlightNo: "AA1", ArrivalDate: departure, Terminal: "T1", CheckedBags: 2, Status: models.RequestStatusPublished}
+	require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+	require.NoError(t, db.Table("shift_requests").Create(map[string]any{"shift_id": shifts[0].ID, "request_id": req.ID}).Error)
+
+	items, err := staffSvc.MyShifts(staff.ID, false)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, shifts[0].ID, items[0].ID)
+	require.NotNil(t, items[0].Driver)
+	require.Len(t, items[0].Manifest, 1)
+	assert.Equal(t, "13800000000", items[0].Manifest[0].Phone)
+	assert.Equal(t, 2, items[0].Manifest[0].CheckedBags)
+
+	// 与名单导出一致，脱敏时只露出前三位与后四位。
+	items, err = staffSvc.MyShifts(staff.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, "138****0000", items[0].Manifest[0].Phone)
+	item, err := staffSvc.MyShift(staff.ID, shifts[0].ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, "138****0000", item.Manifest[0].Phone)
+
+	_, err = staffSvc.MyShift(staff.ID, shifts[2].ID, true)
+	assert.ErrorIs(t, err, ErrStaffNotOnShift)
+	_, err = staffSvc.MyShift(staff.ID, shifts[1].ID, true)
+	assert.ErrorIs(t, err, ErrShiftNotFound)
+
+	_, err = staffSvc.StartShift(staff.ID, shifts[2].ID)
+	assert.ErrorIs(t, err, ErrStaffNotOnShift)
+	_, err = staffSvc.CompleteShift(staff.ID, shifts[0].ID)
+	assert.ErrorIs(t, err, ErrInvalidShiftTransition)
+	started, err := staffSvc.StartShift(staff.ID, shifts[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ShiftStatusInProgress, started.Status)
+	require.NotNil(t, started.StartedAt)
+	completed, err := staffSvc.CompleteShift(staff.ID, shifts[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ShiftStatusCompleted, completed.Status)
+
+	var actions []string
+	require.NoError(t, db.Model(&models.AuditLog{}).Where("entity_type = ? AND entity_id = ?", auditEntityShift, shifts[0].ID).Order("id").Pluck("action", &actions).Error)
+	assert.Equal(t, []string{"shift.start", "shift.complete"}, actions)
+}
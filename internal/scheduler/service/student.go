@@ -2,26 +2,48 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
+	"pickup/internal/scheduler/events"
 	"pickup/internal/scheduler/models"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// MaxPassengersPerRequest 单个需求（学生本人加同行家属）的人数上限。
+const MaxPassengersPerRequest = 6
+
+var (
+	ErrRequestAlreadyCanceled = errors.New("request already canceled")
+	ErrRequestNotCancelable   = errors.New("request can no longer be canceled")
+	ErrInvalidPassengers      = fmt.Errorf("passengers must be between 1 and %d", MaxPassengersPerRequest)
+	ErrNegativeCheckedBags    = errors.New("checked_bags must not be negative")
+	ErrNegativeCarryOnBags    = errors.New("carry_on_bags must not be negative")
+)
+
+func validPassengers(n int) bool {
+	return n >= 1 && n <= MaxPassengersPerRequest
+}
+
 type StudentService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	broker *events.Broker
+	loc    *time.Location
 }
 
-func NewStudentService(db *gorm.DB) *StudentService {
-	return &StudentService{db: db}
+// NewStudentService broker 为 nil 时不广播变更；expected_arrival_time 按 loc 解释。
+func NewStudentService(db *gorm.DB, broker *events.Broker, loc *time.Location) *StudentService {
+	return &StudentService{db: db, broker: broker, loc: loc}
 }
 
 type CreateRequestInput struct {
 	FlightNo            string `json:"flight_no" binding:"required"`
 	ArrivalDate         string `json:"arrival_date" binding:"required"`
+	Airport             string `json:"airport"`
 	Terminal            string `json:"terminal" binding:"required"`
+	Passengers          int    `json:"passengers"`
 	CheckedBags         int    `json:"checked_bags"`
 	CarryOnBags         int    `json:"carry_on_bags"`
 	ExpectedArrivalTime string `json:"expected_arrival_time" binding:"required"`
@@ -30,22 +52,42 @@ type CreateRequestInput struct {
 type UpdateRequestInput struct {
 	FlightNo            *string `json:"flight_no"`
 	ArrivalDate         *string `json:"arrival_date"`
+	Airport             *string `json:"airport"`
 	Terminal            *string `json:"terminal"`
+	Passengers          *int    `json:"passengers"`
 	CheckedBags         *int    `json:"checked_bags"`
 	CarryOnBags         *int    `json:"carry_on_bags"`
 	ExpectedArrivalTime *string `json:"expected_arrival_time"`
+	// IfMatch 来自 If-Match 请求头，非 nil 时须等于当前 version。
+	IfMatch *uint `json:"-"`
 }
 
-func pickupBufferByTerminal(terminal string) int {
-	if terminal == "T5" {
-		return 90
+// CreateRequest 每个学生在同一活动内只能有一条有效需求；存在当前活动时需求归入该活动。
+// Passengers 含学生本人，缺省为 1。
+func (s *StudentService) CreateRequest(userID uint, input CreateRequestInput) (*models.Request, error) {
+	if input.Passengers == 0 {
+		input.Passengers = 1
+	}
+	if !validPassengers(input.Passengers) {
+		return nil, ErrInvalidPassengers
+	}
+	campaign, err := activeCampaign(s.db)
+	if err != nil {
+		return nil, err
+	}
+	var campaignID *uint
+	if campaign != nil {
+		if campaign.Status == models.CampaignStatusClosed {
+			return nil, ErrCampaignClosed
+		}
+		campaignID = &campaign.ID
 	}
-	return 45
-}
 
-func (s *StudentService) CreateRequest(userID uint, input CreateRequestInput) (*models.Request, error) {
 	var existingCount int64
-	if err := s.db.Model(&models.Request{}).Where("user_id = ?", userID).Count(&existingCount).Error; err != nil {
+	if err := s.db.Model(&models.Request{}).
+		Scopes(inCampaign("campaign_id", campaignID)).
+		Where("user_id = ? AND status <> ?", userID, models.RequestStatusCanceled).
+		Count(&existingCount).Error; err != nil {
 		return nil, err
 	}
 	if existingCount > 0 {
@@ -56,34 +98,56 @@ func (s *StudentService) CreateRequest(userID uint, input CreateRequestInput) (*
 	if err != nil {
 		return nil, err
 	}
-	expectedArrivalTime, err := time.Parse("2006-01-02 15:04:05", input.ExpectedArrivalTime)
+	if campaign != nil && (arrivalDate.Before(dateOnly(campaign.StartDate)) || arrivalDate.After(dateOnly(campaign.EndDate))) {
+		return nil, ErrArrivalOutsideCampaign
+	}
+	expectedArrivalTime, err := time.ParseInLocation("2006-01-02 15:04:05", input.ExpectedArrivalTime, s.loc)
+	if err != nil {
+		return nil, err
+	}
+	buffers, err := LoadTerminalBuffers(s.db)
 	if err != nil {
 		return nil, err
 	}
-	buffer := pickupBufferByTerminal(input.Terminal)
+	airport, buffer := buffers.Lookup(input.Airport, input.Terminal)
 	calcPickupTime := expectedArrivalTime.Add(time.Duration(buffer) * time.Minute)
 
 	req := models.Request{
 		UserID:         userID,
 		FlightNo:       input.FlightNo,
 		ArrivalDate:    arrivalDate,
+		Airport:        airport,
 		Terminal:       input.Terminal,
+		Passengers:     input.Passengers,
 		CheckedBags:    input.CheckedBags,
 		CarryOnBags:    input.CarryOnBags,
 		Status:         models.RequestStatusPending,
 		ArrivalTimeAPI: &expectedArrivalTime,
 		PickupBuffer:   buffer,
 		CalcPickupTime: &calcPickupTime,
+		CampaignID:     campaignID,
 	}
 	if err := s.db.Omit(clause.Associations).Create(&req).Error; err != nil {
 		return nil, err
 	}
+	s.broker.Publish(events.RequestCreated, requestEvent(&req))
 	return &req, nil
 }
 
+// ListMyRequests 存在当前活动时只返回该活动内的需求。
 func (s *StudentService) ListMyRequests(userID uint) ([]models.Request, error) {
+	campaign, err := activeCampaign(s.db)
+	if err != nil {
+		return nil, err
+	}
+	var campaignID *uint
+	if campaign != nil {
+		campaignID = &campaign.ID
+	}
+
 	var reqs []models.Request
-	if err := s.db.Where("user_id = ?", userID).
+	if err := s.db.Scopes(inCampaign("campaign_id", campaignID)).
+		Where("user_id = ?", userID).
 		Preload("Shifts.Driver").
 		Find(&reqs).Error; err != nil {
 		return nil, err
@@ -95,52 +159,163 @@ func (s *StudentService) ListMyRequests(userID uint) ([]models.Request, error) {
 		}
 		reqs[i].Shift = &reqs[i].Shifts[0]
 	}
+
+	ids := make([]uint, 0, len(reqs))
+	for _, req := range reqs {
+		if req.Shift != nil {
+			ids = append(ids, req.ID)
+		}
+	}
+	boarding, err := loadBoarding(s.db, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range reqs {
+		if row, ok := boarding[reqs[i].ID]; ok {
+			reqs[i].Boarding = &row
+		}
+	}
 	return reqs, nil
 }
 
 func (s *StudentService) UpdatePendingRequest(userID, requestID uint, input UpdateRequestInput) (*models.Request, error) {
 	var req models.Request
-	if err := s.db.Where("id = ? AND user_id = ?", requestID, userID).First(&req).Error; err != nil {
-		return nil, err
-	}
-	if req.Status != models.RequestStatusPending {
-		return nil, errors.New("only pending request can be updated")
-	}
-	if input.FlightNo != nil {
-		req.FlightNo = *input.FlightNo
-	}
-	if input.ArrivalDate != nil {
-		arrivalDate, err := time.Parse("2006-01-02", *input.ArrivalDate)
-		if err != nil {
-			return nil, err
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", requestID, userID).
+			First(&req).Error; err != nil {
+			return err
 		}
-		req.ArrivalDate = arrivalDate
-	}
-	if input.Terminal != nil {
-		req.Terminal = *input.Terminal
-		req.PickupBuffer = pickupBufferByTerminal(*input.Terminal)
-		if req.ArrivalTimeAPI != nil {
-			pickup := req.ArrivalTimeAPI.Add(time.Duration(req.PickupBuffer) * time.Minute)
+		if err := checkVersion(req.Version, input.IfMatch); err != nil {
+			return err
+		}
+		if req.Status != models.RequestStatusPending {
+			return errors.New("only pending request can be updated")
+		}
+		if input.FlightNo != nil {
+			req.FlightNo = *input.FlightNo
+		}
+		if input.ArrivalDate != nil {
+			arrivalDate, err := time.Parse("2006-01-02", *input.ArrivalDate)
+			if err != nil {
+				return err
+			}
+			// 与创建时一致，到达日期须在需求所属活动的日期范围内。
+			if req.CampaignID != nil {
+				var campaign models.Campaign
+				if err := tx.First(&campaign, *req.CampaignID).Error; err != nil {
+					return err
+				}
+				if arrivalDate.Before(dateOnly(campaign.StartDate)) || arrivalDate.After(dateOnly(campaign.EndDate)) {
+					return ErrArrivalOutsideCampaign
+				}
+			}
+			req.ArrivalDate = arrivalDate
+		}
+		if input.Airport != nil || input.Terminal != nil {
+			if input.Airport != nil {
+				req.Airport = *input.Airport
+			}
+			if input.Terminal != nil {
+				req.Terminal = *input.Terminal
+			}
+			buffers, err := LoadTerminalBuffers(tx)
+			if err != nil {
+				return err
+			}
+			req.Airport, req.PickupBuffer = buffers.Lookup(req.Airport, req.Terminal)
+			if req.ArrivalTimeAPI != nil {
+				pickup := req.ArrivalTimeAPI.Add(time.Duration(req.PickupBuffer) * time.Minute)
+				req.CalcPickupTime = &pickup
+			}
+		}
+		if input.Passengers != nil {
+			if !validPassengers(*input.Passengers) {
+				return ErrInvalidPassengers
+			}
+			req.Passengers = *input.Passengers
+		}
+		if input.CheckedBags != nil {
+			if *input.CheckedBags < 0 {
+				return ErrNegativeCheckedBags
+			}
+			req.CheckedBags = *input.CheckedBags
+		}
+		if input.CarryOnBags != nil {
+			if *input.CarryOnBags < 0 {
+				return ErrNegativeCarryOnBags
+			}
+			req.CarryOnBags = *input.CarryOnBags
+		}
+		if input.ExpectedArrivalTime != nil {
+			expectedArrivalTime, err := time.ParseInLocation("2006-01-02 15:04:05", *input.ExpectedArrivalTime, s.loc)
+			if err != nil {
+				return err
+			}
+			req.ArrivalTimeAPI = &expectedArrivalTime
+			pickup := expectedArrivalTime.Add(time.Duration(req.PickupBuffer) * time.Minute)
 			req.CalcPickupTime = &pickup
 		}
+		req.Version++
+		return tx.Omit(clause.Associations).Save(&req).Error
+	})
+	if err != nil {
+		return nil, err
 	}
-	if input.CheckedBags != nil {
-		req.CheckedBags = *input.CheckedBags
-	}
-	if input.CarryOnBags != nil {
-		req.CarryOnBags = *input.CarryOnBags
-	}
-	if input.ExpectedArrivalTime != nil {
-		expectedArrivalTime, err := time.Parse("2006-01-02 15:04:05", *input.ExpectedArrivalTime)
-		if err != nil {
-			return nil, err
+	s.broker.Publish(events.RequestUpdated, requestEvent(&req))
+	return &req, nil
+}
+
+// CancelRequest 学生撤销自己的需求；已分配/已发布的需求在同一事务内从班次解绑。
+// 班次已出发（in_progress/completed）后不可撤销。
+func (s *StudentService) CancelRequest(userID, requestID uint) (*models.Request, error) {
+	var req models.Request
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", requestID, userID).
+			First(&req).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRequestNotFound
+			}
+			return err
 		}
-		req.ArrivalTimeAPI = &expectedArrivalTime
-		pickup := expectedArrivalTime.Add(time.Duration(req.PickupBuffer) * time.Minute)
-		req.CalcPickupTime = &pickup
-	}
-	if err := s.db.Omit(clause.Associations).Save(&req).Error; err != nil {
+		if req.Status == models.RequestStatusCanceled {
+			return ErrRequestAlreadyCanceled
+		}
+
+		if req.Status != models.RequestStatusPending {
+			var departed int64
+			if err := tx.Table("shift_requests sr").
+				Joins("JOIN shifts s ON s.id = sr.shift_id").
+				Where("sr.request_id = ? AND s.status IN ?", requestID,
+					[]models.ShiftStatus{models.ShiftStatusInProgress, models.ShiftStatusCompleted}).
+				Count(&departed).Error; err != nil {
+				return err
+			}
+			if departed > 0 {
+				return ErrRequestNotCancelable
+			}
+			if err := tx.Where("request_id = ?", requestID).Delete(&models.ShiftRequest{}).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.Request{}).Where("id = ?", requestID).Updates(map[string]any{
+			"status":      models.RequestStatusCanceled,
+			"canceled_at": now,
+			"version":     bumpVersion,
+		}).Error; err != nil {
+			return err
+		}
+		req.Status = models.RequestStatusCanceled
+		req.CanceledAt = &now
+		req.Version++
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
+	s.broker.Publish(events.RequestUpdated, requestEvent(&req))
 	return &req, nil
 }
@@ -13,7 +13,8 @@ import (
 
 func TestStudentService_CreateRequest_Success(t *testing.T) {
 	db := newTestDB(t)
-	svc := NewStudentService(db)
+	seedTerminals(t, db)
+	svc := NewStudentService(db, nil, time.UTC)
 
 	res, err := svc.CreateRequest(1, CreateRequestInput{
 		FlightNo:            "AA101",
@@ -34,7 +35,7 @@ func TestStudentService_CreateRequest_Success(t *testing.T) {
 
 func TestStudentService_CreateRequest_InvalidInput(t *testing.T) {
 	db := newTestDB(t)
-	svc := NewStudentService(db)
+	svc := NewStudentService(db, nil, time.UTC)
 
 	_, err := svc.CreateRequest(1, CreateRequestInput{
 		FlightNo:            "AA101",
@@ -51,11 +52,20 @@ func TestStudentService_CreateRequest_InvalidInput(t *testing.T) {
 		ExpectedArrivalTime: "bad-time",
 	})
 	assert.Error(t, err)
+
+	_, err = svc.CreateRequest(1, CreateRequestInput{
+		FlightNo:            "AA101",
+		ArrivalDate:         "2026-03-01",
+		Terminal:            "T1",
+		Passengers:          MaxPassengersPerRequest + 1,
+		ExpectedArrivalTime: "2026-03-01 10:30:00",
+	})
+	assert.ErrorIs(t, err, ErrInvalidPassengers)
 }
 
 func TestStudentService_CreateRequest_OnlyOncePerUser(t *testing.T) {
 	db := newTestDB(t)
-	svc := NewStudentService(db)
+	svc := NewStudentService(db, nil, time.UTC)
 
 	_, err := svc.CreateRequest(7, CreateRequestInput{
 		FlightNo:            "AA101",
@@ -76,7 +86,8 @@ func TestStudentService_CreateRequest_OnlyOncePerUser(t *testing.T) {
 
 func TestStudentService_UpdatePendingRequest_EdgeCases(t *testing.T) {
 	db := newTestDB(t)
-	svc := NewStudentService(db)
+	seedTerminals(t, db)
+	svc := NewStudentService(db, nil, time.UTC)
 
 	arrival := time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)
 	pickup := arrival.Add(45 * time.Minute)
@@ -104,6 +115,15 @@ func TestStudentService_UpdatePendingRequest_EdgeCases(t *testing.T) {
 	require.NotNil(t, updated.CalcPickupTime)
 	assert.Equal(t, updated.ArrivalTimeAPI.Add(90*time.Minute), *updated.CalcPickupTime)
 
+	negative := -1
+	_, err = svc.UpdatePendingRequest(1, req.ID, UpdateRequestInput{CheckedBags: &negative})
+	assert.ErrorIs(t, err, ErrNegativeCheckedBags)
+	_, err = svc.UpdatePendingRequest(1, req.ID, UpdateRequestInput{CarryOnBags: &negative})
+	assert.ErrorIs(t, err, ErrNegativeCarryOnBags)
+	var stored models.Request
+	require.NoError(t, db.First(&stored, req.ID).Error)
+	assert.Equal(t, updated.Version, stored.Version)
+
 	req2 := models.Request{UserID: 1, FlightNo: "AA102", ArrivalDate: time.Now(), Terminal: "T1", Status: models.RequestStatusAssigned}
 	require.NoError(t, db.Omit(clause.Associations).Create(&req2).Error)
 	_, err = svc.UpdatePendingRequest(1, req2.ID, UpdateRequestInput{})
@@ -112,7 +132,7 @@ func TestStudentService_UpdatePendingRequest_EdgeCases(t *testing.T) {
 
 func TestStudentService_ListMyRequests_HideShiftForNonPublished(t *testing.T) {
 	db := newTestDB(t)
-	svc := NewStudentService(db)
+	svc := NewStudentService(db, nil, time.UTC)
 
 	driver := models.Driver{Name: "d1", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
 	require.NoError(t, db.Create(&driver).Error)
@@ -139,3 +159,53 @@ func TestStudentService_ListMyRequests_HideShiftForNonPublished(t *testing.T) {
 		}
 	}
 }
+
+func TestStudentService_CancelRequest(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewStudentService(db, nil, time.UTC)
+
+	driver := models.Driver{Name: "d1", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	shift := models.Shift{DriverID: driver.ID, DepartureTime: time.Now(), Status: models.ShiftStatusPublished}
+	require.NoError(t, db.Create(&shift).Error)
+
+	req, err := svc.CreateRequest(7, CreateRequestInput{
+		FlightNo:            "AA101",
+		ArrivalDate:         "2026-03-01",
+		Terminal:            "T1",
+		ExpectedArrivalTime: "2026-03-01 10:30:00",
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.Request{}).Where("id = ?", req.ID).Update("status", models.RequestStatusPublished).Error)
+	require.NoError(t, db.Table("shift_requests").Create(map[string]any{"shift_id": shift.ID, "request_id": req.ID}).Error)
+
+	_, err = svc.CancelRequest(8, req.ID)
+	assert.ErrorIs(t, err, ErrRequestNotFound)
+
+	canceled, err := svc.CancelRequest(7, req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RequestStatusCanceled, canceled.Status)
+	require.NotNil(t, canceled.CanceledAt)
+
+	var bound int64
+	require.NoError(t, db.Model(&models.ShiftRequest{}).Where("request_id = ?", req.ID).Count(&bound).Error)
+	assert.Zero(t, bound)
+
+	_, err = svc.CancelRequest(7, req.ID)
+	assert.ErrorIs(t, err, ErrRequestAlreadyCanceled)
+
+	// 已撤销的需求不阻止重新提交
+	next, err := svc.CreateRequest(7, CreateRequestInput{
+		FlightNo:            "AA102",
+		ArrivalDate:         "2026-03-02",
+		Terminal:            "T1",
+		ExpectedArrivalTime: "2026-03-02 11:30:00",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.Model(&models.Request{}).Where("id = ?", next.ID).Update("status", models.RequestStatusPublished).Error)
+	require.NoError(t, db.Table("shift_requests").Create(map[string]any{"shift_id": shift.ID, "request_id": next.ID}).Error)
+	require.NoError(t, db.Model(&models.Shift{}).Where("id = ?", shift.ID).Update("status", models.ShiftStatusInProgress).Error)
+	_, err = svc.CancelRequest(7, next.ID)
+	assert.ErrorIs(t, err, ErrRequestNotCancelable)
+}
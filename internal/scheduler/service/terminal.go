@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"pickup/internal/scheduler/models"
+
+	"gorm.io/gorm"
+)
+
+// DefaultPickupBuffer 未配置航站楼时的接机缓冲（分钟）。
+const DefaultPickupBuffer = 45
+
+var (
+	ErrTerminalNotFound = errors.New("terminal not found")
+	ErrInvalidTerminal  = errors.New("airport_code and terminal are required and buffer_minutes must not be negative")
+)
+
+type TerminalDTO struct {
+	AirportCode   string
+	Terminal      string
+	International bool
+	BufferMinutes int
+	MeetingPoint  string
+}
+
+func (d TerminalDTO) normalize() (TerminalDTO, error) {
+	d.AirportCode = strings.ToUpper(strings.TrimSpace(d.AirportCode))
+	d.Terminal = strings.ToUpper(strings.TrimSpace(d.Terminal))
+	d.MeetingPoint = strings.TrimSpace(d.MeetingPoint)
+	if d.AirportCode == "" || d.Terminal == "" || d.BufferMinutes < 0 {
+		return d, ErrInvalidTerminal
+	}
+	return d, nil
+}
+
+// TerminalBuffers 航站楼缓冲索引，供学生提交与航班同步共用。
+type TerminalBuffers struct {
+	byKey      map[string]models.AirportTerminal
+	byTerminal map[string]models.AirportTerminal
+}
+
+// LoadTerminalBuffers 读取全部航站楼配置；同名航站楼仅按 id 最小的一条响应无机场代码的查询。
+func LoadTerminalBuffers(db *gorm.DB) (*TerminalBuffers, error) {
+	var rows []models.AirportTerminal
+	if err := db.Order("id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	buffers := &TerminalBuffers{
+		byKey:      make(map[string]models.AirportTerminal, len(rows)),
+		byTerminal: make(map[string]models.AirportTerminal, len(rows)),
+	}
+	for _, row := range rows {
+		buffers.byKey[row.AirportCode+"/"+row.Terminal] = row
+		if _, ok := buffers.byTerminal[row.Terminal]; !ok {
+			buffers.byTerminal[row.Terminal] = row
+		}
+	}
+	return buffers, nil
+}
+
+// Lookup 返回需求应记录的机场代码与接机缓冲。
+// 未提供机场时按航站楼补全机场；均未命中时沿用传入机场并使用 DefaultPickupBuffer。
+func (b *TerminalBuffers) Lookup(airport, terminal string) (string, int) {
+	airport = strings.ToUpper(strings.TrimSpace(airport))
+	terminal = strings.ToUpper(strings.TrimSpace(terminal))
+	if airport != "" {
+		if row, ok := b.byKey[airport+"/"+terminal]; ok {
+			return row.AirportCode, row.BufferMinutes
+		}
+		return airport, DefaultPickupBuffer
+	}
+	if row, ok := b.byTerminal[terminal]; ok {
+		return row.AirportCode, row.BufferMinutes
+	}
+	return "", DefaultPickupBuffer
+}
+
+func (s *AdminService) ListTerminals() ([]models.AirportTerminal, error) {
+	var items []models.AirportTerminal
+	err := s.db.Order("airport_code ASC, terminal ASC").Find(&items).Error
+	return items, err
+}
+
+func (s *AdminService) CreateTerminal(input TerminalDTO) (*models.AirportTerminal, error) {
+	input, err := input.normalize()
+	if err != nil {
+		return nil, err
+	}
+	terminal := models.AirportTerminal{
+		AirportCode:   input.AirportCode,
+		Terminal:      input.Terminal,
+		International: input.International,
+		BufferMinutes: input.BufferMinutes,
+		MeetingPoint:  input.MeetingPoint,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&terminal).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, s.actor, "terminal.create", auditEntityTerminal, terminal.ID, nil, terminal)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &terminal, nil
+}
+
+// UpdateTerminal 修改缓冲只影响之后提交或同步的需求，已计算的接机时间不回溯。
+func (s *AdminService) UpdateTerminal(terminalID uint, input TerminalDTO) (*models.AirportTerminal, error) {
+	input, err := input.normalize()
+	if err != nil {
+		return nil, err
+	}
+	updates := map[string]any{
+		"airport_code":   input.AirportCode,
+		"terminal":       input.Terminal,
+		"international":  input.International,
+		"buffer_minutes": input.BufferMinutes,
+		"meeting_point":  input.MeetingPoint,
+	}
+	var terminal models.AirportTerminal
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var before models.AirportTerminal
+		if err := tx.First(&before, terminalID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTerminalNotFound
+			}
+			return err
+		}
+		if err := tx.Model(&models.AirportTerminal{}).Where("id = ?", terminalID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&terminal, terminalID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, s.actor, "terminal.update", auditEntityTerminal, terminalID, before, terminal)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &terminal, nil
+}
+
+func (s *AdminService) DeleteTerminal(terminalID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var before models.AirportTerminal
+		if err := tx.First(&before, terminalID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTerminalNotFound
+			}
+			return err
+		}
+		if err := tx.Delete(&models.AirportTerminal{}, terminalID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, s.actor, "terminal.delete", auditEntityTerminal, terminalID, before, nil)
+	})
+}
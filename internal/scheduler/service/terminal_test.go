@@ -0,0 +1,92 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminService_TerminalCRUD(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC).WithActor(Actor{UserID: 1, Role: "admin"})
+
+	_, err := svc.CreateTerminal(TerminalDTO{AirportCode: "ord", Terminal: " ", BufferMinutes: 45})
+	assert.ErrorIs(t, err, ErrInvalidTerminal)
+	_, err = svc.CreateTerminal(TerminalDTO{AirportCode: "ORD", Terminal: "T1", BufferMinutes: -1})
+	assert.ErrorIs(t, err, ErrInvalidTerminal)
+
+	created, err := svc.CreateTerminal(TerminalDTO{AirportCode: " cmi ", Terminal: "main", BufferMinutes: 30, MeetingPoint: "Baggage claim exit"})
+	require.NoError(t, err)
+	assert.Equal(t, "CMI", created.AirportCode)
+	assert.Equal(t, "MAIN", created.Terminal)
+
+	_, err = svc.CreateTerminal(TerminalDTO{AirportCode: "CMI", Terminal: "MAIN", BufferMinutes: 30})
+	assert.Error(t, err)
+
+	updated, err := svc.UpdateTerminal(created.ID, TerminalDTO{AirportCode: "CMI", Terminal: "MAIN", International: true, BufferMinutes: 60})
+	require.NoError(t, err)
+	assert.True(t, updated.International)
+	assert.Equal(t, 60, updated.BufferMinutes)
+	_, err = svc.UpdateTerminal(999, TerminalDTO{AirportCode: "CMI", Terminal: "MAIN"})
+	assert.ErrorIs(t, err, ErrTerminalNotFound)
+
+	items, err := svc.ListTerminals()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+
+	require.NoError(t, svc.DeleteTerminal(created.ID))
+	assert.ErrorIs(t, svc.DeleteTerminal(created.ID), ErrTerminalNotFound)
+
+	var actions []string
+	require.NoError(t, db.Model(&models.AuditLog{}).Where("entity_type = ?", auditEntityTerminal).Order("id").Pluck("action", &actions).Error)
+	assert.Equal(t, []string{"terminal.create", "terminal.update", "terminal.delete"}, actions)
+}
+
+func TestTerminalBuffers_Lookup(t *testing.T) {
+	db := newTestDB(t)
+	seedTerminals(t, db)
+	require.NoError(t, db.Create(&models.AirportTerminal{AirportCode: "CMI", Terminal: "T1", BufferMinutes: 20}).Error)
+
+	buffers, err := LoadTerminalBuffers(db)
+	require.NoError(t, err)
+
+	cases := []struct {
+		airport, terminal string
+		wantAirport       string
+		wantBuffer        int
+	}{
+		{"ORD", "T5", "ORD", 90},
+		{"", "t5", "ORD", 90},
+		{"cmi", "T1", "CMI", 20},
+		{"", "T1", "ORD", 45},
+		{"MDW", "T1", "MDW", DefaultPickupBuffer},
+		{"", "X", "", DefaultPickupBuffer},
+	}
+	for _, tc := range cases {
+		airport, buffer := buffers.Lookup(tc.airport, tc.terminal)
+		assert.Equal(t, tc.wantAirport, airport, tc.airport+"/"+tc.terminal)
+		assert.Equal(t, tc.wantBuffer, buffer, tc.airport+"/"+tc.terminal)
+	}
+
+	student := NewStudentService(db, nil, time.UTC)
+	req, err := student.CreateRequest(1, CreateRequestInput{
+		FlightNo:            "AA3321",
+		ArrivalDate:         "2026-08-20",
+		Airport:             "CMI",
+		Terminal:            "T1",
+		ExpectedArrivalTime: "2026-08-20 10:00:00",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "CMI", req.Airport)
+	assert.Equal(t, 20, req.PickupBuffer)
+
+	airport := "ORD"
+	req, err = student.UpdatePendingRequest(1, req.ID, UpdateRequestInput{Airport: &airport})
+	require.NoError(t, err)
+	assert.Equal(t, "ORD", req.Airport)
+	assert.Equal(t, 45, req.PickupBuffer)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"testing"
 
+	"pickup/internal/scheduler/models"
+
 	"github.com/glebarez/sqlite"
 	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
@@ -31,20 +33,29 @@ func newTestDB(t *testing.T) *gorm.DB {
 			car_model TEXT NOT NULL,
 			max_seats INTEGER NOT NULL,
 			max_checked INTEGER NOT NULL,
-			max_carry_on INTEGER NOT NULL
+			max_carry_on INTEGER NOT NULL,
+			campaign_id INTEGER,
+			user_id INTEGER UNIQUE,
+			archived_at DATETIME,
+			version INTEGER NOT NULL DEFAULT 0
 		);`,
 		`CREATE TABLE requests (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			user_id INTEGER NOT NULL,
 			flight_no TEXT NOT NULL,
 			arrival_date DATETIME NOT NULL,
+			airport TEXT NOT NULL DEFAULT '',
 			terminal TEXT NOT NULL,
+			passengers INTEGER NOT NULL DEFAULT 1,
 			checked_bags INTEGER NOT NULL DEFAULT 0,
 			carry_on_bags INTEGER NOT NULL DEFAULT 0,
 			status TEXT NOT NULL DEFAULT 'pending',
 			arrival_time_api DATETIME,
 			pickup_buffer INTEGER NOT NULL DEFAULT 45,
 			calc_pickup_time DATETIME,
+			canceled_at DATETIME,
+			campaign_id INTEGER,
+			version INTEGER NOT NULL DEFAULT 0,
 			created_at DATETIME,
 			updated_at DATETIME
 		);`,
@@ -52,12 +63,26 @@ func newTestDB(t *testing.T) *gorm.DB {
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			driver_id INTEGER NOT NULL,
 			departure_time DATETIME NOT NULL,
+			estimated_minutes INTEGER NOT NULL DEFAULT 120,
 			status TEXT NOT NULL DEFAULT 'draft',
+			started_at DATETIME,
+			completed_at DATETIME,
+			capacity_policy TEXT,
+			driver_status TEXT NOT NULL DEFAULT 'pending',
+			driver_responded_at DATETIME,
+			driver_note TEXT NOT NULL DEFAULT '',
+			campaign_id INTEGER,
+			schedule_seq INTEGER NOT NULL DEFAULT 0,
+			version INTEGER NOT NULL DEFAULT 0,
 			created_at DATETIME
 		);`,
 		`CREATE TABLE shift_requests (
 			shift_id INTEGER NOT NULL,
 			request_id INTEGER NOT NULL UNIQUE,
+			boarding_status TEXT NOT NULL DEFAULT 'waiting',
+			checked_in_at DATETIME,
+			checked_in_by INTEGER,
+			overload_reason TEXT,
 			PRIMARY KEY (shift_id, request_id)
 		);`,
 		`CREATE TABLE shift_staffs (
@@ -65,6 +90,99 @@ func newTestDB(t *testing.T) *gorm.DB {
 			staff_id INTEGER NOT NULL,
 			PRIMARY KEY (shift_id, staff_id)
 		);`,
+		`CREATE TABLE shift_conflicts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			shift_id INTEGER NOT NULL,
+			request_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			calc_pickup_time DATETIME NOT NULL,
+			departure_time DATETIME NOT NULL,
+			delta_minutes INTEGER NOT NULL,
+			detected_at DATETIME NOT NULL,
+			resolved_at DATETIME
+		);`,
+		`CREATE TABLE audit_logs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			actor_id INTEGER NOT NULL,
+			actor_role TEXT NOT NULL DEFAULT '',
+			action TEXT NOT NULL,
+			entity_type TEXT NOT NULL,
+			entity_id INTEGER NOT NULL,
+			before_data TEXT,
+			after_data TEXT,
+			request_id TEXT NOT NULL DEFAULT '',
+			created_at DATETIME
+		);`,
+		`CREATE TABLE notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			request_id INTEGER NOT NULL,
+			shift_id INTEGER NOT NULL,
+			event TEXT NOT NULL,
+			template_id TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL,
+			err_code INTEGER NOT NULL DEFAULT 0,
+			err_msg TEXT NOT NULL DEFAULT '',
+			created_at DATETIME
+		);`,
+		`CREATE TABLE driver_availabilities (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			driver_id INTEGER NOT NULL,
+			start_time DATETIME NOT NULL,
+			end_time DATETIME NOT NULL,
+			source TEXT NOT NULL DEFAULT 'admin',
+			note TEXT NOT NULL DEFAULT '',
+			created_at DATETIME
+		);`,
+		`CREATE TABLE staff_availabilities (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			staff_id INTEGER NOT NULL,
+			start_time DATETIME NOT NULL,
+			end_time DATETIME NOT NULL,
+			source TEXT NOT NULL DEFAULT 'admin',
+			note TEXT NOT NULL DEFAULT '',
+			created_at DATETIME
+		);`,
+		`CREATE TABLE campaigns (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			start_date DATETIME NOT NULL,
+			end_date DATETIME NOT NULL,
+			status TEXT NOT NULL DEFAULT 'open',
+			active BOOLEAN NOT NULL DEFAULT 0,
+			created_at DATETIME,
+			updated_at DATETIME
+		);`,
+		`CREATE TABLE calendar_feeds (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL UNIQUE,
+			nonce TEXT NOT NULL,
+			created_at DATETIME
+		);`,
+		`CREATE TABLE idempotency_keys (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			idempotency_key TEXT NOT NULL,
+			route TEXT NOT NULL,
+			fingerprint TEXT NOT NULL,
+			status_code INTEGER NOT NULL DEFAULT 0,
+			headers TEXT,
+			body BLOB,
+			expires_at DATETIME NOT NULL,
+			created_at DATETIME,
+			UNIQUE (user_id, idempotency_key, route)
+		);`,
+		`CREATE TABLE airport_terminals (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			airport_code TEXT NOT NULL,
+			terminal TEXT NOT NULL,
+			international BOOLEAN NOT NULL DEFAULT 0,
+			buffer_minutes INTEGER NOT NULL DEFAULT 45,
+			meeting_point TEXT NOT NULL DEFAULT '',
+			created_at DATETIME,
+			updated_at DATETIME,
+			UNIQUE (airport_code, terminal)
+		);`,
 	}
 
 	for _, ddl := range schema {
@@ -73,3 +191,10 @@ func newTestDB(t *testing.T) *gorm.DB {
 
 	return db
 }
+
+// seedTerminals 写入与 AutoMigrate 相同的默认航站楼配置。
+func seedTerminals(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	defaults := models.DefaultAirportTerminals()
+	require.NoError(t, db.Create(&defaults).Error)
+}
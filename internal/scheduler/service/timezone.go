@@ -0,0 +1,26 @@
+package service
+
+import (
+	"time"
+	// 运行环境可能没有系统时区库，内置一份保证 LoadLocation 可用。
+	_ "time/tzdata"
+
+	"pickup/internal/config"
+)
+
+// NewSchedulerLocation 调度业务时区：录入的出发/到达时间、日期窗口与日历订阅都按它解释；
+// 配置无效时退回服务器本地时区。
+func NewSchedulerLocation(cfg *config.SchedulerConfig) *time.Location {
+	if cfg != nil && cfg.Timezone != "" {
+		if loc, err := time.LoadLocation(cfg.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// startOfDay t 在 loc 中所在日期的零点；按天查询的窗口为 [startOfDay, 次日零点)。
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pickup/internal/config"
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/clause"
+)
+
+func TestNewSchedulerLocation(t *testing.T) {
+	assert.Equal(t, "America/Chicago", NewSchedulerLocation(&config.SchedulerConfig{Timezone: "America/Chicago"}).String())
+	assert.Equal(t, time.Local, NewSchedulerLocation(&config.SchedulerConfig{Timezone: "Mars/Olympus"}))
+	assert.Equal(t, time.Local, NewSchedulerLocation(nil))
+}
+
+func TestDayWindowsUseSchedulerTimezone(t *testing.T) {
+	db := newTestDB(t)
+	chicago := NewSchedulerLocation(&config.SchedulerConfig{Timezone: "America/Chicago"})
+	admin := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, chicago)
+	staffSvc := NewStaffService(db, nil, chicago)
+
+	staff := models.User{OpenID: "staff", Name: "s", Role: models.UserRoleStaff}
+	li := models.User{OpenID: "li", Name: "Li", Phone: "13800000000", Role: models.UserRoleStudent}
+	for _, u := range []*models.User{&staff, &li} {
+		require.NoError(t, db.Create(u).Error)
+	}
+	driver := models.Driver{Name: "d", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4}
+	require.NoError(t, db.Create(&driver).Error)
+	// 芝加哥 8 月 20 日 23:30 出发，UTC 已是 21 日。
+	departure := time.Date(2026, 8, 20, 23, 30, 0, 0, chicago)
+	shift := models.Shift{DriverID: driver.ID, DepartureTime: departure, Status: models.ShiftStatusPublished}
+	require.NoError(t, db.Create(&shift).Error)
+	req := models.Request{UserID: li.ID, FlightNo: "AA1", ArrivalDate: dateOnly(departure), Terminal: "T1", Status: models.RequestStatusPublished}
+	require.NoError(t, db.Omit(clause.Associations).Create(&req).Error)
+	require.NoError(t, db.Table("shift_requests").Create(map[string]any{"shift_id": shift.ID, "request_id": req.ID, "boarding_status": models.BoardingStatusNoShow}).Error)
+	require.NoError(t, db.Table("shift_staffs").Create(map[string]any{"shift_id": shift.ID, "staff_id": staff.ID}).Error)
+
+	// UTC 21 日凌晨即芝加哥 20 日晚上，按芝加哥的 20 日划分；三处对同一天的划分一致。
+	day := time.Date(2026, 8, 21, 3, 0, 0, 0, time.UTC)
+	next := day.AddDate(0, 0, 1)
+
+	rows, err := admin.ExportManifests(day, false)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "2026-08-20 23:30", rows[0].DepartureTime.Format("2006-01-02 15:04"))
+	rows, err = staffSvc.ExportManifests(staff.ID, day, false)
+	require.NoError(t, err)
+	assert.Len(t, rows, 1)
+	noShows, err := admin.NoShowReport(day, day)
+	require.NoError(t, err)
+	assert.Len(t, noShows, 1)
+	schedule, err := admin.StaffSchedule(staff.ID, day)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-08-20", schedule.Date)
+	assert.Len(t, schedule.Shifts, 1)
+
+	rows, err = admin.ExportManifests(next, false)
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+	noShows, err = admin.NoShowReport(next, next)
+	require.NoError(t, err)
+	assert.Empty(t, noShows)
+	schedule, err = admin.StaffSchedule(staff.ID, next)
+	require.NoError(t, err)
+	assert.Empty(t, schedule.Shifts)
+}
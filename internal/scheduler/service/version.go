@@ -0,0 +1,21 @@
+package service
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrVersionMismatch 客户端持有的版本（If-Match）已过期，需重新读取后再提交。
+var ErrVersionMismatch = errors.New("resource has been modified by someone else; reload and retry")
+
+// bumpVersion 写入 shifts/requests/drivers 时一并使 version 加一，使旧 ETag 失效。
+var bumpVersion = gorm.Expr("version + 1")
+
+// checkVersion 在已加锁读出当前行后调用，保证比较与写入之间不会被其他事务插入；expected 为 nil 时不校验。
+func checkVersion(current uint, expected *uint) error {
+	if expected != nil && current != *expected {
+		return ErrVersionMismatch
+	}
+	return nil
+}
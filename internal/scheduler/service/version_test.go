@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pickup/internal/scheduler/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion_StaleWritesRejected(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAdminService(db, NewShiftAssignmentService(db, nil), nil, nil, time.UTC)
+	student := NewStudentService(db, nil, time.UTC)
+	version := func(v uint) *uint { return &v }
+
+	driver, err := svc.CreateDriver(DriverDTO{Name: "d1", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4})
+	require.NoError(t, err)
+	assert.Zero(t, driver.Version)
+	driver, err = svc.UpdateDriver(driver.ID, DriverDTO{Name: "d1", CarModel: "Van", MaxSeats: 6, MaxChecked: 6, MaxCarryOn: 6, IfMatch: version(0)})
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), driver.Version)
+	// 另一位管理员仍持有版本 0，提交被拒且不覆盖。
+	_, err = svc.UpdateDriver(driver.ID, DriverDTO{Name: "stale", CarModel: "SUV", MaxSeats: 4, MaxChecked: 4, MaxCarryOn: 4, IfMatch: version(0)})
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	var stored models.Driver
+	require.NoError(t, db.First(&stored, driver.ID).Error)
+	assert.Equal(t, "Van", stored.CarModel)
+	_, err = svc.UpdateDriver(999, DriverDTO{Name: "x", IfMatch: version(0)})
+	assert.ErrorIs(t, err, ErrDriverNotFound)
+
+	shift, err := svc.CreateShift(driver.ID, time.Date(2026, 8, 20, 10, 0, 0, 0, time.UTC), 0)
+	require.NoError(t, err)
+	req, err := student.CreateRequest(2, CreateRequestInput{FlightNo: "AA1", ArrivalDate: "2026-08-20", Terminal: "T1", ExpectedArrivalTime: "2026-08-20 09:00:00"})
+	require.NoError(t, err)
+	_, err = svc.AssignStudent(shift.ID, req.ID, "")
+	require.NoError(t, err)
+	require.NoError(t, svc.PublishShift(shift.ID))
+
+	// 发布同样改变了班次与需求，发布前读到的版本已过期。
+	minutes := 90
+	_, err = svc.UpdateShift(shift.ID, ShiftUpdateDTO{EstimatedMinutes: &minutes, IfMatch: version(0)})
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	updated, err := svc.UpdateShift(shift.ID, ShiftUpdateDTO{EstimatedMinutes: &minutes, IfMatch: version(1)})
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), updated.Version)
+
+	passengers := 2
+	_, err = svc.UpdateRequest(req.ID, RequestUpdateDTO{Passengers: &passengers, IfMatch: version(0)})
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	result, err := svc.UpdateRequest(req.ID, RequestUpdateDTO{Passengers: &passengers, IfMatch: version(2)})
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), result.Request.Version)
+
+	require.NoError(t, svc.RemoveStudent(shift.ID, req.ID))
+	terminal := "T5"
+	_, err = student.UpdatePendingRequest(2, req.ID, UpdateRequestInput{Terminal: &terminal, IfMatch: version(3)})
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	own, err := student.UpdatePendingRequest(2, req.ID, UpdateRequestInput{Terminal: &terminal, IfMatch: version(4)})
+	require.NoError(t, err)
+	assert.Equal(t, uint(5), own.Version)
+	canceled, err := student.CancelRequest(2, req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint(6), canceled.Version)
+}
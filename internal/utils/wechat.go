@@ -160,3 +160,54 @@ func (w *WechatClient) GetPhoneNumber(accessToken, code string) (*GetPhoneNumber
 
 	return &result, nil
 }
+
+// SubscribeMessageValue 订阅消息模板字段值。
+type SubscribeMessageValue struct {
+	Value string `json:"value"`
+}
+
+// SubscribeMessage 小程序订阅消息请求体。
+type SubscribeMessage struct {
+	ToUser           string                           `json:"touser"`
+	TemplateID       string                           `json:"template_id"`
+	Page             string                           `json:"page,omitempty"`
+	MiniprogramState string                           `json:"miniprogram_state,omitempty"`
+	Lang             string                           `json:"lang,omitempty"`
+	Data             map[string]SubscribeMessageValue `json:"data"`
+}
+
+// SubscribeMessageResponse 订阅消息发送响应
+type SubscribeMessageResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// SendSubscribeMessage 发送小程序订阅消息（需要access_token）。
+// errcode 非 0 时同时返回响应与错误，便于调用方记录失败原因。
+func (w *WechatClient) SendSubscribeMessage(accessToken string, msg SubscribeMessage) (*SubscribeMessageResponse, error) {
+	jsonBody, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.Post(
+		fmt.Sprintf("%s/cgi-bin/message/subscribe/send?access_token=%s", w.baseURL, accessToken),
+		"application/json",
+		bytes.NewBuffer(jsonBody),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result SubscribeMessageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if result.ErrCode != 0 {
+		return &result, fmt.Errorf("wechat api error: %d - %s", result.ErrCode, result.ErrMsg)
+	}
+
+	return &result, nil
+}
@@ -47,3 +47,39 @@ func TestWechatClient_GetAccessTokenErrorBranch(t *testing.T) {
 	_, err := client.GetAccessToken()
 	assert.Error(t, err)
 }
+
+func TestWechatClient_SendSubscribeMessage(t *testing.T) {
+	client := NewWechatClient("app", "secret")
+
+	var got SubscribeMessage
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cgi-bin/message/subscribe/send", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "tok", r.URL.Query().Get("access_token"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		if got.ToUser == "blocked" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"errcode": 43101, "errmsg": "user refuse to accept the msg"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"errcode": 0, "errmsg": "ok"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client.SetBaseURL(server.URL)
+
+	msg := SubscribeMessage{
+		ToUser:     "oid",
+		TemplateID: "tpl",
+		Data:       map[string]SubscribeMessageValue{"thing1": {Value: "T5"}},
+	}
+	resp, err := client.SendSubscribeMessage("tok", msg)
+	require.NoError(t, err)
+	assert.Equal(t, 0, resp.ErrCode)
+	assert.Equal(t, "tpl", got.TemplateID)
+	assert.Equal(t, "T5", got.Data["thing1"].Value)
+
+	msg.ToUser = "blocked"
+	resp, err = client.SendSubscribeMessage("tok", msg)
+	assert.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, 43101, resp.ErrCode)
+}